@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"example.com/sa-gameshop/entity"
+	"example.com/sa-gameshop/services"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
@@ -392,12 +393,16 @@ func SetupDatabase() {
 		log.Fatal(err)
 	}
 
+	// รีแอคชันเดิมไม่มี unique ต้องเก็บกวาดก่อนสร้างดัชนี
+	cleanupLegacyReactions()
+
 	// เฟส 6: ตารางอื่น ๆ ที่อ้างอิง users (ตอนนี้โครง users เสถียรแล้ว)
 	if err := db.AutoMigrate(
 		&entity.Game{},
 		&entity.KeyGame{},
 		&entity.UserGame{},
 		&entity.Notification{},
+		&entity.NotificationPreference{},
 		&entity.Order{},
 		&entity.OrderItem{},
 		&entity.Payment{},
@@ -410,21 +415,296 @@ func SetupDatabase() {
 		&entity.ModRating{},
 		&entity.Thread{},
 		&entity.ThreadImage{},
+		&entity.ThreadFlair{},
 		&entity.Comment{},
-		&entity.ThreadLike{},
+		&entity.CommentRevision{},
+		&entity.ThreadSubscription{},
+		&entity.Reaction{},
+		&entity.ReactionCount{},
+		&entity.Attachment{},
+		&entity.ModerationLog{},
+		&entity.ContentReport{},
+		&entity.ModerationAppeal{},
+		&entity.UserRestriction{},
+
+
+
+		&entity.Review{}, // ★ ใช้ชื่อดัชนีใหม่แล้ว
+
+		&entity.Currency{},
+		&entity.Region{},
+		&entity.GameRegionPrice{},
+		&entity.GamePriceHistory{},
+		&entity.GameCurrentPrice{},
+		&entity.GameStatusLog{},
+		&entity.Tags{},
+		&entity.Company{},
+		&entity.Genre{},
+		&entity.Platform{},
+		&entity.Language{},
+		&entity.GameMedia{},
+		&entity.SystemRequirement{},
+		&entity.HardwareProfile{},
+		&entity.DataMigration{},
+		&entity.RatingSystem{},
+		&entity.RatingLevel{},
+		&entity.GameRating{},
+		&entity.UploadObject{},
+		&entity.StorageQuota{},
+		&entity.WishlistItem{},
+		&entity.Gift{},
+		&entity.RefundStatus{},
+		&entity.RefundRequest{},
+		&entity.RefundAttachment{},
+	); err != nil {
+		log.Fatal("auto migrate (others) failed: ", err)
+	}
 
+	// สถานะเกมแบบเดิม (pending/approve) → workflow ใหม่
+	migrateLegacyGameStatus()
 
+	// ThreadLike / Review_Like เดิม → reactions
+	migrateLegacyLikes()
 
-		&entity.Review{},      // ★ ใช้ชื่อดัชนีใหม่แล้ว
-		&entity.Review_Like{}, // ถ้ามี
-	); err != nil {
-		log.Fatal("auto migrate (others) failed: ", err)
+	// หมวดหลักเดิม (games.categories_id) → ตาราง game_categories
+	if err := db.Exec(`INSERT OR IGNORE INTO game_categories (game_id, categories_id)
+		SELECT id, categories_id FROM games WHERE categories_id > 0 AND deleted_at IS NULL`).Error; err != nil {
+		log.Println("backfill game_categories error:", err)
 	}
+	seedGameMetaIfNeeded()
+	backfillTaxonomySlugs()
+	backfillRenderedMarkdown()
+
+	// สกุลเงิน/ภูมิภาคพื้นฐาน (ต้องมีเสมอ ไม่ขึ้นกับว่ามีผู้ใช้แล้วหรือยัง)
+	seedRegionsIfNeeded()
+	seedRatingSystemsIfNeeded()
 
 	// เฟส 7: seed ข้อมูลตัวอย่าง ถ้ายังไม่มีผู้ใช้
 	seedIfNeededWithRoles(roleAdmin, roleUser)
 }
 
+// เดิมหน้าร้านแสดงเกมที่ status = 'approve' และเกมรอตรวจเป็น 'pending'
+func migrateLegacyGameStatus() {
+	if err := db.Exec("UPDATE games SET status = ? WHERE status = 'pending'", entity.GameSubmitted).Error; err != nil {
+		log.Println("migrate game status (pending) error:", err)
+	}
+	if err := db.Exec("UPDATE games SET status = ?, published_at = COALESCE(published_at, updated_at) WHERE status = 'approve'",
+		entity.GamePublished).Error; err != nil {
+		log.Println("migrate game status (approve) error:", err)
+	}
+}
+
+// ตาราง reactions เดิม: ชนิดอิสระ มี soft delete และกดซ้ำได้
+// ชนิดที่ไม่อยู่ในชุดใหม่ถือเป็น like แล้วลบแถวซ้ำ (เก็บแถวแรก)
+func cleanupLegacyReactions() {
+	if !tableExists("reactions") {
+		return
+	}
+	var cols []string
+	db.Raw("SELECT name FROM pragma_table_info('reactions')").Scan(&cols)
+	for _, c := range cols {
+		if c == "deleted_at" {
+			if err := db.Exec("DELETE FROM reactions WHERE deleted_at IS NOT NULL").Error; err != nil {
+				log.Println("cleanup reactions (deleted) error:", err)
+			}
+		}
+	}
+	keys := make([]string, 0, len(services.ReactionTypes))
+	for _, t := range services.ReactionTypes {
+		keys = append(keys, t.Key)
+	}
+	stmts := []struct {
+		sql  string
+		args []interface{}
+	}{
+		{"UPDATE reactions SET type = LOWER(TRIM(type)), target_type = LOWER(TRIM(target_type))", nil},
+		{"UPDATE reactions SET type = ? WHERE type IS NULL OR type NOT IN ?", []interface{}{services.ReactionLike, keys}},
+		{`DELETE FROM reactions WHERE id NOT IN (
+			SELECT MIN(id) FROM reactions GROUP BY target_type, target_id, user_id, type)`, nil},
+	}
+	for _, st := range stmts {
+		if err := db.Exec(st.sql, st.args...).Error; err != nil {
+			log.Println("cleanup reactions error:", err)
+		}
+	}
+}
+
+// ย้ายไลก์จาก thread_likes / review_likes เข้า reactions แล้วลบตารางเดิม
+// reaction_counts ว่างแต่มี reactions (เช่นข้อมูลก่อนมีตัวนับ) → คำนวณใหม่
+func migrateLegacyLikes() {
+	migrated := false
+	for _, legacy := range []struct{ table, target, col string }{
+		{"thread_likes", "thread", "thread_id"},
+		{"review_likes", "review", "review_id"},
+	} {
+		if !tableExists(legacy.table) {
+			continue
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(`INSERT OR IGNORE INTO reactions (created_at, target_type, target_id, user_id, type)
+				SELECT created_at, ?, `+legacy.col+`, user_id, ? FROM `+legacy.table+` WHERE deleted_at IS NULL`,
+				legacy.target, services.ReactionLike).Error; err != nil {
+				return err
+			}
+			return tx.Exec("DROP TABLE " + legacy.table).Error
+		})
+		if err != nil {
+			log.Println("migrate", legacy.table, "error:", err)
+			continue
+		}
+		migrated = true
+	}
+
+	var counted, reactions int64
+	db.Model(&entity.ReactionCount{}).Count(&counted)
+	db.Model(&entity.Reaction{}).Count(&reactions)
+	if migrated || (counted == 0 && reactions > 0) {
+		if err := services.RebuildReactionCounts(db); err != nil {
+			log.Println("rebuild reaction counts error:", err)
+		}
+	}
+}
+
+// หมวด/แท็กเดิมยังไม่มี slug
+func backfillTaxonomySlugs() {
+	for _, table := range []string{"categories", "tags"} {
+		var rows []struct {
+			ID    uint
+			Title string
+		}
+		db.Table(table).Where("slug IS NULL OR slug = ''").Order("id ASC").Scan(&rows)
+		for _, r := range rows {
+			slug := services.UniqueSlug(db, table, services.Slugify(r.Title), r.ID)
+			if err := db.Table(table).Where("id = ?", r.ID).Update("slug", slug).Error; err != nil {
+				log.Println("backfill slug error:", table, r.ID, err)
+			}
+		}
+	}
+}
+
+// โพสต์ก่อนมี Markdown ยังไม่มี HTML (ไม่แจ้งเตือน @mention ย้อนหลัง)
+func backfillRenderedMarkdown() {
+	for _, t := range []struct{ table, src, dst string }{
+		{"threads", "content", "content_html"},
+		{"comments", "content", "content_html"},
+		{"reviews", "review_text", "review_html"},
+	} {
+		var rows []struct {
+			ID  uint
+			Src string
+		}
+		db.Table(t.table).Select("id, "+t.src+" AS src").
+			// ยังไม่เคย render หรือ render ด้วยตัวเก่าที่หลุด slot (NUL) / ลิงก์ "/\"
+			Where("("+t.dst+" IS NULL OR "+t.dst+" = '' OR instr("+t.dst+", char(0)) > 0 OR "+t.dst+" LIKE ?) AND "+t.src+" <> ''", `%href="/\%`).
+			Order("id ASC").Scan(&rows)
+		for _, r := range rows {
+			html, _ := services.RenderMarkdown(db, r.Src)
+			if err := db.Table(t.table).Where("id = ?", r.ID).UpdateColumn(t.dst, html).Error; err != nil {
+				log.Println("backfill markdown error:", t.table, r.ID, err)
+			}
+		}
+	}
+}
+
+// แพลตฟอร์ม/ภาษาพื้นฐาน
+func seedGameMetaIfNeeded() {
+	platforms := []entity.Platform{
+		{Code: "windows", Name: "Windows"},
+		{Code: "macos", Name: "macOS"},
+		{Code: "linux", Name: "Linux"},
+	}
+	for _, p := range platforms {
+		if err := db.Where("code = ?", p.Code).FirstOrCreate(&p).Error; err != nil {
+			log.Println("seed platform error:", p.Code, err)
+		}
+	}
+	languages := []entity.Language{
+		{Code: "th", Name: "ไทย"},
+		{Code: "en", Name: "English"},
+		{Code: "ja", Name: "日本語"},
+	}
+	for _, l := range languages {
+		if err := db.Where("code = ?", l.Code).FirstOrCreate(&l).Error; err != nil {
+			log.Println("seed language error:", l.Code, err)
+		}
+	}
+}
+
+// ระบบเรตติ้งพื้นฐาน + ผูกกับภูมิภาคเริ่มต้นที่ยังไม่ได้ตั้ง
+func seedRatingSystemsIfNeeded() {
+	systems := []struct {
+		Code, Name, Region string
+		Levels             []entity.RatingLevel
+	}{
+		{"ESRB", "Entertainment Software Rating Board", "US", []entity.RatingLevel{
+			{Code: "E", Name: "Everyone", MinAge: 0},
+			{Code: "E10+", Name: "Everyone 10+", MinAge: 10},
+			{Code: "T", Name: "Teen", MinAge: 13},
+			{Code: "M", Name: "Mature 17+", MinAge: 17},
+			{Code: "AO", Name: "Adults Only 18+", MinAge: 18},
+		}},
+		{"PEGI", "Pan European Game Information", "EU", []entity.RatingLevel{
+			{Code: "3", Name: "PEGI 3", MinAge: 3},
+			{Code: "7", Name: "PEGI 7", MinAge: 7},
+			{Code: "12", Name: "PEGI 12", MinAge: 12},
+			{Code: "16", Name: "PEGI 16", MinAge: 16},
+			{Code: "18", Name: "PEGI 18", MinAge: 18},
+		}},
+		{"TH", "เรตติ้งไทย", "TH", []entity.RatingLevel{
+			{Code: "G", Name: "ทั่วไป", MinAge: 0},
+			{Code: "13", Name: "13+", MinAge: 13},
+			{Code: "15", Name: "15+", MinAge: 15},
+			{Code: "18", Name: "18+", MinAge: 18},
+			{Code: "20", Name: "20+", MinAge: 20},
+		}},
+	}
+	for _, s := range systems {
+		sys := entity.RatingSystem{Code: s.Code, Name: s.Name}
+		if err := db.Where("code = ?", s.Code).FirstOrCreate(&sys).Error; err != nil {
+			log.Println("seed rating system error:", s.Code, err)
+			continue
+		}
+		for _, l := range s.Levels {
+			l.RatingSystemID = sys.ID
+			if err := db.Where("rating_system_id = ? AND code = ?", sys.ID, l.Code).FirstOrCreate(&l).Error; err != nil {
+				log.Println("seed rating level error:", s.Code, l.Code, err)
+			}
+		}
+		db.Model(&entity.Region{}).Where("code = ? AND rating_system_id IS NULL", s.Region).Update("rating_system_id", sys.ID)
+	}
+}
+
+// สร้างสกุลเงิน + ภูมิภาคเริ่มต้น (THB เป็นสกุลหลักของ BasePrice) เฉพาะตอนที่ยังไม่มี region
+func seedRegionsIfNeeded() {
+	var count int64
+	db.Model(&entity.Region{}).Count(&count)
+	if count > 0 {
+		return
+	}
+
+	currencies := []entity.Currency{
+		{Code: "THB", Symbol: "฿", Decimals: 2, RateFromBase: 1, RoundingMode: entity.RoundNearest},
+		{Code: "USD", Symbol: "$", Decimals: 2, RateFromBase: 0.028, RoundingMode: entity.RoundCharm, RoundingStep: 1},
+		{Code: "EUR", Symbol: "€", Decimals: 2, RateFromBase: 0.026, RoundingMode: entity.RoundCharm, RoundingStep: 1},
+	}
+	regions := []entity.Region{
+		{Code: "TH", Name: "Thailand", IsDefault: true},
+		{Code: "US", Name: "United States"},
+		{Code: "EU", Name: "Europe"},
+	}
+	for i := range currencies {
+		cur := currencies[i]
+		if err := db.Where("code = ?", cur.Code).FirstOrCreate(&cur).Error; err != nil {
+			log.Println("seed currency error:", cur.Code, err)
+			continue
+		}
+		regions[i].CurrencyID = cur.ID
+		if err := db.Create(&regions[i]).Error; err != nil {
+			log.Println("seed region error:", regions[i].Code, err)
+		}
+	}
+}
+
 // สร้างข้อมูลตัวอย่างแบบเบา ๆ เฉพาะตอนที่ยังไม่มีผู้ใช้
 func seedIfNeededWithRoles(roleAdmin entity.Role, roleUser entity.Role) {
 	var count int64
@@ -459,13 +739,16 @@ func seedIfNeededWithRoles(roleAdmin entity.Role, roleUser entity.Role) {
 	//สร้างCategories
 	db.Model(&entity.Categories{}).Create(&entity.Categories{
 		Title: "FPS",
+		Slug:  "fps",
 	})
 
 	db.Model(&entity.Categories{}).Create(&entity.Categories{
 		Title: "Horror",
+		Slug:  "horror",
 	})
 
 	db.Model(&entity.Categories{}).Create(&entity.Categories{
 		Title: "TPS",
+		Slug:  "tps",
 	})
 }
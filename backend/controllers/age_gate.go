@@ -0,0 +1,178 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"example.com/sa-gameshop/configs"
+	"example.com/sa-gameshop/entity"
+	"example.com/sa-gameshop/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ผู้ชมของคำขอ (อายุคำนวณจากวันเกิดในฐานข้อมูล)
+func requestViewer(c *gin.Context, db *gorm.DB) services.Viewer {
+	return services.ViewerFor(db, optionalUserID(c))
+}
+
+// gameAgeAllowed ตอบ 403 และคืน false ถ้าผู้ชมอายุไม่ถึงเรตของเกม
+func gameAgeAllowed(c *gin.Context, db *gorm.DB, v services.Viewer, gameID uint, region entity.Region) bool {
+	minAge, err := services.CheckGameAge(db, v, gameID, region)
+	switch {
+	case errors.Is(err, services.ErrAgeRestricted), errors.Is(err, services.ErrAgeUnverified):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "game_id": gameID, "min_age": minAge})
+		return false
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
+}
+
+// threadAgeAllowed ตรวจเรตของเกมที่กระทู้สังกัด
+func threadAgeAllowed(c *gin.Context, db *gorm.DB, threadID string) bool {
+	var th entity.Thread
+	if tx := db.Select("id, game_id").First(&th, threadID); tx.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "thread not found"})
+		return false
+	}
+	region, err := requestRegion(c, db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "region not configured"})
+		return false
+	}
+	return gameAgeAllowed(c, db, requestViewer(c, db), th.GameID, region)
+}
+
+// ===== Rating systems =====
+
+// GET /rating-systems
+func FindRatingSystems(c *gin.Context) {
+	var rows []entity.RatingSystem
+	if err := configs.DB().
+		Preload("Levels", func(tx *gorm.DB) *gorm.DB { return tx.Order("min_age ASC") }).
+		Order("code ASC").
+		Find(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, rows)
+}
+
+// POST /rating-systems  (games.manage)
+func CreateRatingSystem(c *gin.Context) {
+	var body struct {
+		Code string `json:"code" binding:"required"`
+		Name string `json:"name"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code is required"})
+		return
+	}
+	row := entity.RatingSystem{Code: strings.ToUpper(strings.TrimSpace(body.Code)), Name: strings.TrimSpace(body.Name)}
+	if err := configs.DB().Create(&row).Error; err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, row)
+}
+
+// POST /rating-systems/:id/levels  (games.manage)
+func CreateRatingLevel(c *gin.Context) {
+	var body struct {
+		Code   string `json:"code" binding:"required"`
+		Name   string `json:"name"`
+		MinAge int    `json:"min_age" binding:"min=0,max=21"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code is required and min_age must be 0-21"})
+		return
+	}
+	db := configs.DB()
+	var sys entity.RatingSystem
+	if tx := db.First(&sys, c.Param("id")); tx.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "rating system not found"})
+		return
+	}
+	row := entity.RatingLevel{
+		RatingSystemID: sys.ID,
+		Code:           strings.ToUpper(strings.TrimSpace(body.Code)),
+		Name:           strings.TrimSpace(body.Name),
+		MinAge:         body.MinAge,
+	}
+	if err := db.Create(&row).Error; err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, row)
+}
+
+// PUT /games/:id/ratings  (games.manage)
+// body: { "ratings": [ { "system": "ESRB", "level": "M" }, { "system": "PEGI", "level": "18" } ] }
+// แทนที่ของเดิมทั้งหมด และตั้ง games.age_rating = อายุขั้นต่ำสูงสุด
+func SetGameRatings(c *gin.Context) {
+	var body struct {
+		Ratings []struct {
+			System string `json:"system"`
+			Level  string `json:"level"`
+		} `json:"ratings" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	db := configs.DB()
+	var game entity.Game
+	if tx := db.First(&game, c.Param("id")); tx.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "game not found"})
+		return
+	}
+
+	rows := make([]entity.GameRating, 0, len(body.Ratings))
+	seen := map[uint]bool{}
+	maxAge := 0
+	for _, r := range body.Ratings {
+		var sys entity.RatingSystem
+		if tx := db.Where("code = ?", strings.ToUpper(strings.TrimSpace(r.System))).First(&sys); tx.RowsAffected == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown rating system: " + r.System})
+			return
+		}
+		var lvl entity.RatingLevel
+		if tx := db.Where("rating_system_id = ? AND code = ?", sys.ID, strings.ToUpper(strings.TrimSpace(r.Level))).First(&lvl); tx.RowsAffected == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown level " + r.Level + " for " + sys.Code})
+			return
+		}
+		if seen[sys.ID] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "duplicate rating system: " + sys.Code})
+			return
+		}
+		seen[sys.ID] = true
+		if lvl.MinAge > maxAge {
+			maxAge = lvl.MinAge
+		}
+		rows = append(rows, entity.GameRating{GameID: game.ID, RatingSystemID: sys.ID, RatingLevelID: lvl.ID})
+	}
+
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("game_id = ?", game.ID).Delete(&entity.GameRating{}).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		if err := tx.Create(&rows).Error; err != nil {
+			return err
+		}
+		// age_rating เดิมยังใช้เป็น fallback และตัวกรองหน้าร้าน
+		return tx.Model(&game).UpdateColumn("age_rating", maxAge).Error
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var out []entity.GameRating
+	db.Preload("RatingSystem").Preload("RatingLevel").Where("game_id = ?", game.ID).Find(&out)
+	c.JSON(http.StatusOK, out)
+}
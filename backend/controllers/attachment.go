@@ -9,16 +9,19 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func attachmentTargetExists(targetType string, targetID any) bool {
+// เจ้าของเป้าหมาย (แนบไฟล์ได้เฉพาะเธรด/คอมเมนต์ของตัวเอง)
+func attachmentTargetAuthor(targetType string, targetID any) (uint, bool) {
 	switch strings.ToLower(targetType) {
 	case "thread":
 		var t entity.Thread
-		return configs.DB().First(&t, targetID).RowsAffected > 0
+		found := configs.DB().First(&t, targetID).RowsAffected > 0
+		return t.UserID, found
 	case "comment":
 		var m entity.Comment
-		return configs.DB().First(&m, targetID).RowsAffected > 0
+		found := configs.DB().First(&m, targetID).RowsAffected > 0 && !m.Removed
+		return m.UserID, found
 	default:
-		return false
+		return 0, false
 	}
 }
 
@@ -30,16 +33,15 @@ func CreateAttachment(c *gin.Context) {
 		return
 	}
 
-	// เช็ค User
-	var user entity.User
-	if tx := configs.DB().Where("id = ?", body.UserID).First(&user); tx.RowsAffected == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id not found"})
-		return
-	}
+	// ผู้ใช้มาจาก token เสมอ (ไม่เชื่อ user_id ใน body)
+	body.UserID = c.GetUint("userID")
 	// เช็ค Target
-	if !attachmentTargetExists(body.TargetType, body.TargetID) {
+	if author, ok := attachmentTargetAuthor(body.TargetType, body.TargetID); !ok {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid target"})
 		return
+	} else if author != body.UserID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return
 	}
 
 	if err := configs.DB().Create(&body).Error; err != nil {
@@ -97,6 +99,11 @@ func UpdateAttachment(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "id not found"})
 		return
 	}
+	if row.UserID != c.GetUint("userID") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return
+	}
+	payload.UserID = 0 // ห้ามย้ายเจ้าของ
 	// ถ้าแก้ target ให้ตรวจสอบเหมือนเดิม
 	if payload.TargetType != "" || payload.TargetID != 0 {
 		tt := payload.TargetType
@@ -107,9 +114,12 @@ func UpdateAttachment(c *gin.Context) {
 		if tid == 0 {
 			tid = row.TargetID
 		}
-		if !attachmentTargetExists(tt, tid) {
+		if author, ok := attachmentTargetAuthor(tt, tid); !ok {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid target"})
 			return
+		} else if author != row.UserID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+			return
 		}
 	}
 	if err := db.Model(&row).Updates(payload).Error; err != nil {
@@ -119,11 +129,25 @@ func UpdateAttachment(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "updated successful"})
 }
 
-// DELETE /attachments/:id
+// DELETE /attachments/:id (เจ้าของ หรือผู้ดูแลพร้อม ?reason=)
 func DeleteAttachmentByID(c *gin.Context) {
-	if tx := configs.DB().Exec("DELETE FROM attachments WHERE id = ?", c.Param("id")); tx.RowsAffected == 0 {
+	db := configs.DB()
+	var row entity.Attachment
+	if tx := db.First(&row, c.Param("id")); tx.RowsAffected == 0 {
 		c.JSON(http.StatusNotFound, gin.H{"error": "id not found"})
 		return
 	}
+	reason := deleteReason(c)
+	moderator, ok := communityWriteAllowed(c, db, row.UserID, reason)
+	if !ok {
+		return
+	}
+	if tx := db.Exec("DELETE FROM attachments WHERE id = ?", row.ID); tx.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "id not found"})
+		return
+	}
+	if moderator {
+		logModeration(c, db, "attachment", row.ID, row.UserID, "delete", reason, row.FileURL)
+	}
 	c.JSON(http.StatusOK, gin.H{"message": "deleted successful"})
 }
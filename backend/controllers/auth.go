@@ -3,6 +3,8 @@ package controllers
 import (
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"example.com/sa-gameshop/configs"
@@ -33,6 +35,9 @@ func Login(c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "incorrect password"})
 		return
 	}
+	if restrictionBlocked(c, configs.DB(), user.ID, entity.RestrictLogin) {
+		return
+	}
 
 	secret := os.Getenv("JWT_SECRET")
 	if secret == "" {
@@ -59,3 +64,40 @@ func Login(c *gin.Context) {
 		"exp":      exp,
 	})
 }
+
+// optionalUserID คืน user id ถ้ามี (ใช้กับเส้นทางสาธารณะที่แสดงผลต่างกันตามผู้ใช้)
+// อ่านจาก context ที่ AuthRequired set ไว้ → Bearer token → X-User-ID ตามลำดับ; ไม่พบ = 0
+func optionalUserID(c *gin.Context) uint {
+	if uid := c.GetUint("userID"); uid != 0 {
+		return uid
+	}
+	if auth := c.GetHeader("Authorization"); strings.HasPrefix(strings.ToLower(auth), "bearer ") {
+		secret := os.Getenv("JWT_SECRET")
+		if secret == "" {
+			secret = "secret"
+		}
+		token, err := jwt.Parse(strings.TrimSpace(auth[7:]), func(t *jwt.Token) (interface{}, error) {
+			return []byte(secret), nil
+		})
+		if err == nil && token.Valid {
+			if claims, ok := token.Claims.(jwt.MapClaims); ok {
+				switch v := claims["sub"].(type) {
+				case float64:
+					if v > 0 {
+						return uint(v)
+					}
+				case string:
+					if n, err := strconv.Atoi(v); err == nil && n > 0 {
+						return uint(n)
+					}
+				}
+			}
+		}
+	}
+	if v := c.GetHeader("X-User-ID"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			return uint(n)
+		}
+	}
+	return 0
+}
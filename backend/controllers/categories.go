@@ -1,19 +1,301 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"example.com/sa-gameshop/configs"
 	"example.com/sa-gameshop/entity"
+	"example.com/sa-gameshop/services"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
+// หมวดพร้อมจำนวนเกมที่ published
+// game_count = เกมในหมวดนี้โดยตรง, total_game_count = รวมหมวดย่อย (ไม่นับเกมซ้ำ)
+type categoryNode struct {
+	entity.Categories
+	GameCount      int             `json:"game_count"`
+	TotalGameCount int             `json:"total_game_count"`
+	Children       []*categoryNode `json:"children,omitempty"`
+}
+
+// โหลดหมวดทั้งหมด + นับเกม published (หมวดหลักและ game_categories)
+func loadCategoryNodes(db *gorm.DB) ([]*categoryNode, map[uint]*categoryNode, error) {
+	var cats []entity.Categories
+	if err := db.Order("sort_order ASC, title ASC").Find(&cats).Error; err != nil {
+		return nil, nil, err
+	}
+	var links []struct {
+		CategoryID uint
+		GameID     uint
+	}
+	if err := db.Raw(`SELECT gc.categories_id AS category_id, gc.game_id AS game_id
+		FROM game_categories gc JOIN games g ON g.id = gc.game_id
+		WHERE g.status = ? AND g.deleted_at IS NULL
+		UNION
+		SELECT categories_id, id FROM games WHERE status = ? AND deleted_at IS NULL AND categories_id > 0`,
+		entity.GamePublished, entity.GamePublished).Scan(&links).Error; err != nil {
+		return nil, nil, err
+	}
+	games := map[uint]map[uint]bool{}
+	for _, l := range links {
+		if games[l.CategoryID] == nil {
+			games[l.CategoryID] = map[uint]bool{}
+		}
+		games[l.CategoryID][l.GameID] = true
+	}
+
+	nodes := make([]*categoryNode, 0, len(cats))
+	byID := make(map[uint]*categoryNode, len(cats))
+	for _, c := range cats {
+		n := &categoryNode{Categories: c, GameCount: len(games[c.ID])}
+		nodes = append(nodes, n)
+		byID[c.ID] = n
+	}
+	for _, n := range nodes {
+		if n.ParentID != nil {
+			if p, ok := byID[*n.ParentID]; ok {
+				p.Children = append(p.Children, n)
+			}
+		}
+	}
+	// นับรวมหมวดย่อยทุกชั้น
+	var collect func(n *categoryNode, into map[uint]bool)
+	collect = func(n *categoryNode, into map[uint]bool) {
+		for g := range games[n.ID] {
+			into[g] = true
+		}
+		for _, ch := range n.Children {
+			collect(ch, into)
+		}
+	}
+	for _, n := range nodes {
+		all := map[uint]bool{}
+		collect(n, all)
+		n.TotalGameCount = len(all)
+	}
+	return nodes, byID, nil
+}
+
+// GET /categories?tree=true
+// ค่าเริ่มต้นคืน array แบนเหมือนเดิม (เพิ่ม slug/parent_id/จำนวนเกม); tree=true คืนเฉพาะหมวดบนสุดพร้อม children
 func FindCategories(c *gin.Context) {
-	var categories []entity.Categories
-	if err := configs.DB().Raw("SELECT * FROM categories").Find(&categories).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	nodes, byID, err := loadCategoryNodes(configs.DB())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if c.Query("tree") != "true" {
+		flat := make([]categoryNode, 0, len(nodes))
+		for _, n := range nodes {
+			row := *n
+			row.Children = nil
+			flat = append(flat, row)
+		}
+		c.JSON(http.StatusOK, flat)
+		return
+	}
+	roots := []*categoryNode{}
+	for _, n := range nodes {
+		if n.ParentID == nil || byID[*n.ParentID] == nil {
+			roots = append(roots, n)
+		}
+	}
+	c.JSON(http.StatusOK, roots)
+}
+
+// GET /categories/:id  (id หรือ slug)
+func FindCategoryByID(c *gin.Context) {
+	_, byID, err := loadCategoryNodes(configs.DB())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	key := c.Param("id")
+	if id, err := strconv.Atoi(key); err == nil {
+		if n, ok := byID[uint(id)]; ok {
+			c.JSON(http.StatusOK, n)
+			return
+		}
+	}
+	for _, n := range byID {
+		if n.Slug == key {
+			c.JSON(http.StatusOK, n)
+			return
+		}
+	}
+	c.JSON(http.StatusNotFound, gin.H{"error": "category not found"})
+}
+
+type categoryBody struct {
+	Title     *string `json:"title"`
+	Slug      *string `json:"slug"`
+	ParentID  *uint   `json:"parent_id"` // 0 = ย้ายขึ้นไปเป็นหมวดบนสุด
+	SortOrder *int    `json:"sort_order"`
+}
+
+// ตรวจ parent_id (0 → nil) และกันวนลูปในต้นไม้
+func categoryParent(c *gin.Context, db *gorm.DB, id uint, parentID uint) (*uint, bool) {
+	if parentID == 0 {
+		return nil, true
+	}
+	if tx := db.First(&entity.Categories{}, parentID); tx.RowsAffected == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "parent_id not found"})
+		return nil, false
+	}
+	if err := services.CheckCategoryParent(db, id, &parentID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return &parentID, true
+}
+
+// POST /categories  (games.manage)
+func CreateCategory(c *gin.Context) {
+	var body categoryBody
+	if err := c.ShouldBindJSON(&body); err != nil || body.Title == nil || strings.TrimSpace(*body.Title) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "title is required"})
+		return
+	}
+	db := configs.DB()
+	row := entity.Categories{Title: strings.TrimSpace(*body.Title)}
+	base := row.Title
+	if body.Slug != nil && strings.TrimSpace(*body.Slug) != "" {
+		base = *body.Slug
+	}
+	row.Slug = services.UniqueSlug(db, "categories", services.Slugify(base), 0)
+	if body.ParentID != nil {
+		parent, ok := categoryParent(c, db, 0, *body.ParentID)
+		if !ok {
+			return
+		}
+		row.ParentID = parent
+	}
+	if body.SortOrder != nil {
+		row.SortOrder = *body.SortOrder
+	}
+	if err := db.Create(&row).Error; err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, row)
+}
+
+// PATCH /categories/:id  (games.manage)
+// เปลี่ยนชื่อไม่กระทบเกม (ผูกด้วย id); slug เปลี่ยนเมื่อส่งมาเท่านั้น ลิงก์เดิมจะได้ไม่เสีย
+func UpdateCategory(c *gin.Context) {
+	db := configs.DB()
+	var row entity.Categories
+	if tx := db.First(&row, c.Param("id")); tx.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "category not found"})
+		return
+	}
+	var body categoryBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, categories)
+	updates := map[string]interface{}{}
+	if body.Title != nil {
+		title := strings.TrimSpace(*body.Title)
+		if title == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "title must not be empty"})
+			return
+		}
+		updates["title"] = title
+	}
+	if body.Slug != nil {
+		slug := services.Slugify(*body.Slug)
+		if slug == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid slug"})
+			return
+		}
+		updates["slug"] = services.UniqueSlug(db, "categories", slug, row.ID)
+	}
+	if body.ParentID != nil {
+		parent, ok := categoryParent(c, db, row.ID, *body.ParentID)
+		if !ok {
+			return
+		}
+		updates["parent_id"] = parent
+	}
+	if body.SortOrder != nil {
+		updates["sort_order"] = *body.SortOrder
+	}
+	if len(updates) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "no changes"})
+		return
+	}
+	if err := db.Model(&row).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	_ = db.First(&row, row.ID)
+	c.JSON(http.StatusOK, row)
+}
+
+// DELETE /categories/:id?move_to=  (games.manage)
+// หมวดย่อยย้ายขึ้นไปอยู่กับ parent เดิม; ถ้ายังมีเกมต้องระบุ move_to
+func DeleteCategory(c *gin.Context) {
+	db := configs.DB()
+	var row entity.Categories
+	if tx := db.First(&row, c.Param("id")); tx.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "category not found"})
+		return
+	}
+	var moveTo uint
+	if v := c.Query("move_to"); v != "" {
+		var target entity.Categories
+		if tx := db.First(&target, v); tx.RowsAffected == 0 || target.ID == row.ID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid move_to"})
+			return
+		}
+		moveTo = target.ID
+	}
+	err := services.DeleteCategory(db, row, moveTo)
+	if errors.Is(err, services.ErrCategoryInUse) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
+}
+
+// POST /categories/:id/merge  (games.manage)
+// body: { "into_id": 2 } — ย้ายเกมและหมวดย่อยทั้งหมดไปหมวดปลายทาง แล้วลบหมวดนี้
+func MergeCategory(c *gin.Context) {
+	var body struct {
+		IntoID uint `json:"into_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "into_id is required"})
+		return
+	}
+	db := configs.DB()
+	var from, into entity.Categories
+	if tx := db.First(&from, c.Param("id")); tx.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "category not found"})
+		return
+	}
+	if tx := db.First(&into, body.IntoID); tx.RowsAffected == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "into_id not found"})
+		return
+	}
+	if err := services.MergeCategory(db, from, into); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	_, byID, err := loadCategoryNodes(db)
+	if err != nil || byID[into.ID] == nil {
+		c.JSON(http.StatusOK, into)
+		return
+	}
+	c.JSON(http.StatusOK, byID[into.ID])
 }
@@ -1,19 +1,31 @@
 package controllers
 
 import (
+	"log"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"example.com/sa-gameshop/configs"
 	"example.com/sa-gameshop/entity"
+	"example.com/sa-gameshop/services"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
+const (
+	// ชั้นลึกสุดของคำตอบ (0 = บนสุด) ตอบคอมเมนต์ชั้นนี้จะต่อเป็นพี่น้องพร้อมอ้างข้อความแทน
+	maxCommentDepth = 4
+	// ความยาวข้อความที่เก็บตอนอ้าง (ตัวอักษร)
+	quoteMaxRunes = 300
+)
+
 // POST /threads/:id/comments
 type createCommentBody struct {
-	Content string `json:"content" binding:"required"`
+	Content  string `json:"content" binding:"required"`
+	ParentID *uint  `json:"parent_id"` // ตอบคอมเมนต์ไหน (ไม่ส่ง = ระดับบนสุด)
+	QuoteID  *uint  `json:"quote_id"`  // อ้างข้อความจากคอมเมนต์ไหน
 }
 
 func CreateComment(c *gin.Context) {
@@ -29,59 +41,460 @@ func CreateComment(c *gin.Context) {
 	}
 
 	db := configs.DB()
+	if restrictionBlocked(c, db, uid, entity.RestrictCommunity) {
+		return
+	}
 	var th entity.Thread
 	if tx := db.First(&th, c.Param("id")); tx.RowsAffected == 0 {
 		c.JSON(http.StatusNotFound, gin.H{"error": "thread not found"})
 		return
 	}
+	if th.Hidden {
+		c.JSON(http.StatusNotFound, gin.H{"error": "thread not found"})
+		return
+	}
+	if th.Locked {
+		c.JSON(http.StatusForbidden, gin.H{"error": "thread is locked"})
+		return
+	}
+	if !threadAgeAllowed(c, db, c.Param("id")) {
+		return
+	}
 
 	row := entity.Comment{
 		Content:  strings.TrimSpace(body.Content),
 		ThreadID: th.ID,
 		UserID:   uid,
 	}
-	if err := db.Create(&row).Error; err != nil {
+	var mentioned []uint
+	row.ContentHTML, mentioned = services.RenderMarkdown(db, row.Content)
+	if body.ParentID != nil {
+		parent, ok := threadComment(c, db, th.ID, *body.ParentID, "parent")
+		if !ok {
+			return
+		}
+		if parent.Depth >= maxCommentDepth {
+			// ลึกเกิน → ต่อใต้ parent ของคอมเมนต์นั้นแทน และอ้างข้อความไว้ให้รู้ว่าตอบใคร
+			if body.QuoteID == nil {
+				body.QuoteID = &parent.ID
+			}
+			row.ParentID = parent.ParentID
+			row.Depth = parent.Depth
+		} else {
+			row.ParentID = &parent.ID
+			row.Depth = parent.Depth + 1
+		}
+	}
+	if body.QuoteID != nil {
+		quoted, ok := threadComment(c, db, th.ID, *body.QuoteID, "quoted")
+		if !ok {
+			return
+		}
+		row.QuotedCommentID = &quoted.ID
+		row.QuoteText = truncateRunes(quoted.Content, quoteMaxRunes)
+	}
+
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&row).Error; err != nil {
+			return err
+		}
+		if row.ParentID != nil {
+			return tx.Model(&entity.Comment{}).Where("id = ?", *row.ParentID).
+				UpdateColumn("reply_count", gorm.Expr("reply_count + 1")).Error
+		}
+		return nil
+	}); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	// เพิ่มตัวนับ comment
+	// เพิ่มตัวนับ comment + เวลาความเคลื่อนไหวล่าสุด
 	db.Model(&entity.Thread{}).Where("id = ?", th.ID).
-		UpdateColumn("comment_count", gorm.Expr("comment_count + 1"))
+		UpdateColumns(map[string]interface{}{
+			"comment_count":    gorm.Expr("comment_count + 1"),
+			"last_activity_at": row.CreatedAt,
+		})
+	notifyMentions(db, uid, mentioned, nil, "กระทู้ "+th.Title, entity.Notification{ThreadID: &th.ID, CommentID: &row.ID})
 
 	_ = db.Preload("User").First(&row, row.ID)
+	// แจ้งผู้ติดตาม (คนที่ถูก @ ได้แจ้งเตือนไปแล้ว) แล้วติดตามกระทู้ให้ผู้แสดงความเห็น
+	if err := services.NotifyThreadComment(db, th, row, row.User.Username, mentioned); err != nil {
+		log.Println("[Comment] notify followers error:", err)
+	}
+	if err := services.AutoFollowThread(db, uid, th.ID); err != nil {
+		log.Println("[Comment] auto follow error:", err)
+	}
+	services.Events.Publish(services.Event{Type: services.EventComment, Topic: services.ThreadTopic(th.ID), Data: row})
 	c.JSON(http.StatusCreated, row)
 }
 
-// GET /threads/:id/comments?limit=&offset=
+// คอมเมนต์ที่ตอบ/อ้างต้องอยู่ในเธรดเดียวกันและยังไม่ถูกลบ
+func threadComment(c *gin.Context, db *gorm.DB, threadID, commentID uint, what string) (entity.Comment, bool) {
+	var cm entity.Comment
+	if tx := db.Where("id = ? AND thread_id = ?", commentID, threadID).Limit(1).Find(&cm); tx.RowsAffected == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": what + " comment not found in this thread"})
+		return cm, false
+	}
+	if cm.Removed || cm.Hidden {
+		c.JSON(http.StatusBadRequest, gin.H{"error": what + " comment was removed"})
+		return cm, false
+	}
+	return cm, true
+}
+
+func truncateRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n]) + "…"
+}
+
+// GET /threads/:id/comments?format=flat|tree&limit=&offset=&replies_limit=&parent_id=
+// - limit/offset แบ่งหน้าคอมเมนต์ชั้นบนสุด (หรือคำตอบของ parent_id ถ้าส่งมา)
+// - replies_limit จำนวนคำตอบที่แนบมาต่อ 1 กิ่งในแต่ละชั้น ที่เหลือโหลดต่อด้วย parent_id=<id>&offset=<ที่มีแล้ว>
+// - flat (ค่าเริ่มต้น) = เรียงแบบ depth-first พร้อม depth, tree = ซ้อนใน replies
+// จำนวนทั้งหมดของชั้นที่แบ่งหน้าอยู่ใน header X-Total-Count
 func FindCommentsByThread(c *gin.Context) {
+	db := configs.DB()
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "200"))
 	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	repliesLimit, _ := strconv.Atoi(c.DefaultQuery("replies_limit", "10"))
+	if limit <= 0 || limit > 500 {
+		limit = 200
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	if repliesLimit < 0 || repliesLimit > 100 {
+		repliesLimit = 10
+	}
+	format := c.DefaultQuery("format", "flat")
+	if format != "flat" && format != "tree" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be flat or tree"})
+		return
+	}
+	if !threadAgeAllowed(c, db, c.Param("id")) || !threadVisible(c, db, c.Param("id")) {
+		return
+	}
 
-	var rows []entity.Comment
-	if err := configs.DB().Preload("User").
-		Where("thread_id = ?", c.Param("id")).
-		Order("id ASC"). // เรียงเป็นแถวเดียว
+	q := db.Model(&entity.Comment{}).Where("thread_id = ?", c.Param("id"))
+	if pid := c.Query("parent_id"); pid != "" {
+		q = q.Where("parent_id = ?", pid)
+	} else {
+		q = q.Where("parent_id IS NULL")
+	}
+	var total int64
+	q.Count(&total)
+
+	var top []entity.Comment
+	if err := q.Preload("User").
+		Order("id ASC").
 		Limit(limit).Offset(offset).
-		Find(&rows).Error; err != nil {
+		Find(&top).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
+
+	byID, children, err := loadCommentReplies(db, top, repliesLimit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	quoteGone := unavailableQuotes(db, top, byID)
+
+	var build func(cm entity.Comment) entity.Comment
+	build = func(cm entity.Comment) entity.Comment {
+		for _, id := range children[cm.ID] {
+			cm.Replies = append(cm.Replies, build(byID[id]))
+		}
+		cm.HasMoreReplies = cm.ReplyCount > len(cm.Replies)
+		maskRemovedComment(&cm)
+		if cm.QuotedCommentID != nil && quoteGone[*cm.QuotedCommentID] {
+			cm.QuoteText = ""
+		}
+		return cm
+	}
+	var flatten func(cm entity.Comment, out []entity.Comment) []entity.Comment
+	flatten = func(cm entity.Comment, out []entity.Comment) []entity.Comment {
+		replies := cm.Replies
+		cm.Replies = nil
+		out = append(out, cm)
+		for _, r := range replies {
+			out = flatten(r, out)
+		}
+		return out
+	}
+
+	rows := make([]entity.Comment, 0, len(top))
+	for _, cm := range top {
+		node := build(cm)
+		if format == "tree" {
+			rows = append(rows, node)
+		} else {
+			rows = flatten(node, rows)
+		}
+	}
+	c.Header("X-Total-Count", strconv.FormatInt(total, 10))
 	c.JSON(http.StatusOK, rows)
 }
 
-// DELETE /comments/:id
+// โหลดคำตอบทีละชั้น ชั้นละไม่เกิน perBranch ต่อ parent (ลึกสุด maxCommentDepth ชั้น)
+func loadCommentReplies(db *gorm.DB, roots []entity.Comment, perBranch int) (map[uint]entity.Comment, map[uint][]uint, error) {
+	byID := map[uint]entity.Comment{}
+	children := map[uint][]uint{}
+	var parents []uint
+	for _, cm := range roots {
+		if cm.ReplyCount > 0 {
+			parents = append(parents, cm.ID)
+		}
+	}
+	for len(parents) > 0 && perBranch > 0 {
+		var ids []uint
+		if err := db.Raw(`SELECT id FROM (
+				SELECT id, ROW_NUMBER() OVER (PARTITION BY parent_id ORDER BY id) AS rn
+				FROM comments WHERE parent_id IN ? AND deleted_at IS NULL
+			) WHERE rn <= ?`, parents, perBranch).Scan(&ids).Error; err != nil {
+			return nil, nil, err
+		}
+		if len(ids) == 0 {
+			break
+		}
+		var rows []entity.Comment
+		if err := db.Preload("User").Where("id IN ?", ids).Order("id ASC").Find(&rows).Error; err != nil {
+			return nil, nil, err
+		}
+		parents = parents[:0]
+		for _, r := range rows {
+			byID[r.ID] = r
+			children[*r.ParentID] = append(children[*r.ParentID], r.ID)
+			if r.ReplyCount > 0 {
+				parents = append(parents, r.ID)
+			}
+		}
+	}
+	return byID, children, nil
+}
+
+// unavailableQuotes id ของคอมเมนต์ที่ถูกอ้างถึงแต่ตอนนี้ถูกลบ/ซ่อนแล้ว (quote_text ที่คัดลอกไว้ต้องไม่แสดงต่อ)
+func unavailableQuotes(db *gorm.DB, top []entity.Comment, more map[uint]entity.Comment) map[uint]bool {
+	gone := map[uint]bool{}
+	for _, cm := range top {
+		if cm.QuotedCommentID != nil {
+			gone[*cm.QuotedCommentID] = true
+		}
+	}
+	for _, cm := range more {
+		if cm.QuotedCommentID != nil {
+			gone[*cm.QuotedCommentID] = true
+		}
+	}
+	if len(gone) == 0 {
+		return gone
+	}
+	ids := make([]uint, 0, len(gone))
+	for id := range gone {
+		ids = append(ids, id)
+	}
+	var visible []uint
+	db.Model(&entity.Comment{}).Where("id IN ? AND removed = ? AND hidden = ?", ids, false, false).Pluck("id", &visible)
+	for _, id := range visible {
+		delete(gone, id)
+	}
+	return gone
+}
+
+// คอมเมนต์ที่ถูกลบแต่ยังมีคำตอบ: ซ่อนเนื้อหาและผู้เขียน, ผู้ดูแลซ่อน: ซ่อนเนื้อหา
+func maskRemovedComment(cm *entity.Comment) {
+	if cm.Removed {
+		cm.UserID = 0
+		cm.User = entity.User{}
+	}
+	if cm.Removed || cm.Hidden {
+		cm.Content = ""
+		cm.ContentHTML = ""
+		cm.QuoteText = ""
+	}
+}
+
+// PATCH /comments/:id {"content": "...", "reason": "..."}
+// เจ้าของ หรือผู้ดูแล (ต้องมี reason) เก็บเนื้อหาเดิมไว้ใน revision
+func UpdateComment(c *gin.Context) {
+	uid := c.GetUint("userID")
+	var body struct {
+		Content string `json:"content" binding:"required"`
+		Reason  string `json:"reason"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil || strings.TrimSpace(body.Content) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "content required"})
+		return
+	}
+	db := configs.DB()
+	var row entity.Comment
+	if tx := db.First(&row, c.Param("id")); tx.RowsAffected == 0 || row.Removed {
+		c.JSON(http.StatusNotFound, gin.H{"error": "id not found"})
+		return
+	}
+	reason := moderationReason(c, body.Reason)
+	moderator, ok := communityWriteAllowed(c, db, row.UserID, reason)
+	if !ok {
+		return
+	}
+	if !moderator && restrictionBlocked(c, db, uid, entity.RestrictCommunity) {
+		return
+	}
+	if !moderator && !threadAgeAllowed(c, db, strconv.FormatUint(uint64(row.ThreadID), 10)) {
+		return
+	}
+
+	content := strings.TrimSpace(body.Content)
+	if content != row.Content {
+		contentHTML, mentioned := services.RenderMarkdown(db, content)
+		_, previously := services.RenderMarkdown(db, row.Content)
+		if err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&entity.CommentRevision{
+				CommentID: row.ID,
+				Content:   row.Content,
+				EditorID:  uid,
+			}).Error; err != nil {
+				return err
+			}
+			return tx.Model(&row).Updates(map[string]interface{}{
+				"content":      content,
+				"content_html": contentHTML,
+				"edited_at":    time.Now(),
+				"edit_count":   gorm.Expr("edit_count + 1"),
+			}).Error
+		}); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if moderator {
+			logModeration(c, db, "comment", row.ID, row.UserID, "update", reason, row.Content)
+		}
+		var th entity.Thread
+		db.Select("id, title").Limit(1).Find(&th, row.ThreadID)
+		notifyMentions(db, row.UserID, mentioned, previously, "กระทู้ "+th.Title, entity.Notification{ThreadID: &th.ID, CommentID: &row.ID})
+	}
+	_ = db.Preload("User").First(&row, row.ID)
+	c.JSON(http.StatusOK, row)
+}
+
+// GET /comments/:id/revisions — เนื้อหาก่อนแก้ไขแต่ละครั้ง (เก่าสุดก่อน)
+// ความเห็น/กระทู้ที่ถูกซ่อน → 404 ยกเว้นเจ้าของหรือผู้ดูแล
+func FindCommentRevisions(c *gin.Context) {
+	db := configs.DB()
+	var row entity.Comment
+	if tx := db.First(&row, c.Param("id")); tx.RowsAffected == 0 || row.Removed ||
+		(row.Hidden && !canSeeHidden(c, db, "comment", row.UserID)) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "id not found"})
+		return
+	}
+	threadID := strconv.FormatUint(uint64(row.ThreadID), 10)
+	if !threadVisible(c, db, threadID) || !threadAgeAllowed(c, db, threadID) {
+		return
+	}
+	var revs []entity.CommentRevision
+	if err := db.Where("comment_id = ?", row.ID).Order("id ASC").Find(&revs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, revs)
+}
+
+// DELETE /comments/:id (?reason= เมื่อผู้ดูแลลบของคนอื่น)
+// มีคำตอบอยู่ → เก็บแถวไว้เป็น "ความเห็นถูกลบ", ไม่มี → ลบจริงแล้วเก็บกวาด parent ที่ถูกลบและไม่เหลือคำตอบ
 func DeleteComment(c *gin.Context) {
 	db := configs.DB()
 	var row entity.Comment
-	if tx := db.First(&row, c.Param("id")); tx.RowsAffected == 0 {
+	if tx := db.First(&row, c.Param("id")); tx.RowsAffected == 0 || row.Removed {
 		c.JSON(http.StatusNotFound, gin.H{"error": "id not found"})
 		return
 	}
-	if err := db.Delete(&row).Error; err != nil {
+	reason := deleteReason(c)
+	moderator, ok := communityWriteAllowed(c, db, row.UserID, reason)
+	if !ok {
+		return
+	}
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		return removeComment(tx, row)
+	}); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	db.Model(&entity.Thread{}).Where("id = ?", row.ThreadID).
-		UpdateColumn("comment_count", gorm.Expr("CASE WHEN comment_count > 0 THEN comment_count - 1 ELSE 0 END"))
+	if moderator {
+		logModeration(c, db, "comment", row.ID, row.UserID, "delete", reason, row.Content)
+	}
 	c.JSON(http.StatusOK, gin.H{"message": "deleted successful"})
 }
+
+// removeComment ลบคอมเมนต์ (มีคำตอบ → เก็บเป็น "ความเห็นถูกลบ") พร้อมปรับตัวนับของ parent/เธรด
+func removeComment(tx *gorm.DB, row entity.Comment) error {
+	if row.ReplyCount > 0 {
+		if err := tx.Model(&row).Updates(map[string]interface{}{
+			"removed": true, "content": "", "content_html": "", "quote_text": "",
+		}).Error; err != nil {
+			return err
+		}
+	} else {
+		if err := tx.Delete(&row).Error; err != nil {
+			return err
+		}
+		for pid := row.ParentID; pid != nil; {
+			if err := tx.Model(&entity.Comment{}).Where("id = ?", *pid).
+				UpdateColumn("reply_count", gorm.Expr("CASE WHEN reply_count > 0 THEN reply_count - 1 ELSE 0 END")).Error; err != nil {
+				return err
+			}
+			var parent entity.Comment
+			if err := tx.Select("id, parent_id, reply_count, removed").First(&parent, *pid).Error; err != nil {
+				return err
+			}
+			if !parent.Removed || parent.ReplyCount > 0 {
+				break
+			}
+			if err := tx.Delete(&parent).Error; err != nil {
+				return err
+			}
+			pid = parent.ParentID
+		}
+	}
+	return tx.Model(&entity.Thread{}).Where("id = ?", row.ThreadID).
+		UpdateColumn("comment_count", gorm.Expr("CASE WHEN comment_count > 0 THEN comment_count - 1 ELSE 0 END")).Error
+}
+
+// restoreComment คืนคอมเมนต์ที่ถูกลบ (อุทธรณ์สำเร็จ) เนื้อหาเอาจาก snapshot ใน moderation log
+// parent ที่ถูกเก็บกวาดไปแล้วจะกลับมาเป็น "ความเห็นถูกลบ" เพื่อให้ต้นไม้ต่อกันได้
+func restoreComment(tx *gorm.DB, id uint, content string) error {
+	var row entity.Comment
+	if err := tx.Unscoped().First(&row, id).Error; err != nil {
+		return err
+	}
+	if !row.DeletedAt.Valid && !row.Removed {
+		return nil
+	}
+	html, _ := services.RenderMarkdown(tx, content)
+	if err := tx.Unscoped().Model(&entity.Comment{}).Where("id = ?", row.ID).Updates(map[string]interface{}{
+		"deleted_at": nil, "removed": false, "content": content, "content_html": html,
+	}).Error; err != nil {
+		return err
+	}
+	if row.DeletedAt.Valid {
+		for pid := row.ParentID; pid != nil; {
+			var parent entity.Comment
+			if err := tx.Unscoped().Select("id, parent_id, deleted_at").First(&parent, *pid).Error; err != nil {
+				return err
+			}
+			if err := tx.Unscoped().Model(&entity.Comment{}).Where("id = ?", parent.ID).Updates(map[string]interface{}{
+				"deleted_at": nil, "reply_count": gorm.Expr("reply_count + 1"),
+			}).Error; err != nil {
+				return err
+			}
+			if !parent.DeletedAt.Valid {
+				break
+			}
+			pid = parent.ParentID
+		}
+	}
+	return tx.Model(&entity.Thread{}).Where("id = ?", row.ThreadID).
+		UpdateColumn("comment_count", gorm.Expr("comment_count + 1")).Error
+}
@@ -0,0 +1,241 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"example.com/sa-gameshop/configs"
+	"example.com/sa-gameshop/entity"
+	"example.com/sa-gameshop/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// uploadFileName ตั้งชื่อไฟล์ <unixnano>_<ชื่อเดิม> (ตัด path/อักขระคั่น path ออกจากชื่อที่ client ส่งมา)
+func uploadFileName(filename string) string {
+	name := path.Base(strings.ReplaceAll(filename, "\\", "/"))
+	if name == "." || name == "/" || name == ".." {
+		name = "file"
+	}
+	return fmt.Sprintf("%d_%s", time.Now().UnixNano(), name)
+}
+
+// storeUpload เก็บไฟล์ที่อัปโหลดลง storage กลางที่ key (เช่น "mods/123_x.zip") แล้วนับพื้นที่ให้ uid
+// เกิน quota = services.ErrQuotaExceeded (ยังไม่เขียนไฟล์)
+func storeUpload(c *gin.Context, file *multipart.FileHeader, key string, uid uint) error {
+	db := configs.DB()
+	if _, err := services.CheckUploadQuota(db, uid, file.Size); err != nil {
+		return err
+	}
+	f, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	ctype := file.Header.Get("Content-Type")
+	if ctype == "" {
+		ctype = mime.TypeByExtension(path.Ext(key))
+	}
+	if err := services.Files().Put(c.Request.Context(), key, f, file.Size, ctype); err != nil {
+		return err
+	}
+	services.TrackUpload(c.Request.Context(), db, uid, key)
+	return nil
+}
+
+// checkUploadQuota ตรวจ quota ของไฟล์หลายไฟล์ล่วงหน้า (ตอบ 413 เองถ้าเกิน)
+func checkUploadQuota(c *gin.Context, uid uint, files []*multipart.FileHeader) bool {
+	var total int64
+	for _, f := range files {
+		total += f.Size
+	}
+	if _, err := services.CheckUploadQuota(configs.DB(), uid, total); err != nil {
+		uploadError(c, err, err.Error())
+		return false
+	}
+	return true
+}
+
+// uploadError ตอบ error ของ storeUpload (เกิน quota = 413, อื่น ๆ = 500 พร้อม msg)
+func uploadError(c *gin.Context, err error, msg string) {
+	if errors.Is(err, services.ErrQuotaExceeded) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+}
+
+// serveStoredFile ส่งไฟล์จาก storage (downloadName != "" = บังคับดาวน์โหลด)
+// local อ่านจากดิสก์ตรง ๆ (รองรับ Range/If-Modified-Since) ส่วน storage ภายนอก redirect ไป signed URL
+func serveStoredFile(c *gin.Context, p, downloadName string) {
+	key := services.StorageKey(p)
+	if key == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "file not found"})
+		return
+	}
+	st := services.Files()
+	if downloadName != "" {
+		c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": downloadName}))
+	}
+
+	if _, ok := st.(*services.LocalStorage); !ok {
+		u, err := st.SignedURL(key, 15*time.Minute)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.Redirect(http.StatusFound, u)
+		return
+	}
+
+	rc, info, err := st.Get(c.Request.Context(), key)
+	if errors.Is(err, services.ErrObjectNotFound) || errors.Is(err, services.ErrInvalidKey) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "file not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer rc.Close()
+	if info.ContentType != "" {
+		c.Header("Content-Type", info.ContentType)
+	}
+	if rs, ok := rc.(io.ReadSeeker); ok {
+		http.ServeContent(c.Writer, c.Request, path.Base(key), info.ModTime, rs)
+		return
+	}
+	c.DataFromReader(http.StatusOK, info.Size, info.ContentType, rc, nil)
+}
+
+// GET /uploads/*filepath — แทน r.Static เดิม ให้ทุกไฟล์ผ่าน storage กลาง
+// ไฟล์ส่วนตัว (สลิป/ไฟล์แนบคำร้อง/ม็อด) ต้องมีลายเซ็นที่ยังไม่หมดอายุ หรือผู้เรียกเป็นเจ้าของ/มีสิทธิ์
+func ServeUpload(c *gin.Context) {
+	key := services.StorageKey(c.Param("filepath"))
+	if key != "" && services.IsPrivateUpload(key) {
+		if sig := c.Query("signature"); sig != "" {
+			local, ok := services.Files().(*services.LocalStorage)
+			if !ok || !local.VerifySignature(key, c.Query("expires"), sig) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "link expired or invalid"})
+				return
+			}
+		} else if !canReadPrivateUpload(configs.DB(), optionalUserID(c), key) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+			return
+		}
+	}
+	serveStoredFile(c, key, "")
+}
+
+// canReadPrivateUpload เจ้าของไฟล์ หรือผู้มี permission ของงานนั้น ๆ
+func canReadPrivateUpload(db *gorm.DB, uid uint, key string) bool {
+	if uid == 0 {
+		return false
+	}
+	dir, rest, _ := strings.Cut(key, "/")
+	ownerID, _, _ := strings.Cut(rest, "/")
+	var cnt int64
+	switch dir {
+	case "slips":
+		if services.UserHasPermission(db, uid, "payments.read", "payments.manage") {
+			return true
+		}
+		db.Table("payments").
+			Joins("JOIN orders ON orders.id = payments.order_id").
+			Where("payments.slip_path = ? AND orders.user_id = ? AND payments.deleted_at IS NULL", key, uid).
+			Count(&cnt)
+	case "reports":
+		if services.UserHasPermission(db, uid, "reports.read", "reports.manage") {
+			return true
+		}
+		db.Table("problem_reports").
+			Where("id = ? AND user_id = ? AND deleted_at IS NULL", ownerID, uid).
+			Count(&cnt)
+	case "replies":
+		if services.UserHasPermission(db, uid, "reports.read", "reports.manage") {
+			return true
+		}
+		db.Table("problem_replies").
+			Joins("JOIN problem_reports ON problem_reports.id = problem_replies.report_id").
+			Where("problem_replies.id = ? AND problem_reports.user_id = ? AND problem_replies.deleted_at IS NULL", ownerID, uid).
+			Count(&cnt)
+	case "mods":
+		if services.UserHasPermission(db, uid, "workshop.moderate") {
+			return true
+		}
+		db.Table("mods").
+			Where("file_path IN ? AND user_id = ? AND deleted_at IS NULL", []string{key, "uploads/" + key, "/uploads/" + key}, uid).
+			Count(&cnt)
+	}
+	return cnt > 0
+}
+
+// fileURL URL เต็มของไฟล์สำหรับตอบ FE (ไฟล์ส่วนตัวได้ signed URL)
+func fileURL(c *gin.Context, p string) string {
+	u := services.FileURL(p)
+	if strings.HasPrefix(u, "/") {
+		return baseURL(c) + u
+	}
+	return u
+}
+
+// bareFileURL URL เต็มแบบไม่มีลายเซ็น (ไฟล์ส่วนตัวต้องล็อกอินเป็นเจ้าของ/มีสิทธิ์ถึงจะเปิดได้)
+func bareFileURL(c *gin.Context, p string) string {
+	key := services.StorageKey(p)
+	if key == "" {
+		return ""
+	}
+	return baseURL(c) + "/uploads/" + key
+}
+
+// reportStaff ผู้ใช้ของคำขอมีสิทธิ์ดูคำร้องทุกใบ (reports.read/reports.manage) หรือไม่
+func reportStaff(c *gin.Context) bool {
+	uid := c.GetUint("userID")
+	return uid != 0 && services.UserHasPermission(configs.DB(), uid, "reports.read", "reports.manage")
+}
+
+// signReportFiles เติม url ให้ไฟล์แนบของคำร้องและคำตอบ
+// signed URL เฉพาะเจ้าของคำร้องหรือ staff นอกนั้นได้ path เปล่า
+func signReportFiles(c *gin.Context, rp *entity.ProblemReport) {
+	signReportFilesAs(c, rp, reportStaff(c))
+}
+
+// signReportFilesAs เหมือน signReportFiles แต่ส่งผลตรวจ staff มาเอง (ใช้ซ้ำในรายการหลายใบ)
+func signReportFilesAs(c *gin.Context, rp *entity.ProblemReport, staff bool) {
+	sign := staff || (rp.UserID != 0 && rp.UserID == c.GetUint("userID"))
+	for i := range rp.Attachments {
+		rp.Attachments[i].URL = reportFileURL(c, rp.Attachments[i].FilePath, sign)
+	}
+	for i := range rp.Replies {
+		for j := range rp.Replies[i].Attachments {
+			rp.Replies[i].Attachments[j].URL = reportFileURL(c, rp.Replies[i].Attachments[j].FilePath, sign)
+		}
+	}
+}
+
+func signReplyFiles(c *gin.Context, rep *entity.ProblemReply) {
+	sign := reportStaff(c)
+	if !sign {
+		var n int64
+		configs.DB().Model(&entity.ProblemReport{}).
+			Where("id = ? AND user_id = ?", rep.ReportID, c.GetUint("userID")).Count(&n)
+		sign = n > 0
+	}
+	for i := range rep.Attachments {
+		rep.Attachments[i].URL = reportFileURL(c, rep.Attachments[i].FilePath, sign)
+	}
+}
+
+func reportFileURL(c *gin.Context, p string, sign bool) string {
+	if sign {
+		return fileURL(c, p)
+	}
+	return bareFileURL(c, p)
+}
@@ -0,0 +1,324 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"example.com/sa-gameshop/configs"
+	"example.com/sa-gameshop/entity"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ฟิลด์ metadata ที่รับใน CreateGame/UpdateGamebyID
+// nil = ไม่แตะของเดิม, [] = ล้างทั้งหมด
+type gameMetaInput struct {
+	CategoryIDs  *[]uint           `json:"category_ids"`
+	GenreIDs     *[]uint           `json:"genre_ids"`
+	TagIDs       *[]uint           `json:"tag_ids"`
+	DeveloperIDs *[]uint           `json:"developer_ids"`
+	PublisherIDs *[]uint           `json:"publisher_ids"`
+	PlatformIDs  *[]uint           `json:"platform_ids"`
+	LanguageIDs  *[]uint           `json:"language_ids"`
+	Media        *[]gameMediaInput `json:"media"`
+}
+
+type gameMediaInput struct {
+	Kind    string `json:"kind"`
+	URL     string `json:"url"`
+	Caption string `json:"caption"`
+}
+
+type errBadMeta struct{ msg string }
+
+func (e errBadMeta) Error() string { return e.msg }
+
+// โหลดแถวตาม ids ให้ครบทุกตัว ไม่งั้นคืน errBadMeta
+func loadByIDs[T any](tx *gorm.DB, ids []uint, field string) ([]T, error) {
+	rows := []T{}
+	if len(ids) == 0 {
+		return rows, nil
+	}
+	if err := tx.Where("id IN ?", ids).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	if len(rows) != len(uniqueIDs(ids)) {
+		return nil, errBadMeta{"some " + field + " were not found"}
+	}
+	return rows, nil
+}
+
+func uniqueIDs(ids []uint) []uint {
+	seen := map[uint]bool{}
+	out := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+func replaceAssoc[T any](tx *gorm.DB, game *entity.Game, assoc string, ids *[]uint, field string) error {
+	if ids == nil {
+		return nil
+	}
+	rows, err := loadByIDs[T](tx, *ids, field)
+	if err != nil {
+		return err
+	}
+	return tx.Model(game).Association(assoc).Replace(rows)
+}
+
+// applyGameMeta อัปเดต many-to-many และแกลเลอรีของเกม
+func applyGameMeta(tx *gorm.DB, game *entity.Game, in gameMetaInput) error {
+	// หมวดหลักต้องอยู่ในรายการหมวดเสมอ
+	if in.CategoryIDs != nil && game.CategoriesID > 0 {
+		ids := append([]uint{uint(game.CategoriesID)}, *in.CategoryIDs...)
+		in.CategoryIDs = &ids
+	}
+	steps := []error{
+		replaceAssoc[entity.Categories](tx, game, "CategoryList", in.CategoryIDs, "category_ids"),
+		replaceAssoc[entity.Genre](tx, game, "Genres", in.GenreIDs, "genre_ids"),
+		replaceAssoc[entity.Tags](tx, game, "Tags", in.TagIDs, "tag_ids"),
+		replaceAssoc[entity.Company](tx, game, "Developers", in.DeveloperIDs, "developer_ids"),
+		replaceAssoc[entity.Company](tx, game, "Publishers", in.PublisherIDs, "publisher_ids"),
+		replaceAssoc[entity.Platform](tx, game, "Platforms", in.PlatformIDs, "platform_ids"),
+		replaceAssoc[entity.Language](tx, game, "Languages", in.LanguageIDs, "language_ids"),
+	}
+	for _, err := range steps {
+		if err != nil {
+			return err
+		}
+	}
+
+	if in.Media == nil {
+		return nil
+	}
+	media := make([]entity.GameMedia, 0, len(*in.Media))
+	for i, m := range *in.Media {
+		kind := strings.ToLower(strings.TrimSpace(m.Kind))
+		if kind == "" {
+			kind = entity.MediaScreenshot
+		}
+		if err := validateGameMedia(kind, m.URL); err != nil {
+			return errBadMeta{fmt.Sprintf("media[%d]: %s", i, err.Error())}
+		}
+		media = append(media, entity.GameMedia{
+			GameID:    game.ID,
+			Kind:      kind,
+			URL:       strings.TrimSpace(m.URL),
+			Caption:   strings.TrimSpace(m.Caption),
+			SortOrder: i,
+		})
+	}
+	if err := tx.Where("game_id = ?", game.ID).Delete(&entity.GameMedia{}).Error; err != nil {
+		return err
+	}
+	if len(media) == 0 {
+		return nil
+	}
+	return tx.Create(&media).Error
+}
+
+// screenshot ต้องมาจาก /upload/game; trailer เป็นลิงก์ภายนอก
+func validateGameMedia(kind, url string) error {
+	url = strings.TrimSpace(url)
+	switch kind {
+	case entity.MediaScreenshot:
+		if !strings.HasPrefix(url, "/uploads/games/") {
+			return errors.New("screenshot url must come from /upload/game")
+		}
+	case entity.MediaTrailer:
+		if !strings.HasPrefix(url, "https://") && !strings.HasPrefix(url, "http://") {
+			return errors.New("trailer url must be http(s)")
+		}
+	default:
+		return errors.New("kind must be screenshot or trailer")
+	}
+	return nil
+}
+
+// preload metadata ทั้งหมดสำหรับหน้ารายละเอียดเกม
+func preloadGameMeta(db *gorm.DB) *gorm.DB {
+	return db.
+		Preload("Categories").
+		Preload("CategoryList").
+		Preload("Genres").
+		Preload("Tags").
+		Preload("Developers").
+		Preload("Publishers").
+		Preload("Platforms").
+		Preload("Languages").
+		Preload("Media", func(tx *gorm.DB) *gorm.DB { return tx.Order("sort_order ASC, id ASC") }).
+		Preload("MinimumSpec").
+		Preload("Requirements", func(tx *gorm.DB) *gorm.DB { return tx.Order("os ASC, level ASC") }).
+		Preload("Ratings.RatingSystem").
+		Preload("Ratings.RatingLevel")
+}
+
+// POST /games/:id/media  (games.manage)
+// multipart: file, caption — อัปโหลดผ่าน pipeline เดียวกับ /upload/game แล้วต่อท้ายแกลเลอรี
+func AddGameMedia(c *gin.Context) {
+	db := configs.DB()
+	var game entity.Game
+	if tx := db.First(&game, c.Param("id")); tx.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "game not found"})
+		return
+	}
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+	_, url, err := saveGameImage(c, file)
+	if err != nil {
+		imageUploadError(c, err)
+		return
+	}
+
+	var maxOrder int
+	db.Model(&entity.GameMedia{}).Where("game_id = ?", game.ID).Select("COALESCE(MAX(sort_order), -1)").Scan(&maxOrder)
+	row := entity.GameMedia{
+		GameID:    game.ID,
+		Kind:      entity.MediaScreenshot,
+		URL:       url,
+		Caption:   strings.TrimSpace(c.PostForm("caption")),
+		SortOrder: maxOrder + 1,
+	}
+	if err := db.Create(&row).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, row)
+}
+
+// DELETE /games/:id/media/:media_id  (games.manage)
+func DeleteGameMedia(c *gin.Context) {
+	tx := configs.DB().Where("id = ? AND game_id = ?", c.Param("media_id"), c.Param("id")).Delete(&entity.GameMedia{})
+	if tx.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tx.Error.Error()})
+		return
+	}
+	if tx.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "media not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
+}
+
+// ===== Lookup tables =====
+
+// GET /genres
+func FindGenres(c *gin.Context) {
+	var rows []entity.Genre
+	if err := configs.DB().Order("name ASC").Find(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, rows)
+}
+
+// POST /genres  (games.manage)
+func CreateGenre(c *gin.Context) {
+	var body struct {
+		Name string `json:"name" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return
+	}
+	row := entity.Genre{Name: strings.TrimSpace(body.Name)}
+	if err := configs.DB().Create(&row).Error; err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, row)
+}
+
+// GET /platforms
+func FindPlatforms(c *gin.Context) {
+	var rows []entity.Platform
+	if err := configs.DB().Order("code ASC").Find(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, rows)
+}
+
+// POST /platforms  (games.manage)
+func CreatePlatform(c *gin.Context) {
+	var body struct {
+		Code string `json:"code" binding:"required"`
+		Name string `json:"name"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code is required"})
+		return
+	}
+	row := entity.Platform{Code: strings.ToLower(strings.TrimSpace(body.Code)), Name: strings.TrimSpace(body.Name)}
+	if err := configs.DB().Create(&row).Error; err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, row)
+}
+
+// GET /languages
+func FindLanguages(c *gin.Context) {
+	var rows []entity.Language
+	if err := configs.DB().Order("code ASC").Find(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, rows)
+}
+
+// POST /languages  (games.manage)
+func CreateLanguage(c *gin.Context) {
+	var body struct {
+		Code string `json:"code" binding:"required"`
+		Name string `json:"name"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code is required"})
+		return
+	}
+	row := entity.Language{Code: strings.ToLower(strings.TrimSpace(body.Code)), Name: strings.TrimSpace(body.Name)}
+	if err := configs.DB().Create(&row).Error; err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, row)
+}
+
+// GET /companies
+func FindCompanies(c *gin.Context) {
+	var rows []entity.Company
+	if err := configs.DB().Order("name ASC").Find(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, rows)
+}
+
+// POST /companies  (games.manage)
+func CreateCompany(c *gin.Context) {
+	var body struct {
+		Name    string `json:"name" binding:"required"`
+		Website string `json:"website"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return
+	}
+	row := entity.Company{Name: strings.TrimSpace(body.Name), Website: strings.TrimSpace(body.Website)}
+	if err := configs.DB().Create(&row).Error; err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, row)
+}
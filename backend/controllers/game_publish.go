@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"example.com/sa-gameshop/configs"
+	"example.com/sa-gameshop/entity"
+	"example.com/sa-gameshop/services"
+	"github.com/gin-gonic/gin"
+)
+
+// ผู้ใช้ของคำขอมีสิทธิ์จัดการเกม (games.manage) หรือไม่
+func canManageGames(c *gin.Context) bool {
+	uid := optionalUserID(c)
+	return uid != 0 && services.UserHasPermission(configs.DB(), uid, "games.manage")
+}
+
+// ผู้ใช้ของคำขอเป็นผู้สร้างเกมนี้หรือไม่
+func isGameOwner(c *gin.Context, game entity.Game) bool {
+	uid := optionalUserID(c)
+	return uid != 0 && game.OwnerID != nil && *game.OwnerID == uid
+}
+
+// POST /games/:id/status
+// body: { "status": "submitted|approved|rejected|published|delisted", "note": "...", "release_at": "RFC3339" }
+// ส่งตรวจ (submitted) ทำได้เฉพาะผู้สร้างเกมหรือผู้มีสิทธิ์ games.manage ที่เหลือต้องมี games.manage
+func ChangeGameStatus(c *gin.Context) {
+	var body struct {
+		Status    string     `json:"status" binding:"required"`
+		Note      string     `json:"note"`
+		ReleaseAt *time.Time `json:"release_at"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	to := strings.ToLower(strings.TrimSpace(body.Status))
+	if to == entity.GameRejected && strings.TrimSpace(body.Note) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "note is required when rejecting"})
+		return
+	}
+	if body.ReleaseAt != nil && to != entity.GameApproved {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "release_at is only allowed when approving"})
+		return
+	}
+
+	db := configs.DB()
+	var game entity.Game
+	if tx := db.First(&game, c.Param("id")); tx.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "game not found"})
+		return
+	}
+	if !canManageGames(c) && (to != entity.GameSubmitted || !isGameOwner(c, game)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return
+	}
+
+	err := services.TransitionGame(db, &game, to, optionalUserID(c), strings.TrimSpace(body.Note), body.ReleaseAt)
+	switch {
+	case errors.Is(err, services.ErrInvalidTransition):
+		c.JSON(http.StatusConflict, gin.H{"error": "cannot change status from " + game.Status + " to " + to})
+		return
+	case errors.Is(err, services.ErrReleaseInPast):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, game)
+}
+
+// GET /games/:id/status-logs  (games.manage)
+func FindGameStatusLogs(c *gin.Context) {
+	var logs []entity.GameStatusLog
+	if err := configs.DB().
+		Preload("Actor").
+		Where("game_id = ?", c.Param("id")).
+		Order("id DESC").
+		Find(&logs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, logs)
+}
@@ -1,7 +1,13 @@
 package controllers
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"example.com/sa-gameshop/configs"
@@ -87,33 +93,263 @@ import (
 		c.JSON(http.StatusOK, gin.H{"message": "deleted successful"})
 	}
 */
+// คอลัมน์ที่ใช้เรียงผลลัพธ์ของ /game (ทุกตัวเป็นตัวเลขและไม่เป็น NULL ใช้ทำ cursor ได้)
+var gameSortKeys = map[string]string{
+	"id":         "games.id",
+	"price":      "COALESCE(gp.price, %v)",             // ราคาของ region เท่านั้น ยังไม่มีราคา = ท้ายสุดทั้ง asc/desc (ดู gamePriceNullKey)
+	"release":    "COALESCE(julianday(games.date), 0)", // ไม่มีวันวางขาย = เก่าสุด
+	"rating":     "COALESCE((SELECT AVG(r.rating) FROM reviews r WHERE r.game_id = games.id AND r.deleted_at IS NULL), 0)",
+	"popularity": "(SELECT COUNT(*) FROM user_games ug WHERE ug.game_id = games.id AND ug.deleted_at IS NULL)",
+}
+
+// ค่าแทนราคาที่ยังไม่มีในแคชของ region ให้อยู่ท้ายเสมอ
+func gamePriceNullKey(desc bool) float64 {
+	if desc {
+		return -1
+	}
+	return 1e15
+}
+
+// cursor ของหน้าถัดไป = ค่า sort key + id ของแถวสุดท้าย
+type gameCursor struct {
+	K  float64 `json:"k"`
+	ID uint    `json:"id"`
+}
+
+func encodeGameCursor(cur gameCursor) string {
+	b, _ := json.Marshal(cur)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeGameCursor(s string) (gameCursor, error) {
+	var cur gameCursor
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return cur, err
+	}
+	err = json.Unmarshal(b, &cur)
+	return cur, err
+}
+
+// GET /game
+// query: q, category/genre/tag/platform/language (id คั่นด้วย ,), min_price, max_price (สกุลเงินของ region), on_sale=true,
+//
+//	age_rating (ไม่เกิน), status (เฉพาะ games.manage), os, sort=id|price|release|rating|popularity, order=asc|desc,
+//	limit (ค่าเริ่มต้น 50, สูงสุด 100), cursor, age_gate=blur|hide
+//
+// เกมที่ผู้ชมอายุไม่ถึงเรต: blur (ค่าเริ่มต้น) = แสดงแต่ซ่อนรูป/รายละเอียด, hide = ตัดออกจากผลลัพธ์
+// ผลลัพธ์ยังเป็น array เหมือนเดิม ส่วน cursor หน้าถัดไปอยู่ใน header X-Next-Cursor
 func FindGames(c *gin.Context) {
+	db := configs.DB()
+	region, err := requestRegion(c, db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "region not configured"})
+		return
+	}
+
+	sortBy := c.DefaultQuery("sort", "id")
+	keyExpr, ok := gameSortKeys[sortBy]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid sort"})
+		return
+	}
+	desc := strings.EqualFold(c.Query("order"), "desc")
+	if sortBy == "price" {
+		keyExpr = fmt.Sprintf(keyExpr, gamePriceNullKey(desc))
+	}
+
+	ageGate := c.DefaultQuery("age_gate", "blur")
+	if ageGate != "blur" && ageGate != "hide" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "age_gate must be blur or hide"})
+		return
+	}
+	viewer := requestViewer(c, db)
+
+	limit := 50
+	if v, err := strconv.Atoi(c.Query("limit")); err == nil && v > 0 {
+		limit = v
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	// ราคาจากตารางแคช (ยังไม่มีแคช → ใช้ base_price)
+	q := db.Table("games").
+		Joins("LEFT JOIN game_current_prices gp ON gp.game_id = games.id AND gp.region_id = ?", region.ID).
+		Where("games.deleted_at IS NULL")
+
+	if sql, args := services.GameSearchSQL(db, c.Query("q")); sql != "" {
+		q = q.Where(sql, args...)
+	}
+	if v := c.Query("category"); v != "" {
+		var ids []int
+		for _, part := range strings.Split(v, ",") {
+			id, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid category"})
+				return
+			}
+			ids = append(ids, id)
+		}
+		// รวมหมวดย่อยทุกชั้น
+		q = q.Where("games.id IN (SELECT game_id FROM game_categories WHERE categories_id IN ("+services.CategorySubtreeSQL+"))", ids)
+	}
+	for param, table := range map[string]string{
+		"genre":    "game_genres WHERE genre_id",
+		"tag":      "game_tags WHERE tags_id",
+		"platform": "game_platforms WHERE platform_id",
+		"language": "game_languages WHERE language_id",
+	} {
+		if v := c.Query(param); v != "" {
+			var ids []uint
+			for _, part := range strings.Split(v, ",") {
+				id, err := strconv.Atoi(strings.TrimSpace(part))
+				if err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + param})
+					return
+				}
+				ids = append(ids, uint(id))
+			}
+			q = q.Where("games.id IN (SELECT game_id FROM "+table+" IN ?)", ids)
+		}
+	}
+	// กรองด้วยราคาของ region เท่านั้น (base_price อาจเป็นคนละสกุลเงิน) เกมที่ยังไม่มีราคาใน region จึงไม่ผ่าน
+	if v, err := strconv.ParseFloat(c.Query("min_price"), 64); err == nil {
+		q = q.Where("gp.price >= ?", v)
+	}
+	if v, err := strconv.ParseFloat(c.Query("max_price"), 64); err == nil {
+		q = q.Where("gp.price <= ?", v)
+	}
+	if c.Query("on_sale") == "true" {
+		q = q.Where("gp.on_sale = ?", true)
+	}
+	if v, err := strconv.Atoi(c.Query("age_rating")); err == nil {
+		q = q.Where("games.age_rating <= ?", v)
+	}
+	// หน้าร้านเห็นเฉพาะเกมที่ published; ผู้มีสิทธิ์ games.manage กรองสถานะอื่นได้ (status=a,b หรือ all = ทุกสถานะ)
+	if v := c.Query("status"); v != "" && canManageGames(c) {
+		if v != "all" {
+			q = q.Where("games.status IN ?", strings.Split(v, ","))
+		}
+	} else {
+		q = q.Where("games.status = ?", entity.GamePublished)
+	}
+	if ageGate == "hide" {
+		sub, args := services.RestrictedGameIDsSQL(viewer, region)
+		q = q.Where("games.id NOT IN ("+sub+")", args...)
+	}
+	if v := strings.TrimSpace(c.Query("os")); v != "" {
+		q = q.Where(`EXISTS (SELECT 1 FROM system_requirements sr
+			WHERE sr.game_id = games.id AND sr.os = ? AND sr.deleted_at IS NULL)`, services.NormalizeOS(v))
+	}
+
+	if v := c.Query("cursor"); v != "" {
+		cur, err := decodeGameCursor(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid cursor"})
+			return
+		}
+		if desc {
+			q = q.Where("("+keyExpr+" < ? OR ("+keyExpr+" = ? AND games.id < ?))", cur.K, cur.K, cur.ID)
+		} else {
+			q = q.Where("("+keyExpr+" > ? OR ("+keyExpr+" = ? AND games.id > ?))", cur.K, cur.K, cur.ID)
+		}
+	}
+
+	dir := "ASC"
+	if desc {
+		dir = "DESC"
+	}
+	var page []gameCursor
+	if err := q.Select(keyExpr + " AS k, games.id AS id").
+		Order(keyExpr + " " + dir + ", games.id " + dir).
+		Limit(limit + 1).
+		Scan(&page).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(page) > limit {
+		page = page[:limit]
+		c.Header("X-Next-Cursor", encodeGameCursor(page[len(page)-1]))
+	}
+
+	ids := make([]uint, 0, len(page))
+	for _, p := range page {
+		ids = append(ids, p.ID)
+	}
+
 	var games []entity.Game
 	now := time.Now()
-	if err := configs.DB().
-		Preload("Categories").
-		Preload("Promotions", "status = ? AND start_date <= ? AND end_date >= ?", true, now, now).
-		Preload("Requests").
-		Preload("MinimumSpec").
-		Find(&games).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-		return
+	if len(ids) > 0 {
+		if err := db.
+			Preload("Categories").
+			Preload("Promotions", "status = ? AND start_date <= ? AND end_date >= ?", true, now, now).
+			Preload("Requests").
+			Preload("MinimumSpec").
+			Preload("RegionPrices", "region_id = ?", region.ID).
+			Where("id IN ?", ids).
+			Find(&games).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	byID := make(map[uint]entity.Game, len(games))
+	for _, g := range games {
+		byID[g.ID] = g
 	}
 
 	type response struct {
 		entity.Game
-		DiscountedPrice float64 `json:"discounted_price"`
+		RegionCode      string   `json:"region_code"`
+		Currency        string   `json:"currency"`
+		Price           float64  `json:"price"`
+		DiscountedPrice float64  `json:"discounted_price"`
+		LowestPrice30d  *float64 `json:"lowest_price_30d"`
+		MinAge          int      `json:"min_age"`
+		AgeRestricted   bool     `json:"age_restricted"`
 	}
 
-	var res []response
-	for _, g := range games {
-		discounted := float64(g.BasePrice)
-		for _, p := range g.Promotions {
-			if price := services.ApplyDiscount(float64(g.BasePrice), p.DiscountType, p.DiscountValue); price < discounted {
-				discounted = price
-			}
+	// ราคาต่ำสุด 30 วันก่อนลดราคาของเกมในหน้านี้ (query เดียว)
+	lowest := map[uint]float64{}
+	if len(ids) > 0 {
+		lowest, _ = services.LowestPrices(db, region.ID, ids, now)
+	}
+	minAges, err := services.GameMinAges(db, ids, region)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	currency := ""
+	if region.Currency != nil {
+		currency = region.Currency.Code
+	}
+	res := []response{}
+	for _, id := range ids {
+		g, ok := byID[id]
+		if !ok {
+			continue
 		}
-		res = append(res, response{Game: g, DiscountedPrice: discounted})
+		price := services.RegionalBasePrice(g, region)
+		row := response{
+			Game:            g,
+			RegionCode:      region.Code,
+			Currency:        currency,
+			Price:           price,
+			DiscountedPrice: services.ApplyPromotionsInRegion(price, g.Promotions, region),
+		}
+		if v, ok := lowest[g.ID]; ok {
+			row.LowestPrice30d = &v
+		}
+		row.MinAge = minAges[g.ID]
+		if row.MinAge > viewer.MaxAllowedAge() {
+			// blur: ให้หน้าร้านรู้ว่ามีเกมนี้ แต่ไม่ส่งรูป/รายละเอียด
+			row.AgeRestricted = true
+			row.ImgSrc = ""
+			row.Description = ""
+			row.Media = nil
+		}
+		res = append(res, row)
 	}
 
 	c.JSON(http.StatusOK, res)
@@ -121,21 +357,40 @@ func FindGames(c *gin.Context) {
 
 func FindGameByID(c *gin.Context) {
 	var game entity.Game
-	if tx := configs.DB().First(&game, c.Param("id")); tx.RowsAffected == 0 {
+	if tx := preloadGameMeta(configs.DB()).First(&game, c.Param("id")); tx.RowsAffected == 0 {
 		c.JSON(http.StatusNotFound, gin.H{"error": "game not found"})
 		return
 	}
+	if canManageGames(c) || isGameOwner(c, game) {
+		c.JSON(http.StatusOK, game)
+		return
+	}
+	if game.Status != entity.GamePublished {
+		c.JSON(http.StatusNotFound, gin.H{"error": "game not found"})
+		return
+	}
+	db := configs.DB()
+	region, err := requestRegion(c, db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "region not configured"})
+		return
+	}
+	if !gameAgeAllowed(c, db, requestViewer(c, db), game.ID, region) {
+		return
+	}
 	c.JSON(http.StatusOK, game)
 }
 func CreateGame(c *gin.Context) {
 	var input struct {
-		GameName  string `json:"game_name" binding:"required"`
-		BasePrice int    `json:"base_price" binding:"required"`
-		AgeRating int    `json:"age_rating"`
-		ImgSrc    string `json:"img_src"`
+		GameName    string `json:"game_name" binding:"required"`
+		Description string `json:"description"`
+		BasePrice   int    `json:"base_price" binding:"required"`
+		AgeRating   int    `json:"age_rating"`
+		ImgSrc      string `json:"img_src"`
 		//Minimum_spec_id int                `json:"minimum_spec_id"`
 		MinimumSpec  entity.MinimumSpec `json:"minimum_spec" binding:"required"`
 		CategoriesID int                `json:"categories_id" binding:"required"`
+		gameMetaInput
 	}
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -143,21 +398,55 @@ func CreateGame(c *gin.Context) {
 	}
 
 	games := entity.Game{
-		GameName:  input.GameName,
-		BasePrice: input.BasePrice,
-		AgeRating: input.AgeRating,
-		ImgSrc:    input.ImgSrc,
+		GameName:    input.GameName,
+		Description: input.Description,
+		BasePrice:   input.BasePrice,
+		AgeRating:   input.AgeRating,
+		ImgSrc:      input.ImgSrc,
 		//Minimum_specID: uint(input.Minimum_spec_id),
 		MinimumSpec:  input.MinimumSpec,
 		CategoriesID: input.CategoriesID,
 	}
-	if err := configs.DB().Session(&gorm.Session{FullSaveAssociations: true}).Create(&games).Error; err != nil {
+	if uid := c.GetUint("userID"); uid != 0 {
+		games.OwnerID = &uid
+	}
+	if input.CategoryIDs == nil {
+		input.CategoryIDs = &[]uint{}
+	}
+	err := configs.DB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Session(&gorm.Session{FullSaveAssociations: true}).Create(&games).Error; err != nil {
+			return err
+		}
+		return applyGameMeta(tx, &games, input.gameMetaInput)
+	})
+	var bad errBadMeta
+	if errors.As(err, &bad) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	recordPrices(configs.DB(), []uint{games.ID}, entity.PriceInitial, nil)
 
+	preloadGameMeta(configs.DB()).First(&games, games.ID)
 	c.JSON(http.StatusOK, games)
 }
+
+// ฟิลด์ที่แก้ได้ใน PUT /update-game/:id (nil = ไม่แตะของเดิม)
+// สถานะเปลี่ยนผ่าน POST /games/:id/status ส่วนราคาตาม region ผ่าน PUT /games/:id/prices
+type gameUpdateInput struct {
+	GameName     *string             `json:"game_name"`
+	Description  *string             `json:"description"`
+	CategoriesID *int                `json:"categories_id"`
+	BasePrice    *int                `json:"base_price"`
+	AgeRating    *int                `json:"age_rating"`
+	ImgSrc       *string             `json:"img_src"`
+	MinimumSpec  *entity.MinimumSpec `json:"minimum_spec"`
+	gameMetaInput
+}
+
 func UpdateGamebyID(c *gin.Context) {
 	var games entity.Game
 	id := c.Param("id")
@@ -167,11 +456,59 @@ func UpdateGamebyID(c *gin.Context) {
 		return
 	}
 
-	if err := c.ShouldBindJSON(&games); err != nil {
+	var input gameUpdateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	updates := map[string]interface{}{}
+	if input.GameName != nil {
+		updates["game_name"] = *input.GameName
+	}
+	if input.Description != nil {
+		updates["description"] = *input.Description
+	}
+	if input.CategoriesID != nil {
+		updates["categories_id"] = *input.CategoriesID
+	}
+	if input.BasePrice != nil {
+		updates["base_price"] = *input.BasePrice
+	}
+	if input.AgeRating != nil {
+		updates["age_rating"] = *input.AgeRating
+	}
+	if input.ImgSrc != nil {
+		updates["img_src"] = *input.ImgSrc
+	}
+	oldPrice := games.BasePrice
 
-	configs.DB().Save(&games)
+	err := configs.DB().Transaction(func(tx *gorm.DB) error {
+		if spec := input.MinimumSpec; spec != nil {
+			spec.ID = games.Minimum_specID
+			if err := tx.Select("OS", "Processor", "Memory", "Graphics", "Storage").Save(spec).Error; err != nil {
+				return err
+			}
+			updates["minimum_spec_id"] = spec.ID
+		}
+		if len(updates) > 0 {
+			if err := tx.Model(&games).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+		return applyGameMeta(tx, &games, input.gameMetaInput)
+	})
+	var bad errBadMeta
+	if errors.As(err, &bad) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if games.BasePrice != oldPrice {
+		recordPrices(configs.DB(), []uint{games.ID}, entity.PriceBaseChange, nil)
+	}
+	preloadGameMeta(configs.DB()).First(&games, games.ID)
 	c.JSON(http.StatusOK, games)
 }
@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"example.com/sa-gameshop/configs"
+	"example.com/sa-gameshop/entity"
+	"example.com/sa-gameshop/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ผู้ส่ง/ผู้รับในคำตอบ: แค่ id กับ username (ไม่ให้อีกฝ่ายเห็นอีเมล/วันเกิด)
+type giftUser struct {
+	ID       uint   `json:"id"`
+	Username string `json:"username"`
+}
+
+type giftResponse struct {
+	entity.Gift
+	Sender    *giftUser `json:"sender,omitempty"`
+	Recipient *giftUser `json:"recipient,omitempty"`
+}
+
+func selectGiftUser(db *gorm.DB) *gorm.DB { return db.Select("id", "username") }
+
+func toGiftUser(u *entity.User) *giftUser {
+	if u == nil {
+		return nil
+	}
+	return &giftUser{ID: u.ID, Username: u.Username}
+}
+
+func toGiftResponse(g entity.Gift) giftResponse {
+	return giftResponse{Gift: g, Sender: toGiftUser(g.Sender), Recipient: toGiftUser(g.Recipient)}
+}
+
+// GET /gifts?box=received|sent — ของขวัญที่ได้รับ (ค่าเริ่มต้น) หรือที่ส่งไป
+// กล่องผู้รับไม่แสดงของขวัญที่ยังไม่ชำระเงิน
+func FindMyGifts(c *gin.Context) {
+	uid := c.GetUint("userID")
+	tx := configs.DB().
+		Preload("Sender", selectGiftUser).Preload("Recipient", selectGiftUser).
+		Preload("Order").Preload("Order.OrderItems").Preload("Order.OrderItems.Game")
+
+	switch c.DefaultQuery("box", "received") {
+	case "received":
+		tx = tx.Where("recipient_id = ? AND status <> ?", uid, entity.GiftPending)
+	case "sent":
+		tx = tx.Where("sender_id = ?", uid)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "box must be received or sent"})
+		return
+	}
+	if s := c.Query("status"); s != "" {
+		tx = tx.Where("status = ?", s)
+	}
+
+	var gifts []entity.Gift
+	if err := tx.Order("id DESC").Find(&gifts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	out := make([]giftResponse, 0, len(gifts))
+	for _, g := range gifts {
+		out = append(out, toGiftResponse(g))
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// POST /gifts/:id/claim — ผู้รับกดรับ เกมเข้าคลัง
+func ClaimGift(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	g, err := services.ClaimGift(configs.DB(), uint(id), c.GetUint("userID"))
+	if err != nil {
+		giftError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, toGiftResponse(g))
+}
+
+// POST /gifts/:id/decline {"reason": "..."} — ผู้รับปฏิเสธ ระบบคืนเงินให้ผู้ซื้อ
+func DeclineGift(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	var body struct {
+		Reason string `json:"reason"`
+	}
+	_ = c.ShouldBindJSON(&body) // อนุญาตให้ว่างได้
+	g, err := services.DeclineGift(configs.DB(), uint(id), c.GetUint("userID"), body.Reason)
+	if err != nil {
+		giftError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, toGiftResponse(g))
+}
+
+func giftError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrGiftNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrGiftNotClaimable):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,25 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+
+	"example.com/sa-gameshop/entity"
+	"example.com/sa-gameshop/services"
+	"gorm.io/gorm"
+)
+
+// notifyMentions แจ้งผู้ถูก @ ในโพสต์ (previously = คนที่ถูก @ ก่อนแก้ไข จะไม่ถูกแจ้งซ้ำ)
+// ผิดพลาดแค่ log ไว้ ไม่ทำให้คำขอล้ม
+func notifyMentions(db *gorm.DB, authorID uint, mentioned, previously []uint, where string, n entity.Notification) {
+	if len(mentioned) == 0 {
+		return
+	}
+	var author entity.User
+	db.Select("id, username").Limit(1).Find(&author, authorID)
+	n.Title = "มีคนกล่าวถึงคุณ"
+	n.Message = fmt.Sprintf("%s กล่าวถึงคุณใน%s", author.Username, where)
+	if err := services.NotifyMentions(db, authorID, mentioned, previously, n); err != nil {
+		log.Println("[Mention] notify error:", err)
+	}
+}
@@ -1,16 +1,14 @@
 package controllers
 
 import (
-	"fmt"
 	"net/http"
-	"os"
 	"path" // ใช้ทำ web path (forward slash)
-	"path/filepath"
 	"strconv"
 	"time"
 
 	"example.com/sa-gameshop/configs"
 	"example.com/sa-gameshop/entity"
+	"example.com/sa-gameshop/services"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
@@ -19,7 +17,7 @@ import (
 func GetMods(c *gin.Context) {
 	var mods []entity.Mod
 
-	q := configs.DB().Model(&entity.Mod{})
+	q := configs.DB().Model(&entity.Mod{}).Where("hidden = ?", false)
 
 	if gid := c.Query("game_id"); gid != "" {
 		q = q.Where("game_id = ?", gid)
@@ -44,7 +42,8 @@ func GetModById(c *gin.Context) {
 	id := c.Param("id")
 	var mod entity.Mod
 	db := configs.DB()
-	if tx := db.Where("id = ?", id).First(&mod); tx.RowsAffected == 0 {
+	if tx := db.Where("id = ?", id).First(&mod); tx.RowsAffected == 0 ||
+		(mod.Hidden && !canSeeHidden(c, db, "mod", mod.UserID)) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "mod not found"})
 		return
 	}
@@ -123,6 +122,9 @@ func CreateMod(c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "please login first"})
 		return
 	}
+	if restrictionBlocked(c, configs.DB(), uploaderID, entity.RestrictWorkshop) {
+		return
+	}
 
 	// ต้องเป็นเจ้าของเกม
 	db := configs.DB()
@@ -156,15 +158,9 @@ func CreateMod(c *gin.Context) {
 	imageFile, _ := c.FormFile("image")
 
 	// ---------- บันทึกไฟล์ม็อด ----------
-	modFilename := fmt.Sprintf("%d_%s", time.Now().UnixNano(), modFile.Filename)
-	modDirFS := filepath.Join("uploads", "mods")
-	if err := os.MkdirAll(modDirFS, os.ModePerm); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create mod directory"})
-		return
-	}
-	modPathFS := filepath.Join(modDirFS, modFilename)
-	if err := c.SaveUploadedFile(modFile, modPathFS); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save mod file"})
+	modFilename := uploadFileName(modFile.Filename)
+	if err := storeUpload(c, modFile, path.Join("mods", modFilename), uploaderID); err != nil {
+		uploadError(c, err, "failed to save mod file")
 		return
 	}
 	modPathWeb := path.Join("uploads", "mods", modFilename)
@@ -172,18 +168,12 @@ func CreateMod(c *gin.Context) {
 	// ---------- บันทึกรูป (ถ้ามี) ----------
 	imagePathWeb := ""
 	if imageFile != nil {
-		imgFilename := fmt.Sprintf("%d_%s", time.Now().UnixNano(), imageFile.Filename)
-		imgDirFS := filepath.Join("uploads", "mod_images")
-		if err := os.MkdirAll(imgDirFS, os.ModePerm); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create image directory"})
+		img, err := saveImageUpload(c, imageFile, "mod_images")
+		if err != nil {
+			imageUploadError(c, err)
 			return
 		}
-		imgPathFS := filepath.Join(imgDirFS, imgFilename)
-		if err := c.SaveUploadedFile(imageFile, imgPathFS); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save image"})
-			return
-		}
-		imagePathWeb = path.Join("uploads", "mod_images", imgFilename)
+		imagePathWeb = img.Path
 	}
 
 	// ✅ สร้าง record
@@ -202,6 +192,7 @@ func CreateMod(c *gin.Context) {
 	}
 
 	if err := db.Create(&mod).Error; err != nil {
+		services.ReleaseUpload(c.Request.Context(), db, modPathWeb, imagePathWeb)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -230,6 +221,9 @@ func UpdateMod(c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
 		return
 	}
+	if restrictionBlocked(c, configs.DB(), mod.UserID, entity.RestrictWorkshop) {
+		return
+	}
 
 	// whitelist ฟิลด์ที่อัปเดตได้
 	type updateInput struct {
@@ -313,6 +307,9 @@ func ReplaceModFile(c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
 		return
 	}
+	if restrictionBlocked(c, configs.DB(), mod.UserID, entity.RestrictWorkshop) {
+		return
+	}
 
 	file, err := c.FormFile("file")
 	if err != nil {
@@ -321,24 +318,21 @@ func ReplaceModFile(c *gin.Context) {
 	}
 
 	// บันทึกไฟล์ใหม่
-	filename := fmt.Sprintf("%d_%s", time.Now().UnixNano(), file.Filename)
-	dirFS := filepath.Join("uploads", "mods")
-	if err := os.MkdirAll(dirFS, os.ModePerm); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create directory"})
-		return
-	}
-	pathFS := filepath.Join(dirFS, filename)
-	if err := c.SaveUploadedFile(file, pathFS); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save file"})
+	filename := uploadFileName(file.Filename)
+	if err := storeUpload(c, file, path.Join("mods", filename), mod.UserID); err != nil {
+		uploadError(c, err, "failed to save file")
 		return
 	}
 	pathWeb := path.Join("uploads", "mods", filename)
 
-	// อัปเดต DB
+	// อัปเดต DB แล้วลบไฟล์เดิม
+	oldPath := mod.FilePath
 	if err := configs.DB().Model(&mod).Update("file_path", pathWeb).Error; err != nil {
+		services.ReleaseUpload(c.Request.Context(), configs.DB(), pathWeb)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	services.ReleaseUpload(c.Request.Context(), configs.DB(), oldPath)
 	// อ่านกลับ
 	if err := configs.DB().Where("id = ?", id).First(&mod).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -376,23 +370,19 @@ func ReplaceModImage(c *gin.Context) {
 		return
 	}
 
-	filename := fmt.Sprintf("%d_%s", time.Now().UnixNano(), img.Filename)
-	dirFS := filepath.Join("uploads", "mod_images")
-	if err := os.MkdirAll(dirFS, os.ModePerm); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create directory"})
-		return
-	}
-	pathFS := filepath.Join(dirFS, filename)
-	if err := c.SaveUploadedFile(img, pathFS); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save image"})
+	stored, err := saveImageUpload(c, img, "mod_images")
+	if err != nil {
+		imageUploadError(c, err)
 		return
 	}
-	pathWeb := path.Join("uploads", "mod_images", filename)
 
-	if err := configs.DB().Model(&mod).Update("image_path", pathWeb).Error; err != nil {
+	oldImage := mod.ImagePath
+	if err := configs.DB().Model(&mod).Update("image_path", stored.Path).Error; err != nil {
+		services.ReleaseUpload(c.Request.Context(), configs.DB(), stored.Path)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	services.ReleaseUpload(c.Request.Context(), configs.DB(), oldImage)
 	if err := configs.DB().Where("id = ?", id).First(&mod).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -426,6 +416,7 @@ func DeleteMod(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	services.ReleaseUpload(c.Request.Context(), configs.DB(), mod.FilePath, mod.ImagePath)
 	c.JSON(http.StatusOK, gin.H{"message": "deleted successfully"})
 }
 
@@ -434,11 +425,12 @@ func DownloadMod(c *gin.Context) {
 	id := c.Param("id")
 	var mod entity.Mod
 	db := configs.DB()
-	if tx := db.First(&mod, id); tx.RowsAffected == 0 {
+	if tx := db.First(&mod, id); tx.RowsAffected == 0 ||
+		(mod.Hidden && !canSeeHidden(c, db, "mod", mod.UserID)) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "mod not found"})
 		return
 	}
 	db.Model(&mod).UpdateColumn("download_count", gorm.Expr("download_count + 1"))
 	mod.DownloadCount++
-	c.File(mod.FilePath)
+	serveStoredFile(c, mod.FilePath, path.Base(mod.FilePath))
 }
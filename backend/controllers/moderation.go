@@ -0,0 +1,80 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"example.com/sa-gameshop/configs"
+	"example.com/sa-gameshop/entity"
+	"example.com/sa-gameshop/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// เหตุผลของผู้ดูแล: จาก body {"reason": "..."} หรือ ?reason= (DELETE มักไม่มี body)
+func moderationReason(c *gin.Context, fromBody string) string {
+	if s := strings.TrimSpace(fromBody); s != "" {
+		return s
+	}
+	return strings.TrimSpace(c.Query("reason"))
+}
+
+// เหตุผลสำหรับ DELETE (body ไม่บังคับ)
+func deleteReason(c *gin.Context) string {
+	var body struct {
+		Reason string `json:"reason"`
+	}
+	_ = c.ShouldBindJSON(&body) // อนุญาตให้ว่างได้
+	return moderationReason(c, body.Reason)
+}
+
+// communityWriteAllowed เจ้าของ หรือผู้มีสิทธิ์ community.moderate (ต้องมีเหตุผล)
+// ไม่ผ่าน = ตอบ error ไปแล้ว, moderator = true ถ้าต้องบันทึก log
+func communityWriteAllowed(c *gin.Context, db *gorm.DB, authorID uint, reason string) (moderator bool, ok bool) {
+	return moderatedWriteAllowed(c, db, services.PermCommunityModerate, authorID, reason)
+}
+
+// moderatedWriteAllowed เจ้าของ หรือผู้มีสิทธิ์ perm (ต้องมีเหตุผล) — ใช้กับเนื้อหาที่ไม่ได้อยู่ใต้ community.moderate
+func moderatedWriteAllowed(c *gin.Context, db *gorm.DB, perm string, authorID uint, reason string) (moderator bool, ok bool) {
+	moderator, err := services.AuthorizeModeratedWrite(db, c.GetUint("userID"), authorID, perm, reason)
+	switch {
+	case errors.Is(err, services.ErrModerationReason):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return moderator, false
+	case err != nil:
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return moderator, false
+	}
+	return moderator, true
+}
+
+// บันทึก log การกระทำของผู้ดูแล (error แค่ log ไม่ให้ request ล้ม)
+func logModeration(c *gin.Context, db *gorm.DB, targetType string, targetID, authorID uint, action, reason, snapshot string) {
+	if err := services.LogModeration(db, c.GetUint("userID"), targetType, targetID, authorID, action, reason, snapshot); err != nil {
+		log.Println("[Moderation] log error:", err)
+	}
+}
+
+// GET /moderation/logs?target_type=&target_id=&actor_id=&author_id=&limit=&offset=
+func FindModerationLogs(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	tx := configs.DB().Preload("Actor").Model(&entity.ModerationLog{})
+	for _, f := range []string{"target_type", "target_id", "actor_id", "author_id"} {
+		if v := c.Query(f); v != "" {
+			tx = tx.Where(f+" = ?", v)
+		}
+	}
+	var rows []entity.ModerationLog
+	if err := tx.Order("id DESC").Limit(limit).Offset(offset).Find(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, rows)
+}
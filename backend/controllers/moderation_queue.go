@@ -0,0 +1,554 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"example.com/sa-gameshop/configs"
+	"example.com/sa-gameshop/entity"
+	"example.com/sa-gameshop/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// mute/ban นานสุด 1 ปี (ถ้าต้องการถาวรให้ใช้ /users ปิดบัญชี)
+const maxRestrictionHours = 24 * 365
+
+var targetTypeLabel = map[string]string{
+	"thread":  "กระทู้",
+	"comment": "ความคิดเห็น",
+	"review":  "รีวิว",
+	"mod":     "ม็อด",
+}
+
+// POST /content-reports {"target_type": "thread", "target_id": 1, "category": "spam", "details": "..."}
+func CreateContentReport(c *gin.Context) {
+	var body struct {
+		TargetType string `json:"target_type" binding:"required"`
+		TargetID   uint   `json:"target_id"   binding:"required"`
+		Category   string `json:"category"    binding:"required"`
+		Details    string `json:"details"     binding:"max=2000"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad request body"})
+		return
+	}
+	if !services.ValidReportCategory(body.Category) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid category"})
+		return
+	}
+	uid := c.GetUint("userID")
+	db := configs.DB()
+
+	t, err := services.LoadModerationTarget(db, body.TargetType, body.TargetID)
+	if errors.Is(err, services.ErrUnknownTarget) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil || t.Removed {
+		c.JSON(http.StatusNotFound, gin.H{"error": "target not found"})
+		return
+	}
+	if t.AuthorID == uid {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cannot report your own content"})
+		return
+	}
+
+	var n int64
+	db.Model(&entity.ContentReport{}).
+		Where("reporter_id = ? AND target_type = ? AND target_id = ? AND status = ?", uid, t.Type, t.ID, entity.ReportOpen).
+		Count(&n)
+	if n > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "already reported"})
+		return
+	}
+
+	row := entity.ContentReport{
+		ReporterID: uid,
+		TargetType: t.Type,
+		TargetID:   t.ID,
+		AuthorID:   t.AuthorID,
+		Category:   body.Category,
+		Details:    strings.TrimSpace(body.Details),
+		Status:     entity.ReportOpen,
+	}
+	if err := db.Create(&row).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, row)
+}
+
+// GET /content-reports/mine — รายงานที่ผู้ใช้ส่ง พร้อมสถานะ
+func FindMyContentReports(c *gin.Context) {
+	var rows []entity.ContentReport
+	if err := configs.DB().Where("reporter_id = ?", c.GetUint("userID")).
+		Order("id DESC").Find(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, rows)
+}
+
+type queueItem struct {
+	TargetType    string                     `json:"target_type"`
+	TargetID      uint                       `json:"target_id"`
+	ReportCount   int64                      `json:"report_count"`
+	FirstReportID uint                       `json:"first_report_id"`
+	Categories    string                     `json:"categories"`
+	Target        *services.ModerationTarget `json:"target"`
+	Reports       []entity.ContentReport     `json:"reports" gorm:"-"`
+}
+
+// GET /moderation/queue?target_type=&status=open&limit=&offset=
+// รวมรายงานตามเนื้อหา เรียงจากถูกรายงานมากสุด แสดงเฉพาะชนิดที่ผู้ใช้มีสิทธิ์
+func FindModerationQueue(c *gin.Context) {
+	db := configs.DB()
+	types := services.ModeratableTypes(db, c.GetUint("userID"))
+	if t := c.Query("target_type"); t != "" {
+		if _, ok := services.ModerationPermFor(t); !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": services.ErrUnknownTarget.Error()})
+			return
+		}
+		allowed := false
+		for _, x := range types {
+			allowed = allowed || x == t
+		}
+		if !allowed {
+			c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+			return
+		}
+		types = []string{t}
+	}
+	status := c.DefaultQuery("status", entity.ReportOpen)
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	base := db.Model(&entity.ContentReport{}).Where("target_type IN ? AND status = ?", types, status)
+	var total int64
+	if err := db.Table("(?) AS q", base.Session(&gorm.Session{}).Select("target_type, target_id").Group("target_type, target_id")).
+		Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var items []queueItem
+	if err := base.Select("target_type, target_id, COUNT(*) AS report_count, MIN(id) AS first_report_id, GROUP_CONCAT(DISTINCT category) AS categories").
+		Group("target_type, target_id").
+		Order("report_count DESC, first_report_id ASC").
+		Limit(limit).Offset(offset).
+		Scan(&items).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	for i := range items {
+		it := &items[i]
+		if t, err := services.LoadModerationTarget(db, it.TargetType, it.TargetID); err == nil {
+			it.Target = &t
+		}
+		db.Preload("Reporter").
+			Where("target_type = ? AND target_id = ? AND status = ?", it.TargetType, it.TargetID, status).
+			Order("id ASC").Find(&it.Reports)
+	}
+	c.Header("X-Total-Count", strconv.FormatInt(total, 10))
+	c.JSON(http.StatusOK, items)
+}
+
+// POST /moderation/actions
+// {"target_type": "comment", "target_id": 5, "action": "hide|delete|lock|warn|mute|ban|dismiss", "reason": "...", "duration_hours": 72}
+func TakeModerationAction(c *gin.Context) {
+	var body struct {
+		TargetType    string `json:"target_type" binding:"required"`
+		TargetID      uint   `json:"target_id"   binding:"required"`
+		Action        string `json:"action"      binding:"required"`
+		Reason        string `json:"reason"`
+		DurationHours int    `json:"duration_hours"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad request body"})
+		return
+	}
+	uid := c.GetUint("userID")
+	db := configs.DB()
+
+	perm, ok := services.ModerationPermFor(body.TargetType)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": services.ErrUnknownTarget.Error()})
+		return
+	}
+	if !services.UserHasPermission(db, uid, perm) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return
+	}
+	reason := strings.TrimSpace(body.Reason)
+	if reason == "" && body.Action != services.ActionDismiss {
+		c.JSON(http.StatusBadRequest, gin.H{"error": services.ErrModerationReason.Error()})
+		return
+	}
+
+	t, err := services.LoadModerationTarget(db, body.TargetType, body.TargetID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "target not found"})
+		return
+	}
+
+	var expires *time.Time
+	switch body.Action {
+	case services.ActionHide:
+		if t.Hidden || t.Removed {
+			c.JSON(http.StatusConflict, gin.H{"error": "target already hidden"})
+			return
+		}
+	case services.ActionDelete:
+		if t.Removed {
+			c.JSON(http.StatusConflict, gin.H{"error": "target already removed"})
+			return
+		}
+	case services.ActionLock:
+		if t.Type != "thread" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "only threads can be locked"})
+			return
+		}
+		if t.Locked {
+			c.JSON(http.StatusConflict, gin.H{"error": "thread already locked"})
+			return
+		}
+	case services.ActionMute, services.ActionBan:
+		if body.DurationHours <= 0 || body.DurationHours > maxRestrictionHours {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("duration_hours must be 1-%d", maxRestrictionHours)})
+			return
+		}
+		if t.AuthorID == uid {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "cannot restrict yourself"})
+			return
+		}
+		e := time.Now().Add(time.Duration(body.DurationHours) * time.Hour)
+		expires = &e
+	case services.ActionWarn, services.ActionDismiss:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid action"})
+		return
+	}
+
+	var entry entity.ModerationLog
+	var reporters []uint
+	err = db.Transaction(func(tx *gorm.DB) error {
+		switch body.Action {
+		case services.ActionHide:
+			if err := services.SetTargetHidden(tx, t.Type, t.ID, true); err != nil {
+				return err
+			}
+		case services.ActionDelete:
+			if err := deleteModerationTarget(tx, t); err != nil {
+				return err
+			}
+		case services.ActionLock:
+			if err := tx.Model(&entity.Thread{}).Where("id = ?", t.ID).Update("locked", true).Error; err != nil {
+				return err
+			}
+		}
+
+		entry = entity.ModerationLog{
+			ActorID:    uid,
+			TargetType: t.Type,
+			TargetID:   t.ID,
+			AuthorID:   t.AuthorID,
+			Action:     body.Action,
+			Reason:     reason,
+			Snapshot:   t.Snapshot(),
+			ExpiresAt:  expires,
+		}
+		if body.Action == services.ActionMute || body.Action == services.ActionBan {
+			scope := services.MuteScopeFor(t.Type)
+			if body.Action == services.ActionBan {
+				scope = entity.RestrictLogin
+			}
+			r, err := services.IssueRestriction(tx, t.AuthorID, scope, reason, expires, uid)
+			if err != nil {
+				return err
+			}
+			entry.RestrictionID = &r.ID
+		}
+		if err := tx.Create(&entry).Error; err != nil {
+			return err
+		}
+
+		status := entity.ReportActioned
+		if body.Action == services.ActionDismiss {
+			status = entity.ReportDismissed
+		}
+		open := tx.Model(&entity.ContentReport{}).
+			Where("target_type = ? AND target_id = ? AND status = ?", t.Type, t.ID, entity.ReportOpen)
+		if err := open.Session(&gorm.Session{}).Distinct().Pluck("reporter_id", &reporters).Error; err != nil {
+			return err
+		}
+		now := time.Now()
+		return open.Updates(map[string]interface{}{
+			"status": status, "resolved_by_id": uid, "resolved_at": now, "moderation_log_id": entry.ID,
+		}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	notifyModerationResult(db, t, entry, reporters)
+	c.JSON(http.StatusOK, entry)
+}
+
+// ลบเนื้อหาแบบ soft delete (คืนได้เมื่ออุทธรณ์สำเร็จ)
+func deleteModerationTarget(tx *gorm.DB, t services.ModerationTarget) error {
+	switch t.Type {
+	case "thread":
+		return tx.Delete(&entity.Thread{}, t.ID).Error
+	case "comment":
+		var row entity.Comment
+		if err := tx.First(&row, t.ID).Error; err != nil {
+			return err
+		}
+		return removeComment(tx, row)
+	case "review":
+		return tx.Delete(&entity.Review{}, t.ID).Error
+	case "mod":
+		return tx.Delete(&entity.Mod{}, t.ID).Error
+	}
+	return services.ErrUnknownTarget
+}
+
+// แจ้งผลถึงผู้รายงาน และแจ้ง action ถึงเจ้าของเนื้อหา (dismiss ไม่แจ้งเจ้าของ)
+func notifyModerationResult(db *gorm.DB, t services.ModerationTarget, entry entity.ModerationLog, reporters []uint) {
+	label := targetTypeLabel[t.Type]
+	result := "ผู้ดูแลตรวจสอบแล้วและดำเนินการกับเนื้อหาแล้ว ขอบคุณที่ช่วยรายงาน"
+	if entry.Action == services.ActionDismiss {
+		result = "ผู้ดูแลตรวจสอบแล้ว ไม่พบการละเมิดกฎ"
+	}
+	for _, rid := range reporters {
+		if err := services.NotifyUser(db, rid, services.NotificationReportResult,
+			fmt.Sprintf("ผลการรายงาน%s #%d", label, t.ID), result); err != nil {
+			log.Println("[Moderation] notify reporter error:", err)
+		}
+	}
+	if entry.Action == services.ActionDismiss {
+		return
+	}
+
+	var what string
+	switch entry.Action {
+	case services.ActionHide:
+		what = fmt.Sprintf("%sของคุณ #%d ถูกซ่อน", label, t.ID)
+	case services.ActionDelete:
+		what = fmt.Sprintf("%sของคุณ #%d ถูกลบ", label, t.ID)
+	case services.ActionLock:
+		what = fmt.Sprintf("กระทู้ของคุณ #%d ถูกล็อก", t.ID)
+	case services.ActionWarn:
+		what = fmt.Sprintf("คุณได้รับคำเตือนจาก%s #%d", label, t.ID)
+	case services.ActionMute:
+		what = "คุณถูกระงับการโพสต์ชั่วคราว"
+	case services.ActionBan:
+		what = "บัญชีของคุณถูกระงับชั่วคราว"
+	}
+	msg := what + " เหตุผล: " + entry.Reason
+	if entry.ExpiresAt != nil {
+		msg += " (ถึง " + entry.ExpiresAt.Format("2006-01-02 15:04") + ")"
+	}
+	msg += fmt.Sprintf(" ยื่นอุทธรณ์ได้ภายใน %d วัน (รายการ #%d)", int(services.AppealWindow.Hours()/24), entry.ID)
+	if err := services.NotifyUser(db, t.AuthorID, services.NotificationModerationAction, "การดำเนินการของผู้ดูแล", msg); err != nil {
+		log.Println("[Moderation] notify author error:", err)
+	}
+}
+
+// action ที่เจ้าของเนื้อหาอุทธรณ์ได้
+var appealableActions = map[string]bool{
+	services.ActionHide: true, services.ActionDelete: true, services.ActionLock: true,
+	services.ActionWarn: true, services.ActionMute: true, services.ActionBan: true,
+}
+
+// POST /moderation/logs/:id/appeal {"message": "..."} — เจ้าของเนื้อหาอุทธรณ์ (1 ครั้งต่อ action)
+func CreateModerationAppeal(c *gin.Context) {
+	var body struct {
+		Message string `json:"message" binding:"required,max=2000"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad request body"})
+		return
+	}
+	uid := c.GetUint("userID")
+	db := configs.DB()
+
+	var entry entity.ModerationLog
+	if tx := db.Where("id = ? AND author_id = ?", c.Param("id"), uid).Limit(1).Find(&entry); tx.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "moderation action not found"})
+		return
+	}
+	if !appealableActions[entry.Action] || entry.RevertedAt != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "action cannot be appealed"})
+		return
+	}
+	if time.Since(entry.CreatedAt) > services.AppealWindow {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "appeal window has passed"})
+		return
+	}
+	var n int64
+	db.Model(&entity.ModerationAppeal{}).Where("moderation_log_id = ?", entry.ID).Count(&n)
+	if n > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "already appealed"})
+		return
+	}
+
+	row := entity.ModerationAppeal{
+		ModerationLogID: entry.ID,
+		UserID:          uid,
+		Message:         strings.TrimSpace(body.Message),
+		Status:          entity.AppealPending,
+	}
+	if err := db.Create(&row).Error; err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, row)
+}
+
+// GET /moderation/appeals/mine
+func FindMyModerationAppeals(c *gin.Context) {
+	var rows []entity.ModerationAppeal
+	if err := configs.DB().Preload("ModerationLog").
+		Where("user_id = ?", c.GetUint("userID")).
+		Order("id DESC").Find(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, rows)
+}
+
+// GET /moderation/appeals?status=pending — เฉพาะชนิดเนื้อหาที่ผู้ดูแลมีสิทธิ์
+func FindModerationAppeals(c *gin.Context) {
+	db := configs.DB()
+	types := services.ModeratableTypes(db, c.GetUint("userID"))
+	var rows []entity.ModerationAppeal
+	if err := db.Preload("User").Preload("ModerationLog").Preload("ModerationLog.Actor").
+		Joins("JOIN moderation_logs ml ON ml.id = moderation_appeals.moderation_log_id").
+		Where("ml.target_type IN ? AND moderation_appeals.status = ?", types, c.DefaultQuery("status", entity.AppealPending)).
+		Order("moderation_appeals.id ASC").
+		Find(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, rows)
+}
+
+// POST /moderation/appeals/:id/resolve {"accept": true, "response": "..."}
+// ยอมรับ = ยกเลิกผลของ action (เลิกซ่อน/คืนเนื้อหา/ปลดล็อก/ยกเลิก mute-ban)
+func ResolveModerationAppeal(c *gin.Context) {
+	var body struct {
+		Accept   *bool  `json:"accept" binding:"required"`
+		Response string `json:"response"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad request body"})
+		return
+	}
+	uid := c.GetUint("userID")
+	db := configs.DB()
+
+	var appeal entity.ModerationAppeal
+	if tx := db.Preload("ModerationLog").Limit(1).Find(&appeal, c.Param("id")); tx.RowsAffected == 0 || appeal.ModerationLog == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "appeal not found"})
+		return
+	}
+	entry := appeal.ModerationLog
+	if perm, _ := services.ModerationPermFor(entry.TargetType); !services.UserHasPermission(db, uid, perm) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return
+	}
+	if appeal.Status != entity.AppealPending {
+		c.JSON(http.StatusConflict, gin.H{"error": "appeal already resolved"})
+		return
+	}
+
+	now := time.Now()
+	status := entity.AppealRejected
+	if *body.Accept {
+		status = entity.AppealAccepted
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if *body.Accept {
+			if err := revertModerationAction(tx, *entry, uid); err != nil {
+				return err
+			}
+			if err := tx.Model(entry).Update("reverted_at", now).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&appeal).Updates(map[string]interface{}{
+			"status": status, "reviewer_id": uid, "reviewed_at": now, "response": strings.TrimSpace(body.Response),
+		}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	msg := fmt.Sprintf("อุทธรณ์รายการ #%d ไม่ผ่านการพิจารณา", entry.ID)
+	if *body.Accept {
+		msg = fmt.Sprintf("อุทธรณ์รายการ #%d ได้รับการยอมรับ ยกเลิกการดำเนินการแล้ว", entry.ID)
+	}
+	if r := strings.TrimSpace(body.Response); r != "" {
+		msg += ": " + r
+	}
+	if err := services.NotifyUser(db, appeal.UserID, services.NotificationAppealResult, "ผลการอุทธรณ์", msg); err != nil {
+		log.Println("[Moderation] notify appeal error:", err)
+	}
+	db.Preload("ModerationLog").First(&appeal, appeal.ID)
+	c.JSON(http.StatusOK, appeal)
+}
+
+func revertModerationAction(tx *gorm.DB, entry entity.ModerationLog, actorID uint) error {
+	switch entry.Action {
+	case services.ActionHide:
+		return services.SetTargetHidden(tx, entry.TargetType, entry.TargetID, false)
+	case services.ActionDelete:
+		if entry.TargetType == "comment" {
+			return restoreComment(tx, entry.TargetID, entry.Snapshot)
+		}
+		return services.RestoreTarget(tx, entry.TargetType, entry.TargetID)
+	case services.ActionLock:
+		return tx.Model(&entity.Thread{}).Where("id = ?", entry.TargetID).Update("locked", false).Error
+	case services.ActionMute, services.ActionBan:
+		if entry.RestrictionID != nil {
+			return services.LiftRestriction(tx, *entry.RestrictionID, actorID)
+		}
+	}
+	return nil
+}
+
+// canSeeHidden เนื้อหาที่ถูกซ่อนยังเห็นได้โดยเจ้าของและผู้ดูแลชนิดนั้น
+func canSeeHidden(c *gin.Context, db *gorm.DB, targetType string, authorID uint) bool {
+	uid := optionalUserID(c)
+	if uid == 0 {
+		return false
+	}
+	if uid == authorID {
+		return true
+	}
+	perm, _ := services.ModerationPermFor(targetType)
+	return services.UserHasPermission(db, uid, perm)
+}
+
+// threadVisible เธรดที่ถูกซ่อน → 404 สำหรับคนทั่วไป (ตอบ error ไปแล้วถ้า false)
+func threadVisible(c *gin.Context, db *gorm.DB, threadID string) bool {
+	var th entity.Thread
+	db.Select("id, user_id, hidden").Limit(1).Find(&th, threadID)
+	if th.ID != 0 && th.Hidden && !canSeeHidden(c, db, "thread", th.UserID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "thread not found"})
+		return false
+	}
+	return true
+}
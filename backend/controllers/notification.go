@@ -3,125 +3,218 @@ package controllers
 import (
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"example.com/sa-gameshop/configs"
 	"example.com/sa-gameshop/entity"
+	"example.com/sa-gameshop/services"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
-// POST /notifications
+// แจ้งเตือนทุกเส้นทาง (ยกเว้นประกาศ) ผูกกับผู้ใช้จาก token — ของคนอื่นตอบ 404
+
+// POST /notifications {"title": "...", "message": "...", "role_ids": [2]}
+// ผู้ดูแล (users.manage) ประกาศแจ้งเตือนระบบ ไม่ส่ง role_ids = ผู้ใช้ทุกคน
 func CreateNotification(c *gin.Context) {
-	var body entity.Notification
+	var body struct {
+		Title   string `json:"title"   binding:"required"`
+		Message string `json:"message" binding:"required"`
+		RoleIDs []uint `json:"role_ids"`
+	}
 	if err := c.ShouldBindJSON(&body); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "bad request body"})
 		return
 	}
+	db := configs.DB()
+	if len(body.RoleIDs) > 0 {
+		distinct := map[uint]bool{}
+		for _, id := range body.RoleIDs {
+			distinct[id] = true
+		}
+		var n int64
+		db.Model(&entity.Role{}).Where("id IN ?", body.RoleIDs).Count(&n)
+		if int(n) != len(distinct) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "role_ids not found"})
+			return
+		}
+	}
 
-	// ตรวจว่ามี user นี้จริง
-	var user entity.User
-	if tx := configs.DB().Where("id = ?", body.UserID).First(&user); tx.RowsAffected == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id not found"})
+	sent, err := services.BroadcastNotification(db, strings.TrimSpace(body.Title), strings.TrimSpace(body.Message), body.RoleIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	log.Printf("📥 CreateNotification: by=%d roles=%v recipients=%d title=%q",
+		c.GetUint("userID"), body.RoleIDs, sent, body.Title)
+	c.JSON(http.StatusCreated, gin.H{"recipients": sent})
+}
 
-	// default type
-	if body.Type == "" {
-		body.Type = "system"
+// แจ้งเตือนของผู้ใช้ปัจจุบัน กรองตาม ?type=a,b และ ?unread=1
+func myNotifications(c *gin.Context, db *gorm.DB) *gorm.DB {
+	tx := db.Model(&entity.Notification{}).Where("user_id = ?", c.GetUint("userID"))
+	var types []string
+	for _, t := range strings.Split(c.Query("type"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			types = append(types, t)
+		}
 	}
-	body.IsRead = false
-
-	if err := configs.DB().Create(&body).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
+	if len(types) > 0 {
+		tx = tx.Where("type IN ?", types)
 	}
-
-	log.Printf("📥 CreateNotification: user_id=%d title=%q message=%q type=%q report_id=%v",
-		body.UserID, body.Title, body.Message, body.Type, body.ReportID)
-
-	c.JSON(http.StatusCreated, body)
+	if v := c.Query("unread"); v == "1" || v == "true" {
+		tx = tx.Where("is_read = ?", false)
+	}
+	return tx
 }
 
-// GET /notifications?user_id=...
+// GET /notifications?limit=&cursor=&type=&unread=1
+// ใหม่สุดก่อน (limit ค่าเริ่มต้น 20 สูงสุด 100) cursor หน้าถัดไปอยู่ใน header X-Next-Cursor
 func FindNotifications(c *gin.Context) {
-	uid := c.Query("user_id")
-	if uid == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "must provide user_id"})
-		return
+	limit := 20
+	if v, err := strconv.Atoi(c.Query("limit")); err == nil && v > 0 {
+		limit = v
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	db := configs.DB()
+	tx := myNotifications(c, db).Preload("Report").Preload("Report.Attachments")
+	if v := c.Query("cursor"); v != "" {
+		cur, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid cursor"})
+			return
+		}
+		tx = tx.Where("id < ?", cur)
 	}
 
 	var rows []entity.Notification
-	if err := configs.DB().
-		Preload("User").
-		Preload("Report").
-		Preload("Report.Attachments").
-		Where("user_id = ?", uid).
-		Order("created_at DESC").
-		Find(&rows).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	if err := tx.Order("id DESC").Limit(limit + 1).Find(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-
-	log.Printf("📤 FindNotifications: user_id=%s count=%d", uid, len(rows))
+	if len(rows) > limit {
+		rows = rows[:limit]
+		c.Header("X-Next-Cursor", strconv.FormatUint(uint64(rows[len(rows)-1].ID), 10))
+	}
+	for i := range rows {
+		if rows[i].Report != nil {
+			signReportFiles(c, rows[i].Report)
+		}
+	}
 	c.JSON(http.StatusOK, rows)
 }
 
+// GET /notifications/unread-count?type=
+func CountUnreadNotifications(c *gin.Context) {
+	var n int64
+	if err := myNotifications(c, configs.DB()).Where("is_read = ?", false).Count(&n).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"unread": n})
+}
+
 // GET /notifications/:id
 func FindNotificationByID(c *gin.Context) {
 	var row entity.Notification
 	if tx := configs.DB().
-		Preload("User").
 		Preload("Report").
 		Preload("Report.Attachments").
-		First(&row, c.Param("id")); tx.RowsAffected == 0 {
+		Where("user_id = ?", c.GetUint("userID")).
+		Limit(1).Find(&row, c.Param("id")); tx.RowsAffected == 0 {
 		c.JSON(http.StatusNotFound, gin.H{"error": "notification not found"})
 		return
 	}
+	if row.Report != nil {
+		signReportFiles(c, row.Report)
+	}
 	c.JSON(http.StatusOK, row)
 }
 
 // PUT /notifications/:id/read
 func MarkNotificationRead(c *gin.Context) {
-	id := c.Param("id")
+	uid := c.GetUint("userID")
 	db := configs.DB()
 
-	if err := db.Model(&entity.Notification{}).
-		Where("id = ?", id).
-		Update("is_read", true).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var row entity.Notification
+	if tx := db.Where("user_id = ?", uid).Limit(1).Find(&row, c.Param("id")); tx.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "notification not found"})
 		return
 	}
-
-	var row entity.Notification
-	_ = db.First(&row, id)
+	if !row.IsRead {
+		if err := db.Model(&row).Update("is_read", true).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		services.PublishUnreadCount(db, uid)
+	}
 	c.JSON(http.StatusOK, row)
 }
 
-// PUT /notifications/read-all
+// PUT /notifications/read-all?type= — อ่านทั้งหมด (หรือเฉพาะชนิด)
 func MarkAllNotificationsRead(c *gin.Context) {
+	uid := c.GetUint("userID")
+	db := configs.DB()
+	res := myNotifications(c, db).Where("is_read = ?", false).Update("is_read", true)
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": res.Error.Error()})
+		return
+	}
+	if res.RowsAffected > 0 {
+		services.PublishUnreadCount(db, uid)
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "marked all as read", "updated": res.RowsAffected})
+}
+
+// POST /notifications/bulk {"action": "read"|"unread"|"delete", "ids": [1,2,3]}
+// id ที่ไม่ใช่ของผู้ใช้ถูกข้ามไป (ดูจำนวนที่มีผลใน affected)
+func BulkUpdateNotifications(c *gin.Context) {
 	var body struct {
-		UserID uint `json:"user_id"`
+		Action string `json:"action" binding:"required"`
+		IDs    []uint `json:"ids"    binding:"required"`
 	}
-	if err := c.ShouldBindJSON(&body); err != nil || body.UserID == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "must provide user_id"})
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad request body"})
 		return
 	}
-
+	if len(body.IDs) == 0 || len(body.IDs) > 500 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ids must list 1-500 ids"})
+		return
+	}
+	uid := c.GetUint("userID")
 	db := configs.DB()
-	if err := db.Model(&entity.Notification{}).
-		Where("user_id = ? AND is_read = ?", body.UserID, false).
-		Update("is_read", true).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	tx := db.Where("user_id = ? AND id IN ?", uid, body.IDs)
+
+	var res *gorm.DB
+	switch body.Action {
+	case "read", "unread":
+		res = tx.Model(&entity.Notification{}).Update("is_read", body.Action == "read")
+	case "delete":
+		res = tx.Unscoped().Delete(&entity.Notification{})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "action must be read, unread or delete"})
 		return
 	}
-
-	c.JSON(http.StatusOK, gin.H{"message": "marked all as read"})
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": res.Error.Error()})
+		return
+	}
+	services.PublishUnreadCount(db, uid)
+	c.JSON(http.StatusOK, gin.H{"action": body.Action, "affected": res.RowsAffected})
 }
 
 // DELETE /notifications/:id
 func DeleteNotificationByID(c *gin.Context) {
-	if tx := configs.DB().Exec("DELETE FROM notifications WHERE id = ?", c.Param("id")); tx.RowsAffected == 0 {
+	uid := c.GetUint("userID")
+	db := configs.DB()
+	if tx := db.Unscoped().Where("id = ? AND user_id = ?", c.Param("id"), uid).Delete(&entity.Notification{}); tx.RowsAffected == 0 {
 		c.JSON(http.StatusNotFound, gin.H{"error": "notification not found"})
 		return
 	}
+	services.PublishUnreadCount(db, uid)
 	c.JSON(http.StatusOK, gin.H{"message": "deleted successfully"})
 }
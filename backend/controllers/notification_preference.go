@@ -0,0 +1,41 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"example.com/sa-gameshop/configs"
+	"example.com/sa-gameshop/services"
+	"github.com/gin-gonic/gin"
+)
+
+// GET /notification-preferences — ตั้งค่ารับแจ้งเตือนทุกชนิดของผู้ใช้ปัจจุบัน
+func FindNotificationPreferences(c *gin.Context) {
+	rows, err := services.NotificationPreferences(configs.DB(), c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, rows)
+}
+
+// PUT /notification-preferences {"thread_reply": false, "mention": true}
+func UpdateNotificationPreferences(c *gin.Context) {
+	var body map[string]bool
+	if err := c.ShouldBindJSON(&body); err != nil || len(body) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad request body"})
+		return
+	}
+	db := configs.DB()
+	uid := c.GetUint("userID")
+	if err := services.SetNotificationPreferences(db, uid, body); err != nil {
+		if errors.Is(err, services.ErrUnknownNotificationType) || errors.Is(err, services.ErrRequiredNotificationType) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	rows, _ := services.NotificationPreferences(db, uid)
+	c.JSON(http.StatusOK, rows)
+}
@@ -0,0 +1,108 @@
+package controllers
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"example.com/sa-gameshop/configs"
+	"example.com/sa-gameshop/services"
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ส่ง comment ": ping" กันพร็อกซีตัดการเชื่อมต่อที่เงียบนานเกิน
+var streamHeartbeat = 25 * time.Second
+
+// เปิดดูความเห็นสดได้พร้อมกันกี่กระทู้ต่อการเชื่อมต่อ
+const maxStreamThreads = 20
+
+// GET /notifications/stream?threads=1,2 (Server-Sent Events)
+// เหตุการณ์: notification, unread_count, comment (เฉพาะกระทู้ใน ?threads=), payment
+// ต่อใหม่พร้อม Last-Event-ID (หรือ ?last_event_id=) จะได้เหตุการณ์ที่พลาดไปก่อน
+// ถ้าต่อครั้งแรกหรือพลาดนานเกินบัฟเฟอร์ จะได้ unread_count ล่าสุดเป็นเหตุการณ์แรก
+func StreamNotifications(c *gin.Context) {
+	uid := c.GetUint("userID")
+	db := configs.DB()
+
+	var topics []string
+	for _, s := range strings.Split(c.Query("threads"), ",") {
+		id, err := strconv.ParseUint(strings.TrimSpace(s), 10, 64)
+		if err != nil || id == 0 || len(topics) >= maxStreamThreads {
+			continue
+		}
+		if streamThreadAllowed(c, db, uint(id)) {
+			topics = append(topics, services.ThreadTopic(uint(id)))
+		}
+	}
+
+	lastID := c.GetHeader("Last-Event-ID")
+	if lastID == "" {
+		lastID = c.Query("last_event_id")
+	}
+	sub, missed, resumed, cursor := services.Events.Subscribe(uid, topics, lastID)
+	defer services.Events.Unsubscribe(sub)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	for _, e := range missed {
+		writeStreamEvent(c.Writer, services.Events.EventID(e.ID), e.Type, e.Data)
+	}
+	if !resumed {
+		writeStreamEvent(c.Writer, cursor, services.EventUnreadCount,
+			map[string]int64{"unread": services.UnreadNotificationCount(db, uid)})
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case e, ok := <-sub.C:
+			if !ok {
+				return // อ่านไม่ทัน hub ตัดทิ้ง → client ต่อใหม่ด้วย Last-Event-ID
+			}
+			writeStreamEvent(c.Writer, services.Events.EventID(e.ID), e.Type, e.Data)
+			c.Writer.Flush()
+		case <-heartbeat.C:
+			if _, err := io.WriteString(c.Writer, ": ping\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+}
+
+func writeStreamEvent(w io.Writer, id, typ string, data interface{}) {
+	_ = sse.Encode(w, sse.Event{Id: id, Event: typ, Data: data})
+}
+
+// กระทู้ที่ผู้ชมเปิดดูได้ (ไม่ถูกซ่อน และผ่านเรตอายุ) ไม่ผ่านก็แค่ไม่ได้รับความเห็นสด
+func streamThreadAllowed(c *gin.Context, db *gorm.DB, threadID uint) bool {
+	var th struct {
+		ID     uint
+		GameID uint
+		UserID uint
+		Hidden bool
+	}
+	db.Table("threads").Select("id, game_id, user_id, hidden").
+		Where("id = ? AND deleted_at IS NULL", threadID).Limit(1).Scan(&th)
+	if th.ID == 0 || (th.Hidden && !canSeeHidden(c, db, "thread", th.UserID)) {
+		return false
+	}
+	region, err := requestRegion(c, db)
+	if err != nil {
+		return false
+	}
+	_, err = services.CheckGameAge(db, requestViewer(c, db), th.GameID, region)
+	return err == nil
+}
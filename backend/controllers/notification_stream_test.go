@@ -0,0 +1,182 @@
+package controllers
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"example.com/sa-gameshop/configs"
+	"example.com/sa-gameshop/entity"
+	"example.com/sa-gameshop/services"
+	"github.com/gin-gonic/gin"
+)
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "gameshop-test")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("DB_PATH", filepath.Join(dir, "test.db"))
+	configs.ConnectionDB()
+	if err := configs.DB().AutoMigrate(&entity.Notification{}, &entity.NotificationPreference{}); err != nil {
+		panic(err)
+	}
+	gin.SetMode(gin.TestMode)
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+type sseEvent struct{ id, typ, data string }
+
+type sseClient struct {
+	t      *testing.T
+	cancel context.CancelFunc
+	events chan sseEvent
+}
+
+// เปิด stream ในชื่อ userID แล้วอ่านเหตุการณ์เข้า channel
+func openStream(t *testing.T, srv *httptest.Server, userID, lastEventID string) *sseClient {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/notifications/stream", nil)
+	req.Header.Set("X-User-ID", userID)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		cancel()
+		t.Fatal(err)
+	}
+	if ct := res.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/event-stream") {
+		cancel()
+		t.Fatalf("content-type = %q", ct)
+	}
+	c := &sseClient{t: t, cancel: cancel, events: make(chan sseEvent, 16)}
+	go func() {
+		defer res.Body.Close()
+		defer close(c.events)
+		sc := bufio.NewScanner(res.Body)
+		var e sseEvent
+		for sc.Scan() {
+			line := sc.Text()
+			switch {
+			case line == "":
+				if e.typ != "" {
+					c.events <- e
+				}
+				e = sseEvent{}
+			case strings.HasPrefix(line, "id:"):
+				e.id = strings.TrimPrefix(line, "id:")
+			case strings.HasPrefix(line, "event:"):
+				e.typ = strings.TrimPrefix(line, "event:")
+			case strings.HasPrefix(line, "data:"):
+				e.data = strings.TrimPrefix(line, "data:")
+			}
+		}
+	}()
+	t.Cleanup(cancel)
+	return c
+}
+
+func (c *sseClient) next() sseEvent {
+	c.t.Helper()
+	select {
+	case e, ok := <-c.events:
+		if !ok {
+			c.t.Fatal("stream closed")
+		}
+		return e
+	case <-time.After(2 * time.Second):
+		c.t.Fatal("timed out waiting for event")
+	}
+	return sseEvent{}
+}
+
+func (c *sseClient) expectNone() {
+	c.t.Helper()
+	select {
+	case e, ok := <-c.events:
+		if ok {
+			c.t.Fatalf("unexpected event %+v", e)
+		}
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func newStreamServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	prev := services.Events
+	services.Events = services.NewEventHub(100)
+	r := gin.New()
+	r.GET("/notifications/stream", func(c *gin.Context) {
+		id, _ := strconv.ParseUint(c.GetHeader("X-User-ID"), 10, 64)
+		c.Set("userID", uint(id))
+	}, StreamNotifications)
+	srv := httptest.NewServer(r)
+	t.Cleanup(func() {
+		srv.CloseClientConnections()
+		srv.Close()
+		services.Events = prev
+		configs.DB().Unscoped().Where("1 = 1").Delete(&entity.Notification{})
+	})
+	return srv
+}
+
+func TestStreamNotificationsDelivery(t *testing.T) {
+	srv := newStreamServer(t)
+	alice := openStream(t, srv, "1", "")
+	bob := openStream(t, srv, "2", "")
+
+	// ต่อครั้งแรก → unread_count เป็นเหตุการณ์แรก
+	if e := alice.next(); e.typ != services.EventUnreadCount || e.data != `{"unread":0}` {
+		t.Fatalf("first event = %+v", e)
+	}
+	bob.next()
+
+	services.Events.Publish(services.Event{Type: services.EventPayment, UserID: 1, Data: map[string]int{"order_id": 3}})
+	services.Events.Publish(services.Event{Type: services.EventComment, Topic: services.ThreadTopic(9), Data: "x"})
+	e := alice.next()
+	if e.typ != services.EventPayment || e.data != `{"order_id":3}` || e.id != services.Events.EventID(1) {
+		t.Fatalf("alice got %+v", e)
+	}
+	// ไม่ได้ขอ ?threads=9 → ไม่ได้ความเห็นของกระทู้นั้น และไม่เห็นเหตุการณ์ของคนอื่น
+	alice.expectNone()
+	bob.expectNone()
+}
+
+func TestStreamNotificationsResume(t *testing.T) {
+	srv := newStreamServer(t)
+	first := openStream(t, srv, "1", "")
+	cursor := first.next().id
+	first.cancel()
+
+	services.Events.Publish(services.Event{Type: services.EventPayment, UserID: 1, Data: 1})
+	services.Events.Publish(services.Event{Type: services.EventPayment, UserID: 2, Data: 2})
+	services.Events.Publish(services.Event{Type: services.EventPayment, UserID: 1, Data: 3})
+
+	// ต่อใหม่ด้วย Last-Event-ID → ได้เฉพาะที่พลาดของตัวเอง ไม่มี unread_count ซ้ำ
+	again := openStream(t, srv, "1", cursor)
+	for _, want := range []string{"1", "3"} {
+		if e := again.next(); e.typ != services.EventPayment || e.data != want {
+			t.Fatalf("resumed event = %+v, want data %s", e, want)
+		}
+	}
+	again.expectNone()
+
+	// Last-Event-ID จากก่อนรีสตาร์ท (boot id เก่า) → ไม่ replay แต่ส่ง unread_count ล่าสุด
+	configs.DB().Create(&entity.Notification{Title: "t", Type: services.NotificationSystem, UserID: 1})
+	stale := openStream(t, srv, "1", "1-1")
+	if e := stale.next(); e.typ != services.EventUnreadCount || e.data != `{"unread":1}` || e.id != services.Events.EventID(3) {
+		t.Fatalf("stale resume first event = %+v", e)
+	}
+	stale.expectNone()
+}
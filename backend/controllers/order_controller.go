@@ -2,8 +2,10 @@
 package controllers
 
 import (
+	"errors"
 	"math"
 	"net/http"
+	"strings"
 	"time"
 
 	"example.com/sa-gameshop/configs"
@@ -16,18 +18,24 @@ import (
 // ปัดทศนิยม 2 ตำแหน่ง
 func round2(v float64) float64 { return math.Round(v*100) / 100 }
 
-// ดึงราคาหลังโปรจริง ๆ ให้ไปเติม logic preload promotion ที่นี่ถ้ามี
-func getDiscountedPriceForGame(db *gorm.DB, gameID uint, now time.Time) (float64, error) {
-	return services.GetDiscountedPriceForGame(db, gameID, now)
+// ดึงราคาหลังโปรจริง ๆ ตาม region ของออเดอร์
+func getDiscountedPriceForGame(db *gorm.DB, gameID uint, region entity.Region, now time.Time) (float64, error) {
+	return services.GetDiscountedPriceForGame(db, gameID, region, now)
 }
 
 type CreateOrderItemInput struct {
 	GameID uint `json:"game_id" binding:"required"`
 	QTY    int  `json:"qty" binding:"required"`
 }
+type CreateGiftInput struct {
+	Recipient string `json:"recipient" binding:"required"` // username หรือ email
+	Message   string `json:"message"   binding:"max=500"`
+}
 type CreateOrderInput struct {
 	// ไม่รับ user_id จาก client เพื่อกันสวมรอย
 	Items []CreateOrderItemInput `json:"items" binding:"required"`
+	// ซื้อเป็นของขวัญให้ผู้ใช้อื่น (ไม่ส่ง = ซื้อให้ตัวเอง)
+	Gift *CreateGiftInput `json:"gift"`
 }
 
 // POST /orders  (ต้อง Auth) — ผูกกับ user จาก token/headers เสมอ
@@ -48,11 +56,62 @@ func CreateOrder(c *gin.Context) {
 	db := configs.DB()
 	now := time.Now()
 
+	// ราคาคิดตาม region ในโปรไฟล์เท่านั้น (ไม่รับจาก client)
+	region, err := services.RegionForUser(db, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "region not configured"})
+		return
+	}
+
 	order := entity.Order{
 		UserID:      userID,
 		OrderCreate: now,
 		// ถ้าคุณมี const ชื่ออื่น ให้ปรับตรงนี้
 		OrderStatus: entity.OrderWaitingPayment, // หรือ entity.OrderStatus("WAITING_PAYMENT")
+		RegionCode:  region.Code,
+	}
+	if region.Currency != nil {
+		order.Currency = region.Currency.Code
+	}
+
+	// อายุผู้ซื้อคิดจากวันเกิดในโปรไฟล์
+	viewer := services.ViewerFor(db, userID)
+	ageRegion := region
+
+	// ของขวัญ: เช็คเรตอายุกับผู้รับ และผู้รับต้องยังไม่มีเกม
+	var recipient entity.User
+	if body.Gift != nil {
+		recipient, err = services.FindGiftRecipient(db, body.Gift.Recipient)
+		if err != nil {
+			if errors.Is(err, services.ErrGiftRecipientNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if recipient.ID == userID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": services.ErrGiftToSelf.Error()})
+			return
+		}
+		gameIDs := make([]uint, 0, len(body.Items))
+		for _, it := range body.Items {
+			gameIDs = append(gameIDs, it.GameID)
+		}
+		owned, err := services.OwnedGameIDs(db, recipient.ID, gameIDs)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if len(owned) > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": services.ErrGiftAlreadyOwned.Error(), "game_ids": owned})
+			return
+		}
+		viewer = services.ViewerFor(db, recipient.ID)
+		if ageRegion, err = services.RegionForUser(db, recipient.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "region not configured"})
+			return
+		}
 	}
 
 	total := 0.0
@@ -62,7 +121,14 @@ func CreateOrder(c *gin.Context) {
 		if qty <= 0 {
 			qty = 1
 		}
-		unit, err := getDiscountedPriceForGame(db, it.GameID, now)
+		if err := services.EnsureGamePurchasable(db, it.GameID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "game_id": it.GameID})
+			return
+		}
+		if !gameAgeAllowed(c, db, viewer, it.GameID, ageRegion) {
+			return
+		}
+		unit, err := getDiscountedPriceForGame(db, it.GameID, region, now)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "game not found"})
 			return
@@ -90,13 +156,22 @@ func CreateOrder(c *gin.Context) {
 				return err
 			}
 		}
+		if body.Gift != nil {
+			return tx.Create(&entity.Gift{
+				OrderID:     order.ID,
+				SenderID:    userID,
+				RecipientID: recipient.ID,
+				Message:     strings.TrimSpace(body.Gift.Message),
+				Status:      entity.GiftPending,
+			}).Error
+		}
 		return nil
 	}); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	_ = db.Preload("OrderItems").Preload("User").First(&order, order.ID)
+	_ = db.Preload("OrderItems").Preload("User").Preload("Gift").First(&order, order.ID)
 	c.JSON(http.StatusOK, order)
 }
 
@@ -57,8 +57,44 @@ func CreateOrderItem(c *gin.Context) {
 		return
 	}
 
+	// ใช้ region เดียวกับออเดอร์ เพื่อให้ทุกรายการเป็นสกุลเงินเดียวกัน
+	region, err := services.ResolveRegion(db, od.RegionCode)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "region not configured"})
+		return
+	}
+
+	if err := services.EnsureGamePurchasable(db, body.GameID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// ออเดอร์ของขวัญ: เช็คกับผู้รับเหมือน CreateOrder (ต้องยังไม่มีเกม + เรตอายุของผู้รับ)
+	viewer, ageRegion := services.ViewerFor(db, od.UserID), region
+	var gift entity.Gift
+	db.Where("order_id = ?", od.ID).Limit(1).Find(&gift)
+	if gift.ID != 0 {
+		owned, err := services.OwnedGameIDs(db, gift.RecipientID, []uint{body.GameID})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if len(owned) > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": services.ErrGiftAlreadyOwned.Error(), "game_ids": owned})
+			return
+		}
+		viewer = services.ViewerFor(db, gift.RecipientID)
+		if ageRegion, err = services.RegionForUser(db, gift.RecipientID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "region not configured"})
+			return
+		}
+	}
+	if !gameAgeAllowed(c, db, viewer, body.GameID, ageRegion) {
+		return
+	}
+
 	now := time.Now()
-	unit, err := services.GetDiscountedPriceForGame(db, body.GameID, now)
+	unit, err := services.GetDiscountedPriceForGame(db, body.GameID, region, now)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "game not found"})
 		return
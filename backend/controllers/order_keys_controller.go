@@ -7,6 +7,7 @@ import (
 	"example.com/sa-gameshop/configs"
 	"example.com/sa-gameshop/entity"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // GET /orders/:id/keys  (ต้องเป็นเจ้าของหรือแอดมิน)
@@ -35,7 +36,7 @@ func FindOrderKeys(c *gin.Context) {
 
 	roleAny, _ := c.Get("roleID")
 	isAdmin := roleAny != nil && roleAny.(uint) == configs.AdminRoleID()
-	if !isAdmin && ord.UserID != uid {
+	if !isAdmin && orderKeysOwner(db, ord.ID, ord.UserID) != uid {
 		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
 		return
 	}
@@ -101,7 +102,7 @@ func RevealOrderKey(c *gin.Context) {
 
 	roleAny, _ := c.Get("roleID")
 	isAdmin := roleAny != nil && roleAny.(uint) == configs.AdminRoleID()
-	if !isAdmin && row.UserID != uid {
+	if !isAdmin && orderKeysOwner(db, uint(orderID), row.UserID) != uid {
 		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
 		return
 	}
@@ -113,3 +114,16 @@ func RevealOrderKey(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"code": row.Code})
 }
+
+// ผู้ที่ดูคีย์ของออเดอร์ได้: ออเดอร์ปกติ = ผู้ซื้อ, ของขวัญ = ผู้รับหลังกดรับแล้ว (ก่อนนั้นไม่มีใครดูได้)
+func orderKeysOwner(db *gorm.DB, orderID, buyerID uint) uint {
+	var g entity.Gift
+	db.Where("order_id = ?", orderID).Limit(1).Find(&g)
+	switch {
+	case g.ID == 0:
+		return buyerID
+	case g.Status == entity.GiftClaimed:
+		return g.RecipientID
+	}
+	return 0
+}
@@ -4,13 +4,13 @@ package controllers
 import (
 	"fmt"
 	"net/http"
-	"os"
 	"path/filepath"
 	"strconv"
 	"time"
 
 	"example.com/sa-gameshop/configs"
 	"example.com/sa-gameshop/entity"
+	"example.com/sa-gameshop/services"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
@@ -54,14 +54,10 @@ func CreatePayment(c *gin.Context) {
 		return
 	}
 
-	// สร้างโฟลเดอร์อัปโหลดถ้ายังไม่มี
-	_ = os.MkdirAll("uploads/slips", 0755)
-
 	// ตั้งชื่อไฟล์ปลายทาง
 	dstName := fmt.Sprintf("slips/order_%d_%d%s", ord.ID, time.Now().UnixNano(), filepath.Ext(file.Filename))
-	dstPath := filepath.Join("uploads", dstName) // เก็บเป็น path ภายใน เช่น uploads/slips/...
-	if err := c.SaveUploadedFile(file, dstPath); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "save slip failed"})
+	if err := storeUpload(c, file, dstName, ord.UserID); err != nil {
+		uploadError(c, err, "save slip failed")
 		return
 	}
 
@@ -89,6 +85,7 @@ func CreatePayment(c *gin.Context) {
 		}
 		return nil
 	}); err != nil {
+		services.ReleaseUpload(c.Request.Context(), db, dstName)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "create payment failed"})
 		return
 	}
@@ -99,7 +96,7 @@ func CreatePayment(c *gin.Context) {
 		"amount":        p.Amount,
 		"status":        string(p.Status),
 		"reject_reason": p.RejectReason,
-		"slip_url":      baseURL(c) + "/uploads/" + p.SlipPath,
+		"slip_url":      fileURL(c, p.SlipPath),
 		"uploaded_at":   p.CreatedAt, // ใช้ CreatedAt แทน
 	})
 }
@@ -154,7 +151,7 @@ func FindPayments(c *gin.Context) {
 			OrderNo:      orderNo,
 			UserName:     userName,
 			Amount:       amt,
-			SlipURL:      baseURL(c) + "/uploads/" + p.SlipPath,
+			SlipURL:      fileURL(c, p.SlipPath),
 			UploadedAt:   p.CreatedAt.Format(time.RFC3339), // ใช้ CreatedAt
 			Status:       string(p.Status),
 			RejectReason: p.RejectReason,
@@ -277,7 +274,10 @@ func changePaymentStatus(paymentID uint, newStatus string, rejectReason *string)
 		return err
 	}
 
-	return db.Transaction(func(tx *gorm.DB) error {
+	// แจ้งเตือนที่สร้างใน transaction (ของขวัญ) ส่ง event หลัง commit
+	var outbox services.NotificationOutbox
+	err := db.Transaction(func(tx *gorm.DB) error {
+		tx = outbox.Bind(tx)
 		switch newStatus {
 		case "APPROVED":
 			p.Status = entity.PaymentStatus("APPROVED")
@@ -309,6 +309,10 @@ func changePaymentStatus(paymentID uint, newStatus string, rejectReason *string)
 					}
 				}
 			}
+			// ออเดอร์ของขวัญ: เกมเข้าคลังผู้รับตอนกดรับ (services.ClaimGift)
+			if isGift, err := services.DeliverGift(tx, p.OrderID); err != nil || isGift {
+				return err
+			}
 			// grant user ownership of purchased games
 			processed := make(map[uint]struct{})
 			for _, it := range items {
@@ -362,4 +366,9 @@ func changePaymentStatus(paymentID uint, newStatus string, rejectReason *string)
 			return fmt.Errorf("invalid status: %s", newStatus)
 		}
 	})
+	if err == nil {
+		outbox.Publish(db)
+		services.PublishPaymentStatus(p)
+	}
+	return err
 }
@@ -0,0 +1,103 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"example.com/sa-gameshop/configs"
+	"example.com/sa-gameshop/entity"
+	"example.com/sa-gameshop/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// บันทึกประวัติราคาหลังแก้ข้อมูลที่มีผลต่อราคา (error แค่ log ไม่ให้ request ล้ม)
+func recordPrices(db *gorm.DB, gameIDs []uint, reason entity.PriceChangeReason, promotionID *uint) {
+	if len(gameIDs) == 0 {
+		return
+	}
+	if err := services.SnapshotGamesPrices(db, gameIDs, reason, promotionID, time.Now()); err != nil {
+		log.Println("[PriceHistory] snapshot error:", err)
+	}
+}
+
+// บันทึกราคาใหม่ทุกเกม (เปลี่ยนอัตราแลกเปลี่ยน/เพิ่ม region)
+func recordAllPrices(db *gorm.DB, reason entity.PriceChangeReason) {
+	if err := services.SnapshotAllGamePrices(db, reason, time.Now()); err != nil {
+		log.Println("[PriceHistory] snapshot error:", err)
+	}
+}
+
+// game id ทั้งหมดที่ผูกกับโปรโมชัน
+func promotionGameIDs(db *gorm.DB, promotionID uint) []uint {
+	var ids []uint
+	db.Table("promotion_games").Where("promotion_id = ?", promotionID).Pluck("game_id", &ids)
+	return ids
+}
+
+// GET /games/:id/price-history?region=US&days=90
+// คืน timeline ราคาของเกมใน region และราคาต่ำสุดช่วง 30 วันล่าสุด
+func FindGamePriceHistory(c *gin.Context) {
+	db := configs.DB()
+	var g entity.Game
+	if tx := db.First(&g, c.Param("id")); tx.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "game not found"})
+		return
+	}
+	region, err := requestRegion(c, db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "region not configured"})
+		return
+	}
+
+	days := 90
+	if v, err := strconv.Atoi(c.Query("days")); err == nil && v > 0 && v <= 365 {
+		days = v
+	}
+	now := time.Now()
+	since := now.AddDate(0, 0, -days)
+
+	var rows []entity.GamePriceHistory
+	if err := db.Where("game_id = ? AND region_id = ? AND effective_at >= ?", g.ID, region.ID, since).
+		Order("effective_at ASC, id ASC").
+		Find(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// ราคาที่มีผลอยู่ตอนต้นช่วง ใส่ไว้หัว timeline
+	var prev entity.GamePriceHistory
+	if err := db.Where("game_id = ? AND region_id = ? AND effective_at < ?", g.ID, region.ID, since).
+		Order("effective_at DESC, id DESC").
+		First(&prev).Error; err == nil {
+		rows = append([]entity.GamePriceHistory{prev}, rows...)
+	}
+
+	var lowest *float64
+	if m, err := services.LowestPrices(db, region.ID, []uint{g.ID}, now); err == nil {
+		if v, ok := m[g.ID]; ok {
+			lowest = &v
+		}
+	}
+
+	currency := ""
+	if region.Currency != nil {
+		currency = region.Currency.Code
+	}
+	current, err := services.GetDiscountedPriceForGame(db, g.ID, region, now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"game_id":          g.ID,
+		"region_code":      region.Code,
+		"currency":         currency,
+		"current_price":    current,
+		"lowest_price_30d": lowest,
+		"history":          rows,
+	})
+}
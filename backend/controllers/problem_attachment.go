@@ -38,6 +38,10 @@ func FindProblemAttachments(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
+	staff := reportStaff(c)
+	for i := range attachments {
+		attachments[i].URL = problemAttachmentURL(c, attachments[i], staff)
+	}
 	c.JSON(http.StatusOK, attachments)
 }
 
@@ -48,9 +52,22 @@ func FindProblemAttachmentByID(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "id not found"})
 		return
 	}
+	attachment.URL = problemAttachmentURL(c, attachment, reportStaff(c))
 	c.JSON(http.StatusOK, attachment)
 }
 
+// signed URL เฉพาะเจ้าของคำร้องหรือ staff
+func problemAttachmentURL(c *gin.Context, a entity.ProblemAttachment, staff bool) string {
+	sign := staff
+	if !sign {
+		var n int64
+		configs.DB().Model(&entity.ProblemReport{}).
+			Where("id = ? AND user_id = ?", a.ReportID, c.GetUint("userID")).Count(&n)
+		sign = n > 0
+	}
+	return reportFileURL(c, a.FilePath, sign)
+}
+
 // PUT /problem-attachments/:id
 func UpdateProblemAttachment(c *gin.Context) {
 	var payload entity.ProblemAttachment
@@ -4,15 +4,15 @@ package controllers
 import (
 	"errors"
 	"fmt"
+	"mime/multipart"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
 
 	"example.com/sa-gameshop/configs"
 	"example.com/sa-gameshop/entity"
+	"example.com/sa-gameshop/services"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
@@ -22,7 +22,7 @@ import (
 // ===============================
 
 // POST /reports (multipart/form-data)
-// fields: title, description, category, user_id, attachments[]
+// fields: title, description, category, attachments[] (ผู้ส่งมาจาก token)
 func CreateReport(c *gin.Context) {
 	db := configs.DB()
 
@@ -34,29 +34,22 @@ func CreateReport(c *gin.Context) {
 		status = "open"
 	}
 
-	// ✅ อ่าน user id จาก X-User-ID ก่อน แล้วค่อย fallback ไป form "user_id"
-	uidStr := strings.TrimSpace(c.GetHeader("X-User-ID"))
-	if uidStr == "" {
-		uidStr = strings.TrimSpace(c.PostForm("user_id"))
-	}
-	userID, _ := strconv.Atoi(uidStr)
+	// ผู้ส่งมาจาก token เสมอ (ไม่เชื่อ user_id ใน form)
+	userID := int(c.GetUint("userID"))
 
 	if title == "" || desc == "" || userID <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "missing required fields (title, description, user_id)"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing required fields (title, description)"})
 		return
 	}
 
-	// ✅ SOFT-CHECK ผู้ใช้: ถ้าไม่พบก็ยังอนุญาตให้สร้างรายงานต่อได้ (กันเคส dev/test ที่ยังไม่มี record)
-	if err := db.First(&entity.User{}, userID).Error; err != nil {
-		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-		// ถ้า ErrRecordNotFound: ข้ามไป สร้างต่อได้เลย
-	}
-
 	// ❗ ไม่ใช้ game_id ในระบบนี้แล้ว
 
+	// ไฟล์แนบต้องไม่เกิน quota ของผู้ส่ง (ตรวจก่อนสร้างคำร้อง)
+	files := reportAttachmentFiles(c)
+	if !checkUploadQuota(c, uint(userID), files) {
+		return
+	}
+
 	report := entity.ProblemReport{
 		Title:       title,
 		Description: desc,
@@ -71,27 +64,19 @@ func CreateReport(c *gin.Context) {
 	}
 
 	// แนบไฟล์ผู้ใช้ → uploads/reports/{reportID}/...
-	if form, _ := c.MultipartForm(); form != nil {
-		files := form.File["attachments"]
-		if len(files) == 0 {
-			files = form.File["file"]
-		}
-		if len(files) > 0 {
-			dir := filepath.Join("uploads", "reports", fmt.Sprintf("%d", report.ID))
-			_ = os.MkdirAll(dir, 0o755)
-			for _, f := range files {
-				name := fmt.Sprintf("%d_%s", time.Now().UnixNano(), filepath.Base(f.Filename))
-				dst := filepath.Join(dir, name)
-				relPath := "/" + filepath.ToSlash(dst)
-
-				if err := c.SaveUploadedFile(f, dst); err != nil {
-					continue
-				}
-				_ = db.Create(&entity.ProblemAttachment{
-					FilePath: relPath,
-					ReportID: report.ID,
-				}).Error
+	if len(files) > 0 {
+		dir := fmt.Sprintf("reports/%d", report.ID)
+		for _, f := range files {
+			key := dir + "/" + uploadFileName(f.Filename)
+			relPath := "/uploads/" + key
+
+			if err := storeUpload(c, f, key, report.UserID); err != nil {
+				continue
 			}
+			_ = db.Create(&entity.ProblemAttachment{
+				FilePath: relPath,
+				ReportID: report.ID,
+			}).Error
 		}
 	}
 
@@ -106,10 +91,12 @@ func CreateReport(c *gin.Context) {
 		Preload("Replies.Attachments").
 		First(&report, report.ID).Error
 
+	signReportFiles(c, &report)
 	c.JSON(http.StatusCreated, gin.H{"data": report})
 }
 
 // GET /reports?user_id=&game_id=&status=&page=&limit=
+// ผู้ใช้ทั่วไปเห็นเฉพาะคำร้องของตัวเอง (user_id ถูกบังคับ) staff (reports.read) เห็นทั้งหมด
 func FindReports(c *gin.Context) {
 	db := configs.DB()
 
@@ -130,6 +117,11 @@ func FindReports(c *gin.Context) {
 		}
 	}
 
+	staff := reportStaff(c)
+	if !staff {
+		userID = c.GetUint("userID")
+	}
+
 	status := strings.TrimSpace(c.Query("status"))
 
 	if v := c.Query("page"); v != "" {
@@ -171,10 +163,13 @@ func FindReports(c *gin.Context) {
 		return
 	}
 
+	for i := range items {
+		signReportFilesAs(c, &items[i], staff)
+	}
 	c.JSON(http.StatusOK, gin.H{"data": items})
 }
 
-// GET /reports/:id
+// GET /reports/:id (เจ้าของหรือ staff เท่านั้น)
 func GetReportByID(c *gin.Context) {
 	db := configs.DB()
 	id, _ := strconv.Atoi(c.Param("id"))
@@ -194,6 +189,11 @@ func GetReportByID(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	if rp.UserID != c.GetUint("userID") && !reportStaff(c) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "report not found"})
+		return
+	}
+	signReportFiles(c, &rp)
 	c.JSON(http.StatusOK, gin.H{"data": rp})
 }
 
@@ -264,6 +264,7 @@ func UpdateReport(c *gin.Context) {
 		Preload("Replies.Admin").
 		Preload("Replies.Attachments").
 		First(&rp, rp.ID).Error
+	signReportFiles(c, &rp)
 	c.JSON(http.StatusOK, gin.H{"data": rp})
 }
 
@@ -271,10 +272,21 @@ func UpdateReport(c *gin.Context) {
 func DeleteReport(c *gin.Context) {
 	db := configs.DB()
 	id, _ := strconv.Atoi(c.Param("id"))
+
+	// ไฟล์แนบของคำร้องและคำตอบ (ลบหลังลบแถวสำเร็จ)
+	var paths []string
+	db.Model(&entity.ProblemAttachment{}).Where("report_id = ?", id).Pluck("file_path", &paths)
+	var replyPaths []string
+	db.Model(&entity.ProblemReplyAttachment{}).
+		Joins("JOIN problem_replies r ON r.id = problem_reply_attachments.reply_id").
+		Where("r.report_id = ?", id).
+		Pluck("problem_reply_attachments.file_path", &replyPaths)
+
 	if err := db.Delete(&entity.ProblemReport{}, id).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	services.ReleaseUpload(c.Request.Context(), db, append(paths, replyPaths...)...)
 	c.Status(http.StatusNoContent)
 }
 
@@ -288,7 +300,10 @@ func DeleteReport(c *gin.Context) {
 func ReplyReport(c *gin.Context) {
 	db := configs.DB()
 	reportID, _ := strconv.Atoi(c.Param("id"))
-	adminID, _ := strconv.Atoi(c.PostForm("admin_id")) // optional
+	adminID, _ := strconv.Atoi(c.PostForm("admin_id")) // optional (ไม่ส่ง = ผู้ใช้จาก token)
+	if adminID <= 0 {
+		adminID = int(c.GetUint("userID"))
+	}
 
 	// ยอมรับทั้ง "message" หรือ "text"
 	msg := strings.TrimSpace(c.PostForm("text"))
@@ -305,6 +320,7 @@ func ReplyReport(c *gin.Context) {
 	if err != nil {
 		return
 	}
+	signReplyFiles(c, reply)
 	c.JSON(http.StatusCreated, gin.H{"data": reply})
 }
 
@@ -314,6 +330,9 @@ func AdminCreateReply(c *gin.Context) {
 	db := configs.DB()
 	reportID, _ := strconv.Atoi(c.Param("id"))
 	adminID, _ := strconv.Atoi(c.PostForm("admin_id"))
+	if adminID <= 0 {
+		adminID = int(c.GetUint("userID"))
+	}
 
 	// ยอมรับทั้ง "message" หรือ "text"
 	msg := strings.TrimSpace(c.PostForm("message"))
@@ -330,6 +349,7 @@ func AdminCreateReply(c *gin.Context) {
 	if err != nil {
 		return
 	}
+	signReplyFiles(c, reply)
 	c.JSON(http.StatusCreated, gin.H{"data": reply})
 }
 
@@ -385,6 +405,11 @@ func createReplyFlow(c *gin.Context, db *gorm.DB, reportID int, adminID int, msg
 		return nil, errors.New("missing admin_id")
 	}
 
+	files := reportAttachmentFiles(c)
+	if !checkUploadQuota(c, uint(max(adminID, 0)), files) {
+		return nil, services.ErrQuotaExceeded
+	}
+
 	// สร้าง Reply
 	reply := entity.ProblemReply{
 		ReportID: uint(reportID),
@@ -400,28 +425,20 @@ func createReplyFlow(c *gin.Context, db *gorm.DB, reportID int, adminID int, msg
 
 	// แนบไฟล์ → uploads/replies/{replyID}/...
 	attachCount := 0
-	if form, _ := c.MultipartForm(); form != nil {
-		files := form.File["attachments"]
-		if len(files) == 0 {
-			files = form.File["file"]
-		}
-		if len(files) > 0 {
-			attachCount = len(files)
-			dir := filepath.Join("uploads", "replies", fmt.Sprintf("%d", reply.ID))
-			_ = os.MkdirAll(dir, 0o755)
-			for _, f := range files {
-				name := fmt.Sprintf("%d_%s", time.Now().UnixNano(), filepath.Base(f.Filename))
-				dst := filepath.Join(dir, name)
-				relPath := "/" + filepath.ToSlash(dst)
-
-				if err := c.SaveUploadedFile(f, dst); err != nil {
-					continue
-				}
-				_ = db.Create(&entity.ProblemReplyAttachment{
-					ReplyID:  reply.ID,
-					FilePath: relPath,
-				}).Error
+	if len(files) > 0 {
+		attachCount = len(files)
+		dir := fmt.Sprintf("replies/%d", reply.ID)
+		for _, f := range files {
+			key := dir + "/" + uploadFileName(f.Filename)
+			relPath := "/uploads/" + key
+
+			if err := storeUpload(c, f, key, reply.AdminID); err != nil {
+				continue
 			}
+			_ = db.Create(&entity.ProblemReplyAttachment{
+				ReplyID:  reply.ID,
+				FilePath: relPath,
+			}).Error
 		}
 	}
 
@@ -444,6 +461,18 @@ func createReplyFlow(c *gin.Context, db *gorm.DB, reportID int, adminID int, msg
 	return &reply, nil
 }
 
+// ไฟล์แนบจาก field "attachments" (หรือ "file" แบบเดิม)
+func reportAttachmentFiles(c *gin.Context) []*multipart.FileHeader {
+	form, _ := c.MultipartForm()
+	if form == nil {
+		return nil
+	}
+	if files := form.File["attachments"]; len(files) > 0 {
+		return files
+	}
+	return form.File["file"]
+}
+
 func notifyAdminsNewReport(db *gorm.DB, report *entity.ProblemReport) {
 	// เลือกผู้ใช้ที่เป็น Admin ทั้งหมด (สมมติว่ามี roles.name = 'Admin')
 	type adminUser struct {
@@ -460,14 +489,14 @@ func notifyAdminsNewReport(db *gorm.DB, report *entity.ProblemReport) {
 		Scan(&admins)
 
 	for _, a := range admins {
-		_ = db.Create(&entity.Notification{
+		_ = services.Notify(db, &entity.Notification{
 			Title:    "📩 มีคำร้องใหม่",
 			Message:  fmt.Sprintf("%s: %s", strings.TrimSpace(report.Category), strings.TrimSpace(report.Title)),
 			Type:     "report_new",
 			UserID:   a.ID,       // ผู้รับ = แอดมิน
 			ReportID: &report.ID, // อ้างอิงคำร้อง
 			IsRead:   false,
-		}).Error
+		})
 	}
 }
 
@@ -483,14 +512,14 @@ func makeOrUpdateUserReportNotification(db *gorm.DB, userID uint, typ string, re
 		title = "การแจ้งเตือนคำร้อง"
 	}
 
-	_ = db.Create(&entity.Notification{
+	_ = services.Notify(db, &entity.Notification{
 		Title:    title,
 		Message:  msg,
 		Type:     typ,
 		UserID:   userID,
 		ReportID: &reportID,
 		IsRead:   false,
-	}).Error
+	})
 }
 
 func buildReplyMessage(msg string, attachCount int) string {
@@ -6,7 +6,6 @@ import (
 	"mime/multipart"
 	"net/http"
 	"os"
-	"path/filepath"
 	"strings"
 	"time"
 
@@ -85,18 +84,12 @@ func CreatePromotion(c *gin.Context) {
 
 	var promoImagePath string
 	if req.PromoImage != nil {
-		uploadDir := filepath.Join("uploads", "promotions")
-		if err := os.MkdirAll(uploadDir, os.ModePerm); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create directory"})
+		img, err := saveImageUpload(c, req.PromoImage, "promotions")
+		if err != nil {
+			imageUploadError(c, err)
 			return
 		}
-		filename := fmt.Sprintf("%d_%s", time.Now().UnixNano(), req.PromoImage.Filename)
-		path := filepath.Join(uploadDir, filename)
-		if err := c.SaveUploadedFile(req.PromoImage, path); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save file"})
-			return
-		}
-		promoImagePath = path
+		promoImagePath = img.Path
 	}
 
 	promo := entity.Promotion{
@@ -135,6 +128,8 @@ func CreatePromotion(c *gin.Context) {
 		return
 	}
 
+	recordPrices(db, promotionGameIDs(db, promo.ID), entity.PricePromotionUpdate, &promo.ID)
+
 	// reload with relations for response
 	if err := db.Preload("Games").First(&promo, promo.ID).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "reload failed: " + err.Error()})
@@ -218,18 +213,12 @@ func UpdatePromotion(c *gin.Context) {
 
 	var promoImagePath string
 	if req.PromoImage != nil {
-		uploadDir := filepath.Join("uploads", "promotions")
-		if err := os.MkdirAll(uploadDir, os.ModePerm); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create directory"})
+		img, err := saveImageUpload(c, req.PromoImage, "promotions")
+		if err != nil {
+			imageUploadError(c, err)
 			return
 		}
-		filename := fmt.Sprintf("%d_%s", time.Now().UnixNano(), req.PromoImage.Filename)
-		path := filepath.Join(uploadDir, filename)
-		if err := c.SaveUploadedFile(req.PromoImage, path); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save file"})
-			return
-		}
-		promoImagePath = path
+		promoImagePath = img.Path
 	}
 
 	// validate date window if both provided
@@ -239,6 +228,9 @@ func UpdatePromotion(c *gin.Context) {
 			return
 		}
 	}
+	// เกมเดิมในโปร (ใช้บันทึกราคาหลังแก้ไข)
+	affected := promotionGameIDs(db, row.ID)
+
 	// apply partial fields
 	updates := map[string]any{}
 	if req.Title != nil {
@@ -292,6 +284,9 @@ func UpdatePromotion(c *gin.Context) {
 		}
 	}
 
+	affected = append(affected, promotionGameIDs(db, row.ID)...)
+	recordPrices(db, affected, entity.PricePromotionUpdate, &row.ID)
+
 	if err := db.Preload("Games").First(&row, row.ID).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "reload failed: " + err.Error()})
 		return
@@ -301,13 +296,21 @@ func UpdatePromotion(c *gin.Context) {
 
 // DELETE /promotions/:id
 func DeletePromotion(c *gin.Context) {
-	if tx := configs.DB().Delete(&entity.Promotion{}, c.Param("id")); tx.Error != nil {
+	db := configs.DB()
+	var row entity.Promotion
+	if err := db.First(&row, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "promotion not found"})
+		return
+	}
+	affected := promotionGameIDs(db, row.ID)
+	if tx := db.Delete(&row); tx.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": tx.Error.Error()})
 		return
 	} else if tx.RowsAffected == 0 {
 		c.JSON(http.StatusNotFound, gin.H{"error": "promotion not found"})
 		return
 	}
+	recordPrices(db, affected, entity.PricePromotionUpdate, &row.ID)
 	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
 }
 
@@ -342,10 +345,12 @@ func SetPromotionGames(c *gin.Context) {
 			return
 		}
 	}
+	affected := promotionGameIDs(db, promo.ID)
 	if err := db.Model(&promo).Association("Games").Replace(games); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "update games failed: " + err.Error()})
 		return
 	}
+	recordPrices(db, append(affected, req.GameIDs...), entity.PricePromotionUpdate, &promo.ID)
 	if err := db.Preload("Games").First(&promo, promo.ID).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "reload failed: " + err.Error()})
 		return
@@ -1,56 +1,85 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"example.com/sa-gameshop/configs"
-    "example.com/sa-gameshop/entity"
+	"example.com/sa-gameshop/entity"
+	"example.com/sa-gameshop/services"
 	"github.com/gin-gonic/gin"
 )
 
-func targetExists(targetType string, targetID any) bool {
-	db := configs.DB()
-	switch strings.ToLower(targetType) {
-	case "thread":
-		var t entity.Thread
-		return db.First(&t, targetID).RowsAffected > 0
-	case "comment":
-		var m entity.Comment
-		return db.First(&m, targetID).RowsAffected > 0
+type reactionBody struct {
+	TargetType string `json:"target_type" binding:"required"`
+	TargetID   uint   `json:"target_id"   binding:"required"`
+	Type       string `json:"type"        binding:"required"`
+}
+
+func reactionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidReactionType), errors.Is(err, services.ErrReactionTarget):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 	default:
-		return false
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// สรุปรีแอคชันของเนื้อหาเดียว (ตอบกลับหลังกด/ยกเลิก)
+func reactionSummary(c *gin.Context, targetType string, targetID, viewer uint) *services.ReactionSummary {
+	rows, err := services.ReactionSummaries(configs.DB(), strings.ToLower(strings.TrimSpace(targetType)), []uint{targetID}, viewer)
+	if err != nil || len(rows) == 0 {
+		return nil
 	}
+	return &rows[0]
 }
 
-// POST /reactions
+// GET /reactions/types — ชุดรีแอคชันที่ใช้ได้
+func FindReactionTypes(c *gin.Context) {
+	c.JSON(http.StatusOK, services.ReactionTypes)
+}
+
+// POST /reactions {"target_type": "comment", "target_id": 1, "type": "love"}
+// กดซ้ำไม่เพิ่ม (200), เพิ่มใหม่ (201)
 func CreateReaction(c *gin.Context) {
-	var body entity.Reaction
+	var body reactionBody
 	if err := c.ShouldBindJSON(&body); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "bad request body"})
 		return
 	}
-
-	// เช็ค User
-	var user entity.User
-	if tx := configs.DB().Where("id = ?", body.UserID).First(&user); tx.RowsAffected == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id not found"})
+	// ผู้ใช้มาจาก token เสมอ (ไม่เชื่อ user_id ใน body)
+	uid := c.GetUint("userID")
+	added, err := services.AddReaction(configs.DB(), uid, body.TargetType, body.TargetID, body.Type)
+	if err != nil {
+		reactionError(c, err)
 		return
 	}
-	// เช็คเป้าหมาย
-	if !targetExists(body.TargetType, body.TargetID) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid target"})
-		return
+	status := http.StatusOK
+	if added {
+		status = http.StatusCreated
 	}
+	c.JSON(status, reactionSummary(c, body.TargetType, body.TargetID, uid))
+}
 
-	if err := configs.DB().Create(&body).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+// POST /reactions/toggle {"target_type": "thread", "target_id": 1, "type": "like"}
+func ToggleReaction(c *gin.Context) {
+	var body reactionBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad request body"})
+		return
+	}
+	uid := c.GetUint("userID")
+	reacted, err := services.ToggleReaction(configs.DB(), uid, body.TargetType, body.TargetID, body.Type)
+	if err != nil {
+		reactionError(c, err)
 		return
 	}
-	c.JSON(http.StatusCreated, body)
+	c.JSON(http.StatusOK, gin.H{"reacted": reacted, "summary": reactionSummary(c, body.TargetType, body.TargetID, uid)})
 }
 
-// GET /reactions  (required: ?target_type=...&target_id=... ; optional: ?user_id=...)
+// GET /reactions  (required: ?target_type=...&target_id=... ; optional: ?user_id=...&type=...)
 func FindReactions(c *gin.Context) {
 	var rows []entity.Reaction
 
@@ -63,19 +92,48 @@ func FindReactions(c *gin.Context) {
 		return
 	}
 
-	tx := configs.DB().Model(&entity.Reaction{}).
+	tx := configs.DB().Model(&entity.Reaction{}).Preload("User").
 		Where("target_type = ? AND target_id = ?", tt, tid)
 	if uid != "" {
 		tx = tx.Where("user_id = ?", uid)
 	}
+	if t := c.Query("type"); t != "" {
+		tx = tx.Where("type = ?", t)
+	}
 
-	if err := tx.Find(&rows).Error; err != nil {
+	if err := tx.Order("id ASC").Find(&rows).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, rows)
 }
 
+// GET /reactions/summary?target_type=comment&target_ids=1,2,3
+// จำนวนแต่ละชนิด + mine (ถ้าระบุผู้ใช้ได้)
+func FindReactionSummaries(c *gin.Context) {
+	tt := strings.ToLower(strings.TrimSpace(c.Query("target_type")))
+	if _, ok := services.ModerationPermFor(tt); !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": services.ErrReactionTarget.Error()})
+		return
+	}
+	var ids []uint
+	for _, s := range strings.Split(c.Query("target_ids"), ",") {
+		if n, err := strconv.ParseUint(strings.TrimSpace(s), 10, 64); err == nil && n > 0 {
+			ids = append(ids, uint(n))
+		}
+	}
+	if len(ids) == 0 || len(ids) > 200 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "target_ids must list 1-200 ids"})
+		return
+	}
+	rows, err := services.ReactionSummaries(configs.DB(), tt, ids, optionalUserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, rows)
+}
+
 // GET /reactions/:id
 func FindReactionByID(c *gin.Context) {
 	var row entity.Reaction
@@ -86,9 +144,11 @@ func FindReactionByID(c *gin.Context) {
 	c.JSON(http.StatusOK, row)
 }
 
-// PUT /reactions/:id
+// PUT /reactions/:id {"type": "haha"} — เปลี่ยนชนิด (เจ้าของเท่านั้น)
 func UpdateReaction(c *gin.Context) {
-	var payload entity.Reaction
+	var payload struct {
+		Type string `json:"type" binding:"required"`
+	}
 	if err := c.ShouldBindJSON(&payload); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -99,33 +159,53 @@ func UpdateReaction(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "id not found"})
 		return
 	}
-	// ถ้าแก้ target ให้เช็คด้วย
-	if payload.TargetType != "" || payload.TargetID != 0 {
-		tt := payload.TargetType
-		if tt == "" {
-			tt = row.TargetType
-		}
-		tid := payload.TargetID
-		if tid == 0 {
-			tid = row.TargetID
+	uid := c.GetUint("userID")
+	if row.UserID != uid {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return
+	}
+	typ := strings.ToLower(strings.TrimSpace(payload.Type))
+	if !services.ValidReactionType(typ) {
+		reactionError(c, services.ErrInvalidReactionType)
+		return
+	}
+	if typ != row.Type {
+		if _, err := services.AddReaction(db, uid, row.TargetType, row.TargetID, typ); err != nil {
+			reactionError(c, err)
+			return
 		}
-		if !targetExists(tt, tid) {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid target"})
+		if _, err := services.RemoveReaction(db, uid, row.TargetType, row.TargetID, row.Type); err != nil {
+			reactionError(c, err)
 			return
 		}
 	}
-	if err := db.Model(&row).Updates(payload).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-	c.JSON(http.StatusOK, gin.H{"message": "updated successful"})
+	c.JSON(http.StatusOK, reactionSummary(c, row.TargetType, row.TargetID, uid))
 }
 
-// DELETE /reactions/:id
+// DELETE /reactions/:id (เจ้าของ หรือผู้ดูแลพร้อม ?reason=)
 func DeleteReactionByID(c *gin.Context) {
-	if tx := configs.DB().Exec("DELETE FROM reactions WHERE id = ?", c.Param("id")); tx.RowsAffected == 0 {
+	db := configs.DB()
+	var row entity.Reaction
+	if tx := db.First(&row, c.Param("id")); tx.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "id not found"})
+		return
+	}
+	reason := deleteReason(c)
+	moderator, ok := communityWriteAllowed(c, db, row.UserID, reason)
+	if !ok {
+		return
+	}
+	removed, err := services.RemoveReaction(db, row.UserID, row.TargetType, row.TargetID, row.Type)
+	if err != nil {
+		reactionError(c, err)
+		return
+	}
+	if !removed {
 		c.JSON(http.StatusNotFound, gin.H{"error": "id not found"})
 		return
 	}
+	if moderator {
+		logModeration(c, db, "reaction", row.ID, row.UserID, "delete", reason, row.Type)
+	}
 	c.JSON(http.StatusOK, gin.H{"message": "deleted successful"})
 }
@@ -0,0 +1,414 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"example.com/sa-gameshop/configs"
+	"example.com/sa-gameshop/entity"
+	"example.com/sa-gameshop/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// requestRegion หา region ของคำขอ
+// - ผู้ใช้ที่ระบุตัวได้ → ตามโปรไฟล์ (region_code)
+// - ผู้ใช้ทั่วไป → ?region= หรือค่าเริ่มต้น
+func requestRegion(c *gin.Context, db *gorm.DB) (entity.Region, error) {
+	if uid := optionalUserID(c); uid != 0 {
+		return services.RegionForUser(db, uid)
+	}
+	return services.ResolveRegion(db, c.Query("region"))
+}
+
+// ===== Currencies =====
+
+type currencyBody struct {
+	Code         *string              `json:"code"`
+	Symbol       *string              `json:"symbol"`
+	Decimals     *int                 `json:"decimals"       binding:"omitempty,min=0,max=4"`
+	RateFromBase *float64             `json:"rate_from_base" binding:"omitempty,gt=0"`
+	RoundingMode *entity.RoundingMode `json:"rounding_mode"`
+	RoundingStep *float64             `json:"rounding_step"  binding:"omitempty,min=0"`
+}
+
+func validRoundingMode(m entity.RoundingMode) bool {
+	switch m {
+	case entity.RoundNone, entity.RoundNearest, entity.RoundUp, entity.RoundCharm:
+		return true
+	}
+	return false
+}
+
+// GET /currencies
+func FindCurrencies(c *gin.Context) {
+	var rows []entity.Currency
+	if err := configs.DB().Order("code ASC").Find(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, rows)
+}
+
+// POST /currencies  (games.manage)
+func CreateCurrency(c *gin.Context) {
+	var body currencyBody
+	if err := c.ShouldBindJSON(&body); err != nil || body.Code == nil || body.RateFromBase == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code and rate_from_base required"})
+		return
+	}
+	row := entity.Currency{
+		Code:         strings.ToUpper(strings.TrimSpace(*body.Code)),
+		Decimals:     2,
+		RateFromBase: *body.RateFromBase,
+		RoundingMode: entity.RoundNearest,
+	}
+	if len(row.Code) != 3 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code must be ISO 4217 (3 letters)"})
+		return
+	}
+	if body.Symbol != nil {
+		row.Symbol = *body.Symbol
+	}
+	if body.Decimals != nil {
+		row.Decimals = *body.Decimals
+	}
+	if body.RoundingMode != nil {
+		row.RoundingMode = *body.RoundingMode
+	}
+	if body.RoundingStep != nil {
+		row.RoundingStep = *body.RoundingStep
+	}
+	if !validRoundingMode(row.RoundingMode) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid rounding_mode"})
+		return
+	}
+	if err := configs.DB().Create(&row).Error; err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, row)
+}
+
+// PUT /currencies/:id  (games.manage) — แก้อัตราแลกเปลี่ยน/นโยบายปัดเศษ
+func UpdateCurrency(c *gin.Context) {
+	db := configs.DB()
+	var row entity.Currency
+	if tx := db.First(&row, c.Param("id")); tx.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "currency not found"})
+		return
+	}
+	var body currencyBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updates := map[string]interface{}{}
+	if body.Symbol != nil {
+		updates["symbol"] = *body.Symbol
+	}
+	if body.Decimals != nil {
+		updates["decimals"] = *body.Decimals
+	}
+	if body.RateFromBase != nil {
+		if row.Code == services.BaseCurrency && *body.RateFromBase != 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "base currency rate must be 1"})
+			return
+		}
+		updates["rate_from_base"] = *body.RateFromBase
+	}
+	if body.RoundingMode != nil {
+		if !validRoundingMode(*body.RoundingMode) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid rounding_mode"})
+			return
+		}
+		updates["rounding_mode"] = *body.RoundingMode
+	}
+	if body.RoundingStep != nil {
+		updates["rounding_step"] = *body.RoundingStep
+	}
+	if len(updates) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "no changes"})
+		return
+	}
+	if err := db.Model(&row).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	_ = db.First(&row, row.ID)
+
+	// อัตรา/การปัดเศษเปลี่ยน → ราคาใน region ที่ใช้สกุลนี้เปลี่ยนตาม
+	if body.RateFromBase != nil || body.RoundingMode != nil || body.RoundingStep != nil || body.Decimals != nil {
+		recordAllPrices(db, entity.PriceRateChange)
+	}
+	c.JSON(http.StatusOK, row)
+}
+
+// ===== Regions =====
+
+type regionBody struct {
+	Code       *string `json:"code"`
+	Name       *string `json:"name"`
+	CurrencyID *uint   `json:"currency_id"`
+	IsDefault  *bool   `json:"is_default"`
+
+	RatingSystemID *uint `json:"rating_system_id"` // 0 = ไม่ผูกระบบเรต
+}
+
+// rating_system_id ต้องมีอยู่จริง (0 = ล้างค่า → nil)
+func regionRatingSystem(db *gorm.DB, id uint) (*uint, bool) {
+	if id == 0 {
+		return nil, true
+	}
+	if tx := db.First(&entity.RatingSystem{}, id); tx.RowsAffected == 0 {
+		return nil, false
+	}
+	return &id, true
+}
+
+// GET /regions
+func FindRegions(c *gin.Context) {
+	var rows []entity.Region
+	if err := configs.DB().Preload("Currency").Order("code ASC").Find(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, rows)
+}
+
+// POST /regions  (games.manage)
+func CreateRegion(c *gin.Context) {
+	var body regionBody
+	if err := c.ShouldBindJSON(&body); err != nil || body.Code == nil || body.CurrencyID == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code and currency_id required"})
+		return
+	}
+	db := configs.DB()
+	if tx := db.First(&entity.Currency{}, *body.CurrencyID); tx.RowsAffected == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "currency_id not found"})
+		return
+	}
+
+	row := entity.Region{
+		Code:       strings.ToUpper(strings.TrimSpace(*body.Code)),
+		CurrencyID: *body.CurrencyID,
+	}
+	if row.Code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code required"})
+		return
+	}
+	if body.Name != nil {
+		row.Name = strings.TrimSpace(*body.Name)
+	}
+	if body.IsDefault != nil {
+		row.IsDefault = *body.IsDefault
+	}
+	if body.RatingSystemID != nil {
+		id, ok := regionRatingSystem(db, *body.RatingSystemID)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "rating_system_id not found"})
+			return
+		}
+		row.RatingSystemID = id
+	}
+
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if row.IsDefault {
+			if err := tx.Model(&entity.Region{}).Where("is_default = ?", true).Update("is_default", false).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Create(&row).Error
+	}); err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	recordAllPrices(db, entity.PriceSync)
+	_ = db.Preload("Currency").First(&row, row.ID)
+	c.JSON(http.StatusCreated, row)
+}
+
+// PUT /regions/:id  (games.manage)
+func UpdateRegion(c *gin.Context) {
+	db := configs.DB()
+	var row entity.Region
+	if tx := db.First(&row, c.Param("id")); tx.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "region not found"})
+		return
+	}
+	var body regionBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updates := map[string]interface{}{}
+	if body.Name != nil {
+		updates["name"] = strings.TrimSpace(*body.Name)
+	}
+	if body.CurrencyID != nil {
+		if tx := db.First(&entity.Currency{}, *body.CurrencyID); tx.RowsAffected == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "currency_id not found"})
+			return
+		}
+		updates["currency_id"] = *body.CurrencyID
+	}
+	if body.IsDefault != nil {
+		if !*body.IsDefault && row.IsDefault {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "set another region as default instead"})
+			return
+		}
+		updates["is_default"] = *body.IsDefault
+	}
+	if body.RatingSystemID != nil {
+		id, ok := regionRatingSystem(db, *body.RatingSystemID)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "rating_system_id not found"})
+			return
+		}
+		updates["rating_system_id"] = id
+	}
+	if len(updates) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "no changes"})
+		return
+	}
+
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if body.IsDefault != nil && *body.IsDefault {
+			if err := tx.Model(&entity.Region{}).Where("is_default = ? AND id <> ?", true, row.ID).Update("is_default", false).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&row).Updates(updates).Error
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if body.CurrencyID != nil {
+		recordAllPrices(db, entity.PriceRateChange)
+	}
+	_ = db.Preload("Currency").First(&row, row.ID)
+	c.JSON(http.StatusOK, row)
+}
+
+// ===== Game price lists =====
+
+type regionPriceDTO struct {
+	RegionID   uint    `json:"region_id"`
+	RegionCode string  `json:"region_code"`
+	Currency   string  `json:"currency"`
+	Price      float64 `json:"price"`
+	IsOverride bool    `json:"is_override"` // true = แอดมินตั้งเอง, false = แปลงจาก base_price
+}
+
+// GET /games/:id/prices — ราคาของเกมในทุก region
+func FindGamePrices(c *gin.Context) {
+	db := configs.DB()
+	var g entity.Game
+	if err := db.Preload("RegionPrices").First(&g, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "game not found"})
+		return
+	}
+	var regions []entity.Region
+	if err := db.Preload("Currency").Order("code ASC").Find(&regions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	overrides := map[uint]bool{}
+	for _, rp := range g.RegionPrices {
+		overrides[rp.RegionID] = true
+	}
+	out := make([]regionPriceDTO, 0, len(regions))
+	for _, r := range regions {
+		dto := regionPriceDTO{
+			RegionID:   r.ID,
+			RegionCode: r.Code,
+			Price:      services.RegionalBasePrice(g, r),
+			IsOverride: overrides[r.ID],
+		}
+		if r.Currency != nil {
+			dto.Currency = r.Currency.Code
+		}
+		out = append(out, dto)
+	}
+	c.JSON(http.StatusOK, gin.H{"game_id": g.ID, "base_price": g.BasePrice, "base_currency": services.BaseCurrency, "prices": out})
+}
+
+// PUT /games/:id/prices  (games.manage)
+// body: { "prices": [ { "region_code": "US", "price": 19.99 }, ... ] }
+// price <= 0 → ลบราคาที่ตั้งเอง (กลับไปใช้การแปลงจาก base_price)
+func SetGamePrices(c *gin.Context) {
+	var body struct {
+		Prices []struct {
+			RegionID   uint    `json:"region_id"`
+			RegionCode string  `json:"region_code"`
+			Price      float64 `json:"price"`
+		} `json:"prices" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+
+	db := configs.DB()
+	var g entity.Game
+	if tx := db.First(&g, c.Param("id")); tx.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "game not found"})
+		return
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, p := range body.Prices {
+			var r entity.Region
+			q := tx.Preload("Currency")
+			if p.RegionID != 0 {
+				q = q.Where("id = ?", p.RegionID)
+			} else {
+				q = q.Where("code = ?", strings.ToUpper(strings.TrimSpace(p.RegionCode)))
+			}
+			if err := q.First(&r).Error; err != nil {
+				return errRegionNotFound
+			}
+
+			if p.Price <= 0 {
+				if err := tx.Unscoped().Where("game_id = ? AND region_id = ?", g.ID, r.ID).
+					Delete(&entity.GameRegionPrice{}).Error; err != nil {
+					return err
+				}
+				continue
+			}
+
+			price := services.RoundPrice(p.Price, r.Currency)
+			var row entity.GameRegionPrice
+			err := tx.Where("game_id = ? AND region_id = ?", g.ID, r.ID).First(&row).Error
+			switch {
+			case err == nil:
+				if err := tx.Model(&row).Update("price", price).Error; err != nil {
+					return err
+				}
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				if err := tx.Create(&entity.GameRegionPrice{GameID: g.ID, RegionID: r.ID, Price: price}).Error; err != nil {
+					return err
+				}
+			default:
+				return err
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, errRegionNotFound) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	recordPrices(db, []uint{g.ID}, entity.PriceRegionOverride, nil)
+	FindGamePrices(c)
+}
+
+var errRegionNotFound = errors.New("region not found")
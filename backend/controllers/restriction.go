@@ -0,0 +1,187 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"example.com/sa-gameshop/configs"
+	"example.com/sa-gameshop/entity"
+	"example.com/sa-gameshop/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// restrictionBlocked ผู้ใช้ถูกจำกัดสิทธิ์ในขอบเขตนี้ → ตอบ 403 ไปแล้ว คืน true
+func restrictionBlocked(c *gin.Context, db *gorm.DB, userID uint, scope string) bool {
+	r, err := services.CheckRestriction(db, userID, scope)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return true
+	}
+	if r != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "account restricted", "restriction": r})
+		return true
+	}
+	return false
+}
+
+// GET /users/:id/restrictions?active=1 — ผู้ดูแลดูประวัติการจำกัดสิทธิ์
+func FindUserRestrictions(c *gin.Context) {
+	tx := configs.DB().Preload("IssuedBy").Where("user_id = ?", c.Param("id"))
+	if c.Query("active") == "1" {
+		tx = tx.Where("lifted_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", time.Now())
+	}
+	var rows []entity.UserRestriction
+	if err := tx.Order("id DESC").Find(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, rows)
+}
+
+// GET /restrictions/mine — การจำกัดสิทธิ์ที่ยังมีผลของผู้ใช้ปัจจุบัน
+func FindMyRestrictions(c *gin.Context) {
+	rows, err := services.ActiveRestrictions(configs.DB(), c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, rows)
+}
+
+// POST /users/:id/restrictions {"scope": "community", "reason": "...", "duration_hours": 72}
+// duration_hours = 0 → ถาวร (จนกว่าจะยกเลิก)
+func CreateUserRestriction(c *gin.Context) {
+	var body struct {
+		Scope         string `json:"scope"  binding:"required"`
+		Reason        string `json:"reason" binding:"required"`
+		DurationHours int    `json:"duration_hours"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad request body"})
+		return
+	}
+	uid := c.GetUint("userID")
+	db := configs.DB()
+
+	perm, ok := services.RestrictionPermFor(body.Scope)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": services.ErrUnknownScope.Error()})
+		return
+	}
+	if !services.UserHasPermission(db, uid, perm) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return
+	}
+	reason := strings.TrimSpace(body.Reason)
+	if reason == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": services.ErrModerationReason.Error()})
+		return
+	}
+	if body.DurationHours < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "duration_hours must not be negative"})
+		return
+	}
+
+	var target entity.User
+	if tx := db.Select("id").Limit(1).Find(&target, c.Param("id")); tx.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+	if target.ID == uid {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cannot restrict yourself"})
+		return
+	}
+
+	var expires *time.Time
+	if body.DurationHours > 0 {
+		e := time.Now().Add(time.Duration(body.DurationHours) * time.Hour)
+		expires = &e
+	}
+	var r entity.UserRestriction
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if r, err = services.IssueRestriction(tx, target.ID, body.Scope, reason, expires, uid); err != nil {
+			return err
+		}
+		action := services.ActionMute
+		if body.Scope == entity.RestrictLogin {
+			action = services.ActionBan
+		}
+		return tx.Create(&entity.ModerationLog{
+			ActorID:       uid,
+			TargetType:    "user",
+			TargetID:      target.ID,
+			AuthorID:      target.ID,
+			Action:        action,
+			Reason:        reason,
+			ExpiresAt:     expires,
+			RestrictionID: &r.ID,
+		}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	msg := "บัญชีของคุณถูกจำกัดสิทธิ์ (" + body.Scope + ") เหตุผล: " + reason
+	if expires != nil {
+		msg += " (ถึง " + expires.Format("2006-01-02 15:04") + ")"
+	}
+	if err := services.NotifyUser(db, target.ID, services.NotificationModerationAction, "การดำเนินการของผู้ดูแล", msg); err != nil {
+		log.Println("[Restriction] notify error:", err)
+	}
+	c.JSON(http.StatusCreated, r)
+}
+
+// POST /restrictions/:id/lift {"reason": "..."} — ยกเลิกก่อนหมดอายุ
+func LiftUserRestriction(c *gin.Context) {
+	var body struct {
+		Reason string `json:"reason"`
+	}
+	_ = c.ShouldBindJSON(&body) // อนุญาตให้ว่างได้
+	uid := c.GetUint("userID")
+	db := configs.DB()
+
+	var r entity.UserRestriction
+	if tx := db.Limit(1).Find(&r, c.Param("id")); tx.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "restriction not found"})
+		return
+	}
+	if perm, _ := services.RestrictionPermFor(r.Scope); !services.UserHasPermission(db, uid, perm) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return
+	}
+	if r.LiftedAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "restriction already lifted"})
+		return
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := services.LiftRestriction(tx, r.ID, uid); err != nil {
+			return err
+		}
+		return tx.Create(&entity.ModerationLog{
+			ActorID:       uid,
+			TargetType:    "user",
+			TargetID:      r.UserID,
+			AuthorID:      r.UserID,
+			Action:        "lift",
+			Reason:        moderationReason(c, body.Reason),
+			RestrictionID: &r.ID,
+		}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := services.NotifyUser(db, r.UserID, services.NotificationModerationAction, "การดำเนินการของผู้ดูแล",
+		fmt.Sprintf("ยกเลิกการจำกัดสิทธิ์ (%s) ของคุณแล้ว", r.Scope)); err != nil {
+		log.Println("[Restriction] notify error:", err)
+	}
+	db.First(&r, r.ID)
+	c.JSON(http.StatusOK, r)
+}
@@ -11,13 +11,14 @@ import (
 
 	"example.com/sa-gameshop/configs"
 	"example.com/sa-gameshop/entity"
+	"example.com/sa-gameshop/services"
 )
 
 // ---- DTO ----
 
 type reviewCreateDTO struct {
 	GameID      uint   `json:"game_id" binding:"required"`
-	UserID      uint   `json:"user_id" binding:"required"`
+	UserID      uint   `json:"user_id"` // ไม่ใช้แล้ว ผู้เขียนมาจาก token
 	ReviewTitle string `json:"review_title"`
 	ReviewText  string `json:"review_text" binding:"required"`
 	Rating      int    `json:"rating" binding:"required"`
@@ -27,6 +28,7 @@ type reviewUpdateDTO struct {
 	ReviewTitle *string `json:"review_title"`
 	ReviewText  *string `json:"review_text"`
 	Rating      *int    `json:"rating"`
+	Reason      string  `json:"reason"` // ผู้ดูแลแก้รีวิวของคนอื่นต้องระบุ
 }
 
 // ---- Helpers ----
@@ -41,9 +43,23 @@ func clampRating(n int) int {
 	return n
 }
 
+// ข้อความ "ที่ไหน" ในแจ้งเตือน @mention ของรีวิว
+func reviewWhere(r entity.Review) string {
+	if r.Game != nil {
+		return "รีวิวเกม " + r.Game.GameName
+	}
+	return "รีวิว " + r.ReviewTitle
+}
+
+// เจ้าของรีวิว หรือผู้มีสิทธิ์ reviews.moderate (ต้องมีเหตุผล) — ไม่ผ่าน = ตอบ error ไปแล้ว
+func reviewWriteAllowed(c *gin.Context, db *gorm.DB, r entity.Review, reason string) (moderator bool, ok bool) {
+	perm, _ := services.ModerationPermFor("review")
+	return moderatedWriteAllowed(c, db, perm, r.UserID, reason)
+}
+
 // ---- Handlers ----
 
-// POST /reviews
+// POST /reviews (ผู้เขียนมาจาก token ไม่เชื่อ user_id ใน body)
 func CreateReview(c *gin.Context) {
 	var in reviewCreateDTO
 	if err := c.ShouldBindJSON(&in); err != nil {
@@ -51,8 +67,12 @@ func CreateReview(c *gin.Context) {
 		return
 	}
 	in.Rating = clampRating(in.Rating)
+	in.UserID = c.GetUint("userID")
 
 	db := configs.DB()
+	if restrictionBlocked(c, db, in.UserID, entity.RestrictReviews) {
+		return
+	}
 
 	// กันซ้ำแบบเร็ว (ก่อนชน unique)
 	var exists entity.Review
@@ -85,6 +105,8 @@ func CreateReview(c *gin.Context) {
 		ReviewText:  in.ReviewText,
 		Rating:      in.Rating,
 	}
+	var mentioned []uint
+	r.ReviewHTML, mentioned = services.RenderMarkdown(db, r.ReviewText)
 	if err := db.Create(&r).Error; err != nil {
 		// map duplicate เป็น 409 (กันกรณีเล็ดรอดมาชน unique)
 		if errors.Is(err, gorm.ErrDuplicatedKey) ||
@@ -101,6 +123,7 @@ func CreateReview(c *gin.Context) {
 
 	var out entity.Review
 	_ = db.Preload("User").Preload("Game").First(&out, r.ID).Error
+	notifyMentions(db, r.UserID, mentioned, nil, reviewWhere(out), entity.Notification{ReviewID: &r.ID, GameID: &r.GameID})
 	c.JSON(http.StatusCreated, out)
 }
 
@@ -109,6 +132,7 @@ func FindReviews(c *gin.Context) {
 	db := configs.DB()
 	var list []entity.Review
 	if err := db.Preload("User").Preload("Game").
+		Where("hidden = ?", false).
 		Order("updated_at DESC").
 		Find(&list).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "list_failed"})
@@ -130,10 +154,14 @@ func GetReviewByID(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "get_failed"})
 		return
 	}
+	if row.Hidden && !canSeeHidden(c, db, "review", row.UserID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not_found"})
+		return
+	}
 	c.JSON(http.StatusOK, row)
 }
 
-// PUT /reviews/:id
+// PUT /reviews/:id (เจ้าของ หรือ reviews.moderate พร้อม reason)
 func UpdateReview(c *gin.Context) {
 	id := c.Param("id")
 	var in reviewUpdateDTO
@@ -152,12 +180,24 @@ func UpdateReview(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "get_failed"})
 		return
 	}
+	reason := moderationReason(c, in.Reason)
+	moderator, ok := reviewWriteAllowed(c, db, r, reason)
+	if !ok {
+		return
+	}
+	if !moderator && restrictionBlocked(c, db, r.UserID, entity.RestrictReviews) {
+		return
+	}
+	snapshot := r.ReviewTitle + "\n\n" + r.ReviewText
 
 	if in.ReviewTitle != nil {
 		r.ReviewTitle = *in.ReviewTitle
 	}
-	if in.ReviewText != nil {
+	var mentioned, previously []uint
+	if in.ReviewText != nil && *in.ReviewText != r.ReviewText {
+		_, previously = services.RenderMarkdown(db, r.ReviewText)
 		r.ReviewText = *in.ReviewText
+		r.ReviewHTML, mentioned = services.RenderMarkdown(db, r.ReviewText)
 	}
 	if in.Rating != nil {
 		r.Rating = clampRating(*in.Rating)
@@ -167,20 +207,46 @@ func UpdateReview(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "update_failed"})
 		return
 	}
+	if moderator {
+		logModeration(c, db, "review", r.ID, r.UserID, "update", reason, snapshot)
+	}
 
 	var out entity.Review
 	_ = db.Preload("User").Preload("Game").First(&out, r.ID).Error
+	notifyMentions(db, r.UserID, mentioned, previously, reviewWhere(out), entity.Notification{ReviewID: &r.ID, GameID: &r.GameID})
 	c.JSON(http.StatusOK, out)
 }
 
-// DELETE /reviews/:id
+// DELETE /reviews/:id (เจ้าของ หรือ reviews.moderate พร้อม ?reason=)
 func DeleteReview(c *gin.Context) {
 	id := c.Param("id")
 	db := configs.DB()
-	if err := db.Unscoped().Delete(&entity.Review{}, id).Error; err != nil {
+	var r entity.Review
+	if err := db.First(&r, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "not_found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "get_failed"})
+		return
+	}
+	reason := deleteReason(c)
+	moderator, ok := reviewWriteAllowed(c, db, r, reason)
+	if !ok {
+		return
+	}
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Delete(&entity.Review{}, r.ID).Error; err != nil {
+			return err
+		}
+		return services.ClearReactions(tx, "review", r.ID)
+	}); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "delete_failed"})
 		return
 	}
+	if moderator {
+		logModeration(c, db, "review", r.ID, r.UserID, "delete", reason, r.ReviewTitle+"\n\n"+r.ReviewText)
+	}
 	c.Status(http.StatusNoContent)
 }
 
@@ -189,14 +255,24 @@ func FindReviewsByGame(c *gin.Context) {
 	gameID := c.Param("id")
 	db := configs.DB()
 	var list []entity.Review
-	if err := db.Preload("User").Preload("Likes").
-		Where("game_id = ?", gameID).
+	if err := db.Preload("User").
+		Where("game_id = ? AND hidden = ?", gameID, false).
 		Order("updated_at DESC").
 		Find(&list).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "list_failed"})
 		return
 	}
 
+	ids := make([]uint, 0, len(list))
+	for _, r := range list {
+		ids = append(ids, r.ID)
+	}
+	likes, err := services.ReactionCountsOf(db, "review", ids, services.ReactionLike)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "list_failed"})
+		return
+	}
+
 	// แปลงให้มีฟิลด์ likes เป็นจำนวนไลก์แทนการส่งรายละเอียดไลก์ทั้งหมด
 	out := make([]gin.H, 0, len(list))
 	for _, r := range list {
@@ -207,64 +283,36 @@ func FindReviewsByGame(c *gin.Context) {
 			"DeletedAt":    r.DeletedAt,
 			"review_title": r.ReviewTitle,
 			"review_text":  r.ReviewText,
+			"review_html":  r.ReviewHTML,
 			"rating":       r.Rating,
 			"user_id":      r.UserID,
 			"user":         r.User,
 			"game_id":      r.GameID,
 			"game":         r.Game,
-			"likes":        len(r.Likes),
+			"likes":        likes[r.ID],
 		})
 	}
 
 	c.JSON(http.StatusOK, out)
 }
 
-// POST /reviews/:id/toggle_like
+// POST /reviews/:id/toggle_like — ทางลัดของ POST /reactions/toggle {"type": "like"}
+// ผู้ใช้มาจาก token (user_id ใน body ถูกละเลย)
 func ToggleReviewLike(c *gin.Context) {
 	id := c.Param("id")
-	var body struct {
-		UserID uint `json:"user_id" binding:"required"`
-	}
-	if err := c.ShouldBindJSON(&body); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_payload"})
-		return
-	}
-
 	rid, _ := strconv.Atoi(id)
 	db := configs.DB()
 
-	// หา like เดิม
-	var like entity.Review_Like
-	err := db.Where("review_id = ? AND user_id = ?", rid, body.UserID).First(&like).Error
-	liked := true
-	if err == nil {
-		// มีแล้ว => ลบ (unlike)
-		if err := db.Unscoped().Delete(&like).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "toggle_failed"})
-			return
-		}
-		liked = false
-	} else if err != gorm.ErrRecordNotFound {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "toggle_failed"})
+	liked, err := services.ToggleReaction(db, c.GetUint("userID"), "review", uint(rid), services.ReactionLike)
+	if err != nil {
+		reactionError(c, err)
 		return
-	} else {
-		// ยังไม่มี => เพิ่ม like
-		newLike := entity.Review_Like{
-			ReviewID: uint(rid),
-			UserID:   body.UserID,
-		}
-		if err := db.Create(&newLike).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "toggle_failed"})
-			return
-		}
 	}
-
-	var cnt int64
-	db.Model(&entity.Review_Like{}).Where("review_id = ?", rid).Count(&cnt)
+	counts, _ := services.ReactionCountsOf(db, "review", []uint{uint(rid)}, services.ReactionLike)
 
 	c.JSON(http.StatusOK, gin.H{
 		"review_id": rid,
-		"likes":     cnt,
+		"likes":     counts[uint(rid)],
 		"liked":     liked,
 	})
 }
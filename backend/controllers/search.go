@@ -0,0 +1,63 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"example.com/sa-gameshop/configs"
+	"example.com/sa-gameshop/services"
+	"github.com/gin-gonic/gin"
+)
+
+// GET /search?q=...&type=game,thread&limit=20&offset=0
+// ค้นหารวมทุกประเภท เรียงตามความเกี่ยวข้อง พร้อม snippet และจำนวนต่อประเภท (facets)
+func Search(c *gin.Context) {
+	q := strings.TrimSpace(c.Query("q"))
+	if q == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q is required"})
+		return
+	}
+
+	var kinds []string
+	if v := c.Query("type"); v != "" {
+		valid := services.SearchKinds()
+		for _, k := range strings.Split(v, ",") {
+			k = strings.ToLower(strings.TrimSpace(k))
+			ok := false
+			for _, s := range valid {
+				if s == k {
+					ok = true
+					break
+				}
+			}
+			if !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid type: " + k})
+				return
+			}
+			kinds = append(kinds, k)
+		}
+	}
+
+	limit := 20
+	if v, err := strconv.Atoi(c.Query("limit")); err == nil && v > 0 && v <= 100 {
+		limit = v
+	}
+	offset := 0
+	if v, err := strconv.Atoi(c.Query("offset")); err == nil && v > 0 {
+		offset = v
+	}
+
+	db := configs.DB()
+	region, err := requestRegion(c, db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "region not configured"})
+		return
+	}
+	res, err := services.Search(db, q, kinds, limit, offset, requestViewer(c, db), region)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
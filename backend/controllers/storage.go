@@ -0,0 +1,133 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"example.com/sa-gameshop/configs"
+	"example.com/sa-gameshop/entity"
+	"example.com/sa-gameshop/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm/clause"
+)
+
+// GET /storage/usage — พื้นที่ที่ผู้ใช้ปัจจุบันใช้ไปกับโควต้า
+func GetMyStorageUsage(c *gin.Context) {
+	usage, err := services.UserStorageUsage(configs.DB(), c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, usage)
+}
+
+type storageUsageRow struct {
+	services.StorageUsage
+	Username string `json:"username"`
+}
+
+// GET /admin/storage/usage?limit= — ผู้ใช้ที่ใช้พื้นที่มากที่สุด
+func FindStorageUsage(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if limit <= 0 || limit > 500 {
+		limit = 50
+	}
+	db := configs.DB()
+	var rows []storageUsageRow
+	if err := db.Table("upload_objects o").
+		Select("o.user_id, COALESCE(u.username, '') AS username, SUM(o.size) AS used_bytes, COUNT(*) AS files, q.quota_bytes AS quota_bytes").
+		Joins("LEFT JOIN users u ON u.id = o.user_id").
+		Joins("LEFT JOIN storage_quotas q ON q.user_id = o.user_id AND q.deleted_at IS NULL").
+		Where("o.deleted_at IS NULL").
+		Group("o.user_id").
+		Order("used_bytes DESC").
+		Limit(limit).
+		Scan(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// แถวที่ไม่มีโควต้าเฉพาะใช้ค่าเริ่มต้น
+	var custom []uint
+	db.Model(&entity.StorageQuota{}).Pluck("user_id", &custom)
+	hasCustom := map[uint]bool{}
+	for _, id := range custom {
+		hasCustom[id] = true
+	}
+	def := services.DefaultUploadQuota()
+	var total int64
+	for i := range rows {
+		if !hasCustom[rows[i].UserID] {
+			rows[i].QuotaBytes = def
+		}
+		total += rows[i].UsedBytes
+	}
+	c.JSON(http.StatusOK, gin.H{"data": rows, "default_quota_bytes": def, "listed_bytes": total})
+}
+
+// PUT /admin/storage/quotas/:user_id {"quota_bytes": 1048576}
+// quota_bytes = 0 → ไม่จำกัด, null → กลับไปใช้ค่าเริ่มต้น
+func SetStorageQuota(c *gin.Context) {
+	uid, err := strconv.ParseUint(c.Param("user_id"), 10, 64)
+	if err != nil || uid == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user_id"})
+		return
+	}
+	var body struct {
+		QuotaBytes *int64 `json:"quota_bytes"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	db := configs.DB()
+	if tx := db.First(&entity.User{}, uid); tx.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+
+	if body.QuotaBytes == nil {
+		db.Unscoped().Where("user_id = ?", uid).Delete(&entity.StorageQuota{})
+	} else {
+		if *body.QuotaBytes < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "quota_bytes must be >= 0"})
+			return
+		}
+		q := entity.StorageQuota{UserID: uint(uid), QuotaBytes: *body.QuotaBytes}
+		if err := db.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"quota_bytes", "updated_at"}),
+		}).Create(&q).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	usage, err := services.UserStorageUsage(db, uint(uid))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, usage)
+}
+
+// POST /admin/storage/gc?dry_run=true&min_age_hours=24
+// ค่าเริ่มต้นเป็น dry run (รายงานอย่างเดียว) ต้องส่ง dry_run=false ถึงจะลบจริง
+func RunUploadGC(c *gin.Context) {
+	dryRun := c.DefaultQuery("dry_run", "true") != "false"
+	hours, err := strconv.ParseFloat(c.DefaultQuery("min_age_hours", "24"), 64)
+	if err != nil || hours < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid min_age_hours"})
+		return
+	}
+	rep, err := services.CollectUploadGarbage(c.Request.Context(), configs.DB(), services.UploadGCOptions{
+		DryRun: dryRun,
+		MinAge: time.Duration(hours * float64(time.Hour)),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, rep)
+}
@@ -0,0 +1,203 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"example.com/sa-gameshop/configs"
+	"example.com/sa-gameshop/entity"
+	"example.com/sa-gameshop/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// OS ต้องเป็นรหัสใน platforms
+func validPlatformCode(db *gorm.DB, code string) bool {
+	var n int64
+	db.Model(&entity.Platform{}).Where("code = ?", code).Count(&n)
+	return n > 0
+}
+
+func validTier(v int) bool { return v >= 0 && v <= 10 }
+
+// GET /games/:id/requirements
+func FindGameRequirements(c *gin.Context) {
+	var rows []entity.SystemRequirement
+	if err := configs.DB().Where("game_id = ?", c.Param("id")).Order("os ASC, level ASC").Find(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, rows)
+}
+
+// PUT /games/:id/requirements  (games.manage)
+// body: { "requirements": [ { "os": "windows", "level": "minimum", "ram_mb": 8192, ... } ] }
+// แทนที่ของเดิมทั้งหมด
+func SetGameRequirements(c *gin.Context) {
+	var body struct {
+		Requirements []entity.SystemRequirement `json:"requirements" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	db := configs.DB()
+	var game entity.Game
+	if tx := db.First(&game, c.Param("id")); tx.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "game not found"})
+		return
+	}
+
+	seen := map[string]bool{}
+	rows := make([]entity.SystemRequirement, 0, len(body.Requirements))
+	for _, r := range body.Requirements {
+		r.OS = strings.ToLower(strings.TrimSpace(r.OS))
+		r.Level = strings.ToLower(strings.TrimSpace(r.Level))
+		if r.Level != entity.SpecMinimum && r.Level != entity.SpecRecommended {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "level must be minimum or recommended"})
+			return
+		}
+		if !validPlatformCode(db, r.OS) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown os: " + r.OS})
+			return
+		}
+		if !validTier(r.CPUTier) || !validTier(r.GPUTier) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "cpu_tier/gpu_tier must be 0-10"})
+			return
+		}
+		if r.RAMMB < 0 || r.VRAMMB < 0 || r.StorageGB < 0 || r.DirectX < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "values must not be negative"})
+			return
+		}
+		key := r.OS + "/" + r.Level
+		if seen[key] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "duplicate requirement: " + key})
+			return
+		}
+		seen[key] = true
+		r.Model = gorm.Model{}
+		r.GameID = game.ID
+		rows = append(rows, r)
+	}
+
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("game_id = ?", game.ID).Delete(&entity.SystemRequirement{}).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		return tx.Create(&rows).Error
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, rows)
+}
+
+// GET /hardware-profile
+func GetHardwareProfile(c *gin.Context) {
+	uid := c.GetUint("userID")
+	var row entity.HardwareProfile
+	err := configs.DB().Where("user_id = ?", uid).First(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "hardware profile not set"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, row)
+}
+
+// PUT /hardware-profile
+func SaveHardwareProfile(c *gin.Context) {
+	uid := c.GetUint("userID")
+	var body entity.HardwareProfile
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	db := configs.DB()
+	body.OS = strings.ToLower(strings.TrimSpace(body.OS))
+	if body.OS != "" && !validPlatformCode(db, body.OS) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unknown os: " + body.OS})
+		return
+	}
+	if !validTier(body.CPUTier) || !validTier(body.GPUTier) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cpu_tier/gpu_tier must be 0-10"})
+		return
+	}
+	if body.RAMMB < 0 || body.VRAMMB < 0 || body.FreeStorageGB < 0 || body.DirectX < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "values must not be negative"})
+		return
+	}
+
+	var row entity.HardwareProfile
+	err := db.Where("user_id = ?", uid).First(&row).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	body.Model = row.Model
+	body.UserID = uid
+	if err := db.Save(&body).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, body)
+}
+
+// GET /games/:id/compatibility
+// ใช้โปรไฟล์สเปกของผู้ใช้ (ถ้าล็อกอิน) และ query ทับค่าได้:
+// os, cpu_tier, gpu_tier, ram_mb, vram_mb, free_storage_gb, directx, vulkan
+func CheckGameCompatibility(c *gin.Context) {
+	db := configs.DB()
+	var game entity.Game
+	if tx := db.First(&game, c.Param("id")); tx.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "game not found"})
+		return
+	}
+
+	var hw entity.HardwareProfile
+	if uid := optionalUserID(c); uid != 0 {
+		db.Where("user_id = ?", uid).First(&hw)
+	}
+	if v := c.Query("os"); v != "" {
+		hw.OS = strings.ToLower(strings.TrimSpace(v))
+	}
+	for param, dst := range map[string]*int{
+		"cpu_tier":        &hw.CPUTier,
+		"gpu_tier":        &hw.GPUTier,
+		"ram_mb":          &hw.RAMMB,
+		"vram_mb":         &hw.VRAMMB,
+		"free_storage_gb": &hw.FreeStorageGB,
+		"directx":         &hw.DirectX,
+	} {
+		if v := c.Query(param); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + param})
+				return
+			}
+			*dst = n
+		}
+	}
+	if v := c.Query("vulkan"); v != "" {
+		hw.Vulkan = v
+	}
+	if hw.OS == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "hardware profile not set (save one or pass ?os=...)"})
+		return
+	}
+
+	var reqs []entity.SystemRequirement
+	if err := db.Where("game_id = ?", game.ID).Find(&reqs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, services.CheckCompatibility(game.ID, reqs, hw))
+}
@@ -2,10 +2,13 @@ package controllers
 
 import (
 	"net/http"
+	"strings"
 
 	"example.com/sa-gameshop/configs"
 	"example.com/sa-gameshop/entity"
+	"example.com/sa-gameshop/services"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // GET /tags
@@ -36,6 +39,16 @@ func CreateTag(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	tag.Title = strings.TrimSpace(tag.Title)
+	if tag.Title == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "title is required"})
+		return
+	}
+	base := tag.Slug
+	if strings.TrimSpace(base) == "" {
+		base = tag.Title
+	}
+	tag.Slug = services.UniqueSlug(configs.DB(), "tags", services.Slugify(base), 0)
 	if err := configs.DB().Create(&tag).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -43,34 +56,99 @@ func CreateTag(c *gin.Context) {
 	c.JSON(http.StatusCreated, tag)
 }
 
+type tagBody struct {
+	Title *string `json:"title"`
+	Slug  *string `json:"slug"`
+}
+
 // PATCH /tags/:id
+// แก้ได้แค่ชื่อและ slug; slug เปลี่ยนเมื่อส่งมาเท่านั้น
 func UpdateTag(c *gin.Context) {
-	id := c.Param("id")
+	db := configs.DB()
 	var tag entity.Tags
-	if tx := configs.DB().Where("id = ?", id).First(&tag); tx.RowsAffected == 0 {
+	if tx := db.First(&tag, c.Param("id")); tx.RowsAffected == 0 {
 		c.JSON(http.StatusNotFound, gin.H{"error": "tag not found"})
 		return
 	}
-
-	var input entity.Tags
-	if err := c.ShouldBindJSON(&input); err != nil {
+	var body tagBody
+	if err := c.ShouldBindJSON(&body); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if err := configs.DB().Model(&tag).Updates(input).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	updates := map[string]interface{}{}
+	if body.Title != nil {
+		title := strings.TrimSpace(*body.Title)
+		if title == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "title must not be empty"})
+			return
+		}
+		updates["title"] = title
+	}
+	if body.Slug != nil {
+		slug := services.Slugify(*body.Slug)
+		if slug == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid slug"})
+			return
+		}
+		updates["slug"] = services.UniqueSlug(db, "tags", slug, tag.ID)
+	}
+	if len(updates) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "no changes"})
 		return
 	}
+	if err := db.Model(&tag).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	_ = db.First(&tag, tag.ID)
 	c.JSON(http.StatusOK, tag)
 }
 
-// DELETE /tags/:id
+// DELETE /tags/:id  (ถอดแท็กออกจากเกม/ม็อดด้วย)
 func DeleteTag(c *gin.Context) {
 	id := c.Param("id")
-	if tx := configs.DB().Exec("DELETE FROM tags WHERE id = ?", id); tx.RowsAffected == 0 {
+	var tag entity.Tags
+	if tx := configs.DB().First(&tag, id); tx.RowsAffected == 0 {
 		c.JSON(http.StatusNotFound, gin.H{"error": "tag not found"})
 		return
 	}
+	if err := configs.DB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM game_tags WHERE tags_id = ?", tag.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM mod_tags WHERE tag_id = ?", tag.ID).Error; err != nil {
+			return err
+		}
+		return tx.Exec("DELETE FROM tags WHERE id = ?", tag.ID).Error
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{"message": "deleted successfully"})
 }
+
+// POST /tags/:id/merge  body: { "into_id": 2 }
+func MergeTag(c *gin.Context) {
+	var body struct {
+		IntoID uint `json:"into_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "into_id is required"})
+		return
+	}
+	var from, into entity.Tags
+	if tx := configs.DB().First(&from, c.Param("id")); tx.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "tag not found"})
+		return
+	}
+	if tx := configs.DB().First(&into, body.IntoID); tx.RowsAffected == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "into_id not found"})
+		return
+	}
+	if err := services.MergeTag(configs.DB(), from.ID, into.ID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, into)
+}
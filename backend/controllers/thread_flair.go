@@ -0,0 +1,105 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"example.com/sa-gameshop/configs"
+	"example.com/sa-gameshop/entity"
+	"example.com/sa-gameshop/services"
+	"github.com/gin-gonic/gin"
+)
+
+func flairError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrFlairNotFound) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// GET /games/:id/flairs — ป้ายกระทู้ของเกม (ยังไม่มีเลย = สร้างป้ายเริ่มต้นให้)
+func FindGameFlairs(c *gin.Context) {
+	db := configs.DB()
+	var game entity.Game
+	if tx := db.Select("id").Limit(1).Find(&game, c.Param("id")); tx.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "game not found"})
+		return
+	}
+	rows, err := services.EnsureThreadFlairs(db, game.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, rows)
+}
+
+// POST /games/:id/flairs {"name": "Speedrun", "color": "#ff9800"}
+func CreateGameFlair(c *gin.Context) {
+	var body struct {
+		Name  string `json:"name"  binding:"required,max=60"`
+		Color string `json:"color" binding:"max=16"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad request body"})
+		return
+	}
+	db := configs.DB()
+	var game entity.Game
+	if tx := db.Select("id").Limit(1).Find(&game, c.Param("id")); tx.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "game not found"})
+		return
+	}
+	// สร้างป้ายเริ่มต้นก่อน ไม่งั้นเกมที่ได้ป้ายแรกจากผู้ดูแลจะไม่มี guide/bug/discussion
+	if _, err := services.EnsureThreadFlairs(db, game.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	row := entity.ThreadFlair{
+		GameID: game.ID,
+		Slug:   services.Slugify(body.Name),
+		Name:   strings.TrimSpace(body.Name),
+		Color:  strings.TrimSpace(body.Color),
+	}
+	if row.Slug == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid name"})
+		return
+	}
+	var n int64
+	db.Model(&entity.ThreadFlair{}).Where("game_id = ? AND slug = ?", row.GameID, row.Slug).Count(&n)
+	if n > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "flair already exists"})
+		return
+	}
+	if err := db.Create(&row).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, row)
+}
+
+// DELETE /thread-flairs/:id — กระทู้ที่ใช้ป้ายนี้จะไม่มีป้าย
+func DeleteThreadFlair(c *gin.Context) {
+	db := configs.DB()
+	var row entity.ThreadFlair
+	if tx := db.Limit(1).Find(&row, c.Param("id")); tx.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "flair not found"})
+		return
+	}
+	if err := db.Model(&entity.Thread{}).Where("flair_id = ?", row.ID).Update("flair_id", nil).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	// ลบแบบ soft เพื่อให้รู้ว่าเกมนี้เคยมีป้ายแล้ว (ไม่สร้างป้ายเริ่มต้นซ้ำ) แต่ปล่อย slug ให้สร้างใหม่ได้
+	if err := db.Model(&row).Update("slug", fmt.Sprintf("%s~%d", row.Slug, row.ID)).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := db.Delete(&row).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
+}
@@ -1,16 +1,15 @@
 package controllers
 
 import (
-	"errors"
 	"net/http"
 
 	"example.com/sa-gameshop/configs"
 	"example.com/sa-gameshop/entity"
+	"example.com/sa-gameshop/services"
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
-// POST /threads/:id/toggle_like
+// POST /threads/:id/toggle_like — ทางลัดของ POST /reactions/toggle {"type": "like"}
 func ToggleThreadLike(c *gin.Context) {
 	uid := c.GetUint("userID")
 	if uid == 0 {
@@ -23,46 +22,15 @@ func ToggleThreadLike(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "thread not found"})
 		return
 	}
-
-	db := configs.DB().Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			db.Rollback()
-		}
-	}()
-
-	var like entity.ThreadLike
-	err := db.Where("thread_id = ? AND user_id = ?", th.ID, uid).First(&like).Error
-	switch {
-	case err == nil:
-		// ยกเลิกไลก์
-		if err := db.Unscoped().Delete(&like).Error; err != nil {
-			db.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-		db.Model(&entity.Thread{}).Where("id = ?", th.ID).
-			UpdateColumn("like_count", gorm.Expr("CASE WHEN like_count > 0 THEN like_count - 1 ELSE 0 END"))
-		db.Commit()
-		c.JSON(http.StatusOK, gin.H{"liked": false})
-		return
-
-	case errors.Is(err, gorm.ErrRecordNotFound):
-		// เพิ่มไลก์
-		if err := db.Create(&entity.ThreadLike{ThreadID: th.ID, UserID: uid}).Error; err != nil {
-			db.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-		db.Model(&entity.Thread{}).Where("id = ?", th.ID).
-			UpdateColumn("like_count", gorm.Expr("like_count + 1"))
-		db.Commit()
-		c.JSON(http.StatusOK, gin.H{"liked": true})
+	if !threadAgeAllowed(c, configs.DB(), c.Param("id")) {
 		return
+	}
 
-	default:
-		db.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	liked, err := services.ToggleReaction(configs.DB(), uid, "thread", th.ID, services.ReactionLike)
+	if err != nil {
+		reactionError(c, err)
 		return
 	}
+	configs.DB().Select("like_count").First(&th, th.ID)
+	c.JSON(http.StatusOK, gin.H{"liked": liked, "like_count": th.LikeCount})
 }
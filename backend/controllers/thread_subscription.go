@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	"net/http"
+
+	"example.com/sa-gameshop/configs"
+	"example.com/sa-gameshop/entity"
+	"example.com/sa-gameshop/services"
+	"github.com/gin-gonic/gin"
+)
+
+// GET /thread-subscriptions — กระทู้ที่ติดตามอยู่ (ล่าสุดก่อน)
+func FindMyThreadSubscriptions(c *gin.Context) {
+	var rows []entity.ThreadSubscription
+	if err := configs.DB().
+		Preload("Thread").Preload("Thread.Game").Preload("Thread.User").
+		Joins("JOIN threads ON threads.id = thread_subscriptions.thread_id AND threads.deleted_at IS NULL AND threads.hidden = ?", false).
+		Where("thread_subscriptions.user_id = ? AND thread_subscriptions.following = ?", c.GetUint("userID"), true).
+		Order("COALESCE(threads.last_activity_at, threads.posted_at) DESC, thread_subscriptions.id DESC").
+		Find(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, rows)
+}
+
+// POST /threads/:id/follow
+func FollowThread(c *gin.Context) {
+	setThreadFollowing(c, true)
+}
+
+// DELETE /threads/:id/follow — เลิกติดตาม (แสดงความเห็นต่อก็ไม่ถูกติดตามอัตโนมัติอีก)
+func UnfollowThread(c *gin.Context) {
+	setThreadFollowing(c, false)
+}
+
+func setThreadFollowing(c *gin.Context, following bool) {
+	db := configs.DB()
+	var th entity.Thread
+	if tx := db.Select("id").Limit(1).Find(&th, c.Param("id")); tx.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "thread not found"})
+		return
+	}
+	if following && (!threadAgeAllowed(c, db, c.Param("id")) || !threadVisible(c, db, c.Param("id"))) {
+		return
+	}
+	if err := services.SetThreadFollowing(db, c.GetUint("userID"), th.ID, following); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"thread_id": th.ID, "following": following})
+}
@@ -2,10 +2,8 @@ package controllers
 
 import (
 	"errors"
-	"fmt"
 	"net/http"
 	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -13,12 +11,13 @@ import (
 	"example.com/sa-gameshop/configs"
 	"example.com/sa-gameshop/entity"
 	"example.com/sa-gameshop/middlewares"
+	"example.com/sa-gameshop/services"
 	"github.com/gin-gonic/gin"
 	jwt "github.com/golang-jwt/jwt/v5"
 	"gorm.io/gorm"
 )
 
-// POST /threads  (multipart: title, content, game_id, images[])
+// POST /threads  (multipart: title, content, game_id, flair (id หรือ slug), images[])
 func CreateThread(c *gin.Context) {
 	uid := c.GetUint("userID")
 	if uid == 0 {
@@ -40,6 +39,9 @@ func CreateThread(c *gin.Context) {
 	}
 
 	db := configs.DB()
+	if restrictionBlocked(c, db, uid, entity.RestrictCommunity) {
+		return
+	}
 	var game entity.Game
 	if err := db.First(&game, uint(gid)).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -49,44 +51,96 @@ func CreateThread(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	region, err := requestRegion(c, db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "region not configured"})
+		return
+	}
+	if !gameAgeAllowed(c, db, services.ViewerFor(db, uid), game.ID, region) {
+		return
+	}
+	flairID, err := services.ResolveThreadFlair(db, game.ID, c.PostForm("flair"))
+	if err != nil {
+		flairError(c, err)
+		return
+	}
 
+	// ตรวจ/แปลงรูปก่อนสร้างกระทู้ รูปไหนไม่ผ่านให้ตอบ 400 ทั้งคำขอ
+	var imagePaths []string
+	if form, err := c.MultipartForm(); err == nil && form != nil {
+		for _, f := range form.File["images"] {
+			img, err := saveImageUpload(c, f, "thread_images")
+			if err != nil {
+				services.ReleaseUpload(c.Request.Context(), db, imagePaths...)
+				imageUploadError(c, err)
+				return
+			}
+			imagePaths = append(imagePaths, "/"+img.Path)
+		}
+	}
+
+	contentHTML, mentioned := services.RenderMarkdown(db, content)
+	now := time.Now()
 	th := entity.Thread{
-		Title:    title,
-		Content:  content,
-		GameID:   game.ID,
-		UserID:   uid,
-		PostedAt: time.Now(),
+		Title:          title,
+		Content:        content,
+		ContentHTML:    contentHTML,
+		GameID:         game.ID,
+		UserID:         uid,
+		PostedAt:       now,
+		FlairID:        flairID,
+		LastActivityAt: &now,
 	}
 	if err := db.Create(&th).Error; err != nil {
+		services.ReleaseUpload(c.Request.Context(), db, imagePaths...)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	// แนบรูปเฉพาะตอนสร้าง
-	if form, err := c.MultipartForm(); err == nil && form != nil {
-		files := form.File["images"]
-		if len(files) > 0 {
-			_ = os.MkdirAll("uploads/thread_images", 0755)
-			for _, f := range files {
-				name := fmt.Sprintf("%d_%s", time.Now().UnixNano(), filepath.Base(f.Filename))
-				dst := filepath.Join("uploads", "thread_images", name)
-				if err := c.SaveUploadedFile(f, dst); err == nil {
-					_ = db.Create(&entity.ThreadImage{
-						ThreadID: th.ID,
-						FileURL:  "/" + dst,
-					}).Error
-				}
-			}
-		}
+	for _, p := range imagePaths {
+		_ = db.Create(&entity.ThreadImage{
+			ThreadID: th.ID,
+			FileURL:  p,
+		}).Error
 	}
 
-	_ = db.Preload("ThreadImages").Preload("User").Preload("Game").First(&th, th.ID)
+	notifyMentions(db, uid, mentioned, nil, "กระทู้ "+th.Title, entity.Notification{ThreadID: &th.ID})
+	_ = services.AutoFollowThread(db, uid, th.ID)
+
+	_ = db.Preload("ThreadImages").Preload("User").Preload("Game").Preload("Flair").First(&th, th.ID)
 	c.JSON(http.StatusCreated, th)
 }
 
-// GET /threads?game_id=&q=&limit=&offset=
+// ลำดับการเรียงของ GET /threads (กระทู้ปักหมุดอยู่บนสุดเสมอ)
+// hot: (ไลก์ + 2×คอมเมนต์ + 1) / (อายุเป็นชั่วโมง + 2)² กระทู้ใหม่ที่มีคนสนใจลอยขึ้น แล้วค่อยๆ จมลงตามเวลา
+var threadSorts = map[string]string{
+	"newest":   "threads.id DESC",
+	"likes":    "threads.like_count DESC, threads.id DESC",
+	"comments": "threads.comment_count DESC, threads.id DESC",
+	"activity": "COALESCE(threads.last_activity_at, threads.posted_at, threads.created_at) DESC, threads.id DESC",
+	"hot": "(threads.like_count + 2 * threads.comment_count + 1) / " +
+		"(((julianday('now') - julianday(COALESCE(threads.posted_at, threads.created_at))) * 24 + 2) * " +
+		"((julianday('now') - julianday(COALESCE(threads.posted_at, threads.created_at))) * 24 + 2)) DESC, threads.id DESC",
+}
+
+// GET /threads?game_id=&q=&flair=&sort=newest|likes|comments|activity|hot&limit=&offset=
+// ไม่แสดงกระทู้ของเกมที่ผู้ชมอายุไม่ถึงเรต
 func FindThreads(c *gin.Context) {
-	db := configs.DB().Preload("ThreadImages").Preload("User").Preload("Game")
+	order, ok := threadSorts[c.DefaultQuery("sort", "newest")]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sort must be newest, likes, comments, activity or hot"})
+		return
+	}
+	region, err := requestRegion(c, configs.DB())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "region not configured"})
+		return
+	}
+	sub, args := services.RestrictedGameIDsSQL(requestViewer(c, configs.DB()), region)
+	db := configs.DB().Preload("ThreadImages").Preload("User").Preload("Game").Preload("Flair").
+		Where("game_id NOT IN ("+sub+")", args...).
+		Where("hidden = ?", false)
 
 	if gid := c.Query("game_id"); gid != "" {
 		db = db.Where("game_id = ?", gid)
@@ -95,12 +149,19 @@ func FindThreads(c *gin.Context) {
 		p := "%" + q + "%"
 		db = db.Where("title LIKE ? OR content LIKE ?", p, p)
 	}
+	if f := strings.TrimSpace(c.Query("flair")); f != "" {
+		if id, err := strconv.ParseUint(f, 10, 64); err == nil {
+			db = db.Where("flair_id = ?", id)
+		} else {
+			db = db.Where("flair_id IN (?)", configs.DB().Model(&entity.ThreadFlair{}).Select("id").Where("slug = ?", services.Slugify(f)))
+		}
+	}
 
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
 	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
 
 	var rows []entity.Thread
-	if err := db.Order("id DESC").Limit(limit).Offset(offset).Find(&rows).Error; err != nil {
+	if err := db.Order("threads.pinned DESC").Order(order).Limit(limit).Offset(offset).Find(&rows).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
@@ -114,10 +175,14 @@ func FindThreadByID(c *gin.Context) {
 		Preload("ThreadImages").
 		Preload("User").
 		Preload("Game").
+		Preload("Flair").
 		First(&row, c.Param("id")); tx.Error != nil || tx.RowsAffected == 0 {
 		c.JSON(http.StatusNotFound, gin.H{"error": "thread not found"})
 		return
 	}
+	if !threadAgeAllowed(c, configs.DB(), c.Param("id")) || !threadVisible(c, configs.DB(), c.Param("id")) {
+		return
+	}
 
 	// optional: get user id from Authorization or X-User-ID header
 	var uid uint
@@ -145,25 +210,22 @@ func FindThreadByID(c *gin.Context) {
 		}
 	}
 
-	liked := false
-	if uid != 0 {
-		var tl entity.ThreadLike
-		if err := configs.DB().Where("thread_id = ? AND user_id = ?", row.ID, uid).First(&tl).Error; err == nil {
-			liked = true
-		}
-	}
+	liked := services.UserReacted(configs.DB(), uid, "thread", row.ID, services.ReactionLike)
 
 	type response struct {
 		entity.Thread
-		Liked bool `json:"liked"`
+		Liked     bool `json:"liked"`
+		Following bool `json:"following"`
 	}
-	c.JSON(http.StatusOK, response{Thread: row, Liked: liked})
+	c.JSON(http.StatusOK, response{Thread: row, Liked: liked, Following: services.IsFollowingThread(configs.DB(), uid, row.ID)})
 }
 
-// PUT /threads/:id  (แก้ title/content)
+// PUT /threads/:id  (แก้ title/content/flair)
 type updateThreadBody struct {
-	Title   string `json:"title"`
-	Content string `json:"content"`
+	Title   string  `json:"title"`
+	Content string  `json:"content"`
+	Flair   *string `json:"flair"`  // id หรือ slug, "" = เอาป้ายออก
+	Reason  string  `json:"reason"` // บังคับเมื่อผู้ดูแลแก้เธรดของคนอื่น
 }
 
 func UpdateThread(c *gin.Context) {
@@ -178,30 +240,129 @@ func UpdateThread(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "id not found"})
 		return
 	}
+	reason := moderationReason(c, body.Reason)
+	moderator, ok := communityWriteAllowed(c, db, row.UserID, reason)
+	if !ok {
+		return
+	}
+	// ผู้ดูแลแก้ของคนอื่นไม่ติดการจำกัดสิทธิ์ของตัวเอง
+	if !moderator && restrictionBlocked(c, db, row.UserID, entity.RestrictCommunity) {
+		return
+	}
 	updates := map[string]interface{}{}
 	if s := strings.TrimSpace(body.Title); s != "" {
 		updates["title"] = s
 	}
+	var mentioned, previously []uint
 	if s := strings.TrimSpace(body.Content); s != "" {
 		updates["content"] = s
+		updates["content_html"], mentioned = services.RenderMarkdown(db, s)
+		_, previously = services.RenderMarkdown(db, row.Content)
+	}
+	if body.Flair != nil {
+		flairID, err := services.ResolveThreadFlair(db, row.GameID, *body.Flair)
+		if err != nil {
+			flairError(c, err)
+			return
+		}
+		updates["flair_id"] = flairID
 	}
 	if len(updates) == 0 {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "no changes"})
 		return
 	}
+	snapshot := row.Title + "\n\n" + row.Content
 	if err := db.Model(&row).Updates(updates).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	_ = db.Preload("ThreadImages").Preload("User").Preload("Game").First(&row, row.ID)
+	if moderator {
+		logModeration(c, db, "thread", row.ID, row.UserID, "update", reason, snapshot)
+	}
+	notifyMentions(db, row.UserID, mentioned, previously, "กระทู้ "+row.Title, entity.Notification{ThreadID: &row.ID})
+	_ = db.Preload("ThreadImages").Preload("User").Preload("Game").Preload("Flair").First(&row, row.ID)
 	c.JSON(http.StatusOK, row)
 }
 
 // DELETE /threads/:id
 func DeleteThread(c *gin.Context) {
-	if tx := configs.DB().Delete(&entity.Thread{}, c.Param("id")); tx.Error != nil || tx.RowsAffected == 0 {
+	db := configs.DB()
+	var row entity.Thread
+	if tx := db.First(&row, c.Param("id")); tx.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "id not found"})
+		return
+	}
+	reason := deleteReason(c)
+	moderator, ok := communityWriteAllowed(c, db, row.UserID, reason)
+	if !ok {
+		return
+	}
+	var images []string
+	db.Model(&entity.ThreadImage{}).Where("thread_id = ?", row.ID).Pluck("file_url", &images)
+
+	if tx := db.Delete(&entity.Thread{}, row.ID); tx.Error != nil || tx.RowsAffected == 0 {
 		c.JSON(http.StatusNotFound, gin.H{"error": "id not found"})
 		return
 	}
+	if moderator {
+		logModeration(c, db, "thread", row.ID, row.UserID, "delete", reason, row.Title+"\n\n"+row.Content)
+	}
+	services.ReleaseUpload(c.Request.Context(), db, images...)
 	c.JSON(http.StatusOK, gin.H{"message": "deleted successful"})
 }
+
+// POST /threads/:id/pin {"pinned": true, "reason": "..."} — ผู้ดูแลเท่านั้น
+func SetThreadPinned(c *gin.Context) {
+	setThreadFlag(c, "pinned", "pin", "unpin")
+}
+
+// POST /threads/:id/lock {"locked": true, "reason": "..."} — ล็อกแล้วคอมเมนต์ใหม่ไม่ได้
+func SetThreadLocked(c *gin.Context) {
+	setThreadFlag(c, "locked", "lock", "unlock")
+}
+
+func setThreadFlag(c *gin.Context, column, onAction, offAction string) {
+	body := map[string]interface{}{}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad request body"})
+		return
+	}
+	on, ok := body[column].(bool)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": column + " (bool) required"})
+		return
+	}
+	reason, _ := body["reason"].(string)
+	reason = moderationReason(c, reason)
+	if reason == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": services.ErrModerationReason.Error()})
+		return
+	}
+
+	db := configs.DB()
+	var row entity.Thread
+	if tx := db.First(&row, c.Param("id")); tx.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "thread not found"})
+		return
+	}
+	updates := map[string]interface{}{column: on}
+	if column == "pinned" {
+		var at *time.Time
+		if on {
+			now := time.Now()
+			at = &now
+		}
+		updates["pinned_at"] = at
+	}
+	if err := db.Model(&row).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	action := offAction
+	if on {
+		action = onAction
+	}
+	logModeration(c, db, "thread", row.ID, row.UserID, action, reason, "")
+	_ = db.Preload("User").Preload("Game").Preload("Flair").First(&row, row.ID)
+	c.JSON(http.StatusOK, row)
+}
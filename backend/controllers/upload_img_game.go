@@ -1,53 +1,76 @@
 package controllers
 
 import (
+	"mime/multipart"
 	"net/http"
-	"os"
-	"path/filepath"
-	"strings"
-	"time"
 
+	"example.com/sa-gameshop/configs"
+	"example.com/sa-gameshop/services"
 	"github.com/gin-gonic/gin"
 )
 
-// UploadGame รับไฟล์จาก field name = "file"
-// เซฟที่ ./uploads/games/<timestamp>.<ext>
-// ตอบกลับ url เป็น path สำหรับเอาไปใช้ใน FE/DB
-func UploadGame(c *gin.Context) {
-	file, err := c.FormFile("file")
+// saveImageUpload ส่งไฟล์ที่อัปโหลดเข้า pipeline รูปกลาง (services.SaveImage) เก็บไว้ที่ uploads/<dir>
+// พื้นที่ (ทุก variant) นับให้ผู้ใช้ที่ล็อกอินอยู่
+func saveImageUpload(c *gin.Context, file *multipart.FileHeader, dir string) (services.StoredImage, error) {
+	uid := c.GetUint("userID")
+	db := configs.DB()
+	if _, err := services.CheckUploadQuota(db, uid, file.Size); err != nil {
+		return services.StoredImage{}, err
+	}
+	f, err := file.Open()
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
-		return
+		return services.StoredImage{}, err
 	}
-
-	// ตรวจไฟล์
-	ext := strings.ToLower(filepath.Ext(file.Filename))
-	allow := map[string]bool{
-		".jpg": true, ".jpeg": true, ".png": true, ".webp": true,
+	defer f.Close()
+	img, err := services.SaveImage(c.Request.Context(), f, dir)
+	if err != nil {
+		return img, err
 	}
-	if !allow[ext] {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "only jpg/jpeg/png/webp allowed"})
+	services.TrackUpload(c.Request.Context(), db, uid, img.Path, img.ThumbPath, img.WebPPath, img.ThumbWebPPath)
+	return img, nil
+}
+
+// imageUploadError ตอบ error ของการอัปโหลดรูป (ไฟล์ไม่ผ่าน = 400, อื่น ๆ = 500)
+func imageUploadError(c *gin.Context, err error) {
+	if services.IsImageRejected(err) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	uploadError(c, err, "save image failed: "+err.Error())
+}
+
+// saveGameImage เซฟรูปเกมที่ ./uploads/games แล้วคืน url path (/uploads/games/<hash>.<ext>)
+// ใช้ร่วมกันระหว่าง /upload/game กับแกลเลอรีของเกม
+func saveGameImage(c *gin.Context, file *multipart.FileHeader) (services.StoredImage, string, error) {
+	img, err := saveImageUpload(c, file, "games")
+	if err != nil {
+		return img, "", err
+	}
+	return img, "/" + img.Path, nil
+}
 
-	// path ปลายทาง ./uploads/games
-	baseDir := "uploads"
-	subDir := "games"
-	if err := os.MkdirAll(filepath.Join(baseDir, subDir), 0755); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "cannot create uploads/games"})
+// UploadGame รับไฟล์จาก field name = "file"
+// ตอบกลับ url เป็น path สำหรับเอาไปใช้ใน FE/DB พร้อม thumbnail/webp
+func UploadGame(c *gin.Context) {
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
 		return
 	}
 
-	// ตั้งชื่อไฟล์กันชนกัน
-	filename := time.Now().Format("20060102_150405.000000") + ext
-	dst := filepath.Join(baseDir, subDir, filename)
-
-	if err := c.SaveUploadedFile(file, dst); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "save file failed"})
+	img, urlPath, err := saveGameImage(c, file)
+	if err != nil {
+		imageUploadError(c, err)
 		return
 	}
 
 	// ส่ง path กลับ (ให้ FE เก็บใน DB)
-	urlPath := "/" + filepath.ToSlash(filepath.Join("uploads", subDir, filename))
-	c.JSON(http.StatusOK, gin.H{"url": urlPath})
+	c.JSON(http.StatusOK, gin.H{
+		"url":            urlPath,
+		"thumb_url":      "/" + img.ThumbPath,
+		"webp_url":       "/" + img.WebPPath,
+		"thumb_webp_url": "/" + img.ThumbWebPPath,
+		"width":          img.Width,
+		"height":         img.Height,
+	})
 }
@@ -6,6 +6,7 @@ import (
 
 	"example.com/sa-gameshop/configs"
 	"example.com/sa-gameshop/entity"
+	"example.com/sa-gameshop/services"
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -87,10 +88,24 @@ func FindUsers(c *gin.Context) {
 // GET /users/:id
 func FindUserByID(c *gin.Context) {
 	var user entity.User
-	if tx := configs.DB().Preload("Requests").Preload("Role").First(&user, c.Param("id")); tx.RowsAffected == 0 {
+	db := configs.DB()
+	if tx := db.Preload("Requests").Preload("Role").First(&user, c.Param("id")); tx.RowsAffected == 0 {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "id not found"})
 		return
 	}
+	// ผู้ดูแลเห็นการจำกัดสิทธิ์ที่ยังมีผลในโปรไฟล์
+	if viewer := optionalUserID(c); viewer != 0 && services.UserHasPermission(db, viewer, services.RestrictionViewPerms...) {
+		restrictions, err := services.ActiveRestrictions(db, user.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, struct {
+			entity.User
+			Restrictions []entity.UserRestriction `json:"restrictions"`
+		}{user, restrictions})
+		return
+	}
 	c.JSON(http.StatusOK, user)
 }
 
@@ -0,0 +1,254 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"example.com/sa-gameshop/configs"
+	"example.com/sa-gameshop/entity"
+	"example.com/sa-gameshop/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type wishlistRow struct {
+	entity.WishlistItem
+	CurrentPrice float64 `json:"current_price"`
+	Currency     string  `json:"currency"`
+	Owned        bool    `json:"owned"`
+}
+
+// GET /wishlist — รายการที่อยากได้ของผู้ใช้ปัจจุบัน เรียงตาม position พร้อมราคาปัจจุบัน
+func FindMyWishlist(c *gin.Context) {
+	uid := c.GetUint("userID")
+	db := configs.DB()
+
+	var items []entity.WishlistItem
+	if err := db.Preload("Game").
+		Where("user_id = ?", uid).
+		Order("position ASC, id ASC").
+		Find(&items).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	region, err := services.RegionForUser(db, uid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "region not configured"})
+		return
+	}
+	var owned []uint
+	db.Model(&entity.UserGame{}).Where("user_id = ?", uid).Pluck("game_id", &owned)
+	ownedSet := map[uint]bool{}
+	for _, id := range owned {
+		ownedSet[id] = true
+	}
+
+	now := time.Now()
+	rows := make([]wishlistRow, 0, len(items))
+	for _, it := range items {
+		price, err := services.GetDiscountedPriceForGame(db, it.GameID, region, now)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		row := wishlistRow{WishlistItem: it, CurrentPrice: price, Owned: ownedSet[it.GameID]}
+		if region.Currency != nil {
+			row.Currency = region.Currency.Code
+		}
+		rows = append(rows, row)
+	}
+	c.JSON(http.StatusOK, rows)
+}
+
+// POST /wishlist {"game_id": 1, "note": "..."}
+func AddWishlistItem(c *gin.Context) {
+	var body struct {
+		GameID uint   `json:"game_id" binding:"required"`
+		Note   string `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad request body"})
+		return
+	}
+	uid := c.GetUint("userID")
+	db := configs.DB()
+
+	var game entity.Game
+	if tx := db.First(&game, body.GameID); tx.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "game not found"})
+		return
+	}
+	var n int64
+	db.Model(&entity.UserGame{}).Where("user_id = ? AND game_id = ?", uid, body.GameID).Count(&n)
+	if n > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "game already owned"})
+		return
+	}
+	db.Model(&entity.WishlistItem{}).Where("user_id = ? AND game_id = ?", uid, body.GameID).Count(&n)
+	if n > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "game already in wishlist"})
+		return
+	}
+
+	price, _, err := services.WishlistPrice(db, uid, body.GameID, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	var maxPos int
+	db.Model(&entity.WishlistItem{}).Where("user_id = ?", uid).Select("COALESCE(MAX(position), 0)").Scan(&maxPos)
+
+	item := entity.WishlistItem{
+		UserID:        uid,
+		GameID:        body.GameID,
+		Position:      maxPos + 1,
+		Note:          body.Note,
+		AddedPrice:    price,
+		BaselinePrice: price,
+	}
+	// เกมที่วางขายอยู่แล้วไม่ต้องแจ้ง release อีก
+	if game.Status == entity.GamePublished {
+		now := time.Now()
+		item.ReleaseNotifiedAt = &now
+	}
+	if err := db.Create(&item).Error; err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	item.Game = &game
+	c.JSON(http.StatusCreated, item)
+}
+
+// PATCH /wishlist/:game_id {"note": "...", "position": 2}
+func UpdateWishlistItem(c *gin.Context) {
+	var body struct {
+		Note     *string `json:"note"`
+		Position *int    `json:"position"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad request body"})
+		return
+	}
+	uid := c.GetUint("userID")
+	db := configs.DB()
+
+	var item entity.WishlistItem
+	if tx := db.Where("user_id = ? AND game_id = ?", uid, c.Param("game_id")).First(&item); tx.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "wishlist item not found"})
+		return
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if body.Note != nil {
+			if err := tx.Model(&item).Update("note", *body.Note).Error; err != nil {
+				return err
+			}
+		}
+		if body.Position != nil && *body.Position != item.Position {
+			return moveWishlistItem(tx, uid, item.GameID, *body.Position)
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	db.Preload("Game").First(&item, item.ID)
+	c.JSON(http.StatusOK, item)
+}
+
+// ย้ายเกมไปตำแหน่ง pos (เริ่มที่ 1) แล้วเรียงเลขใหม่ให้ต่อเนื่อง
+func moveWishlistItem(tx *gorm.DB, uid, gameID uint, pos int) error {
+	var ids []uint
+	if err := tx.Model(&entity.WishlistItem{}).Where("user_id = ? AND game_id <> ?", uid, gameID).
+		Order("position ASC, id ASC").Pluck("game_id", &ids).Error; err != nil {
+		return err
+	}
+	if pos < 1 {
+		pos = 1
+	}
+	if pos > len(ids)+1 {
+		pos = len(ids) + 1
+	}
+	order := append([]uint{}, ids[:pos-1]...)
+	order = append(order, gameID)
+	order = append(order, ids[pos-1:]...)
+	return renumberWishlist(tx, uid, order)
+}
+
+func renumberWishlist(tx *gorm.DB, uid uint, gameIDs []uint) error {
+	for i, gid := range gameIDs {
+		if err := tx.Model(&entity.WishlistItem{}).
+			Where("user_id = ? AND game_id = ?", uid, gid).
+			Update("position", i+1).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PUT /wishlist/order {"game_ids": [3, 1, 2]}
+// เกมที่ไม่ได้ส่งมาจะต่อท้ายตามลำดับเดิม
+func ReorderWishlist(c *gin.Context) {
+	var body struct {
+		GameIDs []uint `json:"game_ids" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad request body"})
+		return
+	}
+	uid := c.GetUint("userID")
+	db := configs.DB()
+
+	var current []uint
+	db.Model(&entity.WishlistItem{}).Where("user_id = ?", uid).Order("position ASC, id ASC").Pluck("game_id", &current)
+	inList := map[uint]bool{}
+	for _, id := range current {
+		inList[id] = true
+	}
+
+	seen := map[uint]bool{}
+	order := make([]uint, 0, len(current))
+	for _, id := range body.GameIDs {
+		if !inList[id] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "game " + strconv.FormatUint(uint64(id), 10) + " not in wishlist"})
+			return
+		}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		order = append(order, id)
+	}
+	for _, id := range current {
+		if !seen[id] {
+			order = append(order, id)
+		}
+	}
+
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		return renumberWishlist(tx, uid, order)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"game_ids": order})
+}
+
+// DELETE /wishlist/:game_id
+func RemoveWishlistItem(c *gin.Context) {
+	uid := c.GetUint("userID")
+	// ลบจริง (unique user+game) เพื่อให้เพิ่มกลับได้
+	tx := configs.DB().Unscoped().Where("user_id = ? AND game_id = ?", uid, c.Param("game_id")).Delete(&entity.WishlistItem{})
+	if tx.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tx.Error.Error()})
+		return
+	}
+	if tx.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "wishlist item not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "removed"})
+}
@@ -0,0 +1,30 @@
+package entity
+
+import "gorm.io/gorm"
+
+// ระบบเรตติ้งอายุ เช่น ESRB, PEGI หรือเรตของไทย
+type RatingSystem struct {
+	gorm.Model
+	Code   string        `json:"code" gorm:"size:16;uniqueIndex;not null"`
+	Name   string        `json:"name"`
+	Levels []RatingLevel `json:"levels,omitempty" gorm:"foreignKey:RatingSystemID"`
+}
+
+// ระดับในระบบเรตติ้ง พร้อมอายุขั้นต่ำที่ใช้ตัดสินสิทธิ์
+type RatingLevel struct {
+	gorm.Model
+	RatingSystemID uint   `json:"rating_system_id" gorm:"not null;uniqueIndex:ux_rating_level,priority:1"`
+	Code           string `json:"code" gorm:"size:16;not null;uniqueIndex:ux_rating_level,priority:2"`
+	Name           string `json:"name"`
+	MinAge         int    `json:"min_age"`
+}
+
+// เรตของเกมในแต่ละระบบ (เกมละหนึ่งระดับต่อระบบ)
+type GameRating struct {
+	gorm.Model
+	GameID         uint          `json:"game_id" gorm:"not null;uniqueIndex:ux_game_rating,priority:1"`
+	RatingSystemID uint          `json:"rating_system_id" gorm:"not null;uniqueIndex:ux_game_rating,priority:2"`
+	RatingSystem   *RatingSystem `json:"rating_system,omitempty" gorm:"foreignKey:RatingSystemID"`
+	RatingLevelID  uint          `json:"rating_level_id" gorm:"not null"`
+	RatingLevel    *RatingLevel  `json:"rating_level,omitempty" gorm:"foreignKey:RatingLevelID"`
+}
@@ -7,4 +7,9 @@ import (
 type Categories struct {
 	gorm.Model
 	Title string `json:"title" gorm:"unique"`
+
+	// ต้นไม้หมวดหมู่ (nil = หมวดระดับบนสุด)
+	Slug      string `json:"slug" gorm:"size:96;uniqueIndex"`
+	ParentID  *uint  `json:"parent_id" gorm:"index"`
+	SortOrder int    `json:"sort_order"`
 }
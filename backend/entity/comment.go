@@ -1,12 +1,47 @@
 package entity
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Comment struct {
 	gorm.Model
-	Content  string `json:"content" gorm:"type:text;not null"`
-	ThreadID uint   `json:"thread_id" gorm:"index;not null"`
-	Thread   Thread `json:"-"`
-	UserID   uint   `json:"user_id" gorm:"not null"`
-	User     User   `json:"user"`
+	Content string `json:"content" gorm:"type:text;not null"`
+	// เรนเดอร์จาก Markdown ตอนบันทึก (sanitize แล้ว)
+	ContentHTML string `json:"content_html" gorm:"type:text"`
+	ThreadID    uint   `json:"thread_id" gorm:"index;not null"`
+	Thread      Thread `json:"-"`
+	UserID      uint   `json:"user_id" gorm:"not null"`
+	User        User   `json:"user"`
+
+	// ตอบกลับ: ParentID nil = คอมเมนต์ระดับบนสุด (Depth 0)
+	ParentID   *uint `json:"parent_id" gorm:"index"`
+	Depth      int   `json:"depth" gorm:"not null;default:0"`
+	ReplyCount int   `json:"reply_count" gorm:"not null;default:0"` // จำนวนคำตอบตรง (ลูกชั้นเดียว)
+
+	// อ้างข้อความ: เก็บข้อความตอนอ้างไว้ เผื่อต้นฉบับถูกแก้ (ต้นฉบับถูกลบ/ซ่อน → ไม่แสดง quote_text ตอนอ่าน)
+	QuotedCommentID *uint  `json:"quoted_comment_id"`
+	QuoteText       string `json:"quote_text,omitempty" gorm:"type:text"`
+
+	EditedAt  *time.Time `json:"edited_at"` // != nil → แสดง "แก้ไขแล้ว"
+	EditCount int        `json:"edit_count" gorm:"not null;default:0"`
+
+	// ลบแล้วแต่ยังมีคนตอบอยู่ → เก็บแถวไว้เป็น "ความเห็นถูกลบ" ให้ต้นไม้ไม่ขาด
+	Removed bool `json:"removed" gorm:"not null;default:false"`
+	// ผู้ดูแลซ่อน (แสดงเป็น "ถูกซ่อนโดยผู้ดูแล")
+	Hidden bool `json:"hidden" gorm:"not null;default:false"`
+
+	Replies        []Comment `json:"replies,omitempty" gorm:"-"`
+	HasMoreReplies bool      `json:"has_more_replies,omitempty" gorm:"-"`
+}
+
+// เนื้อหาก่อนแก้ไขแต่ละครั้ง (ใหม่สุดอยู่ท้าย)
+type CommentRevision struct {
+	gorm.Model
+	CommentID uint   `json:"comment_id" gorm:"index;not null"`
+	Content   string `json:"content" gorm:"type:text;not null"`
+	EditorID  uint   `json:"editor_id"`
+	Editor    *User  `json:"editor,omitempty" gorm:"foreignKey:EditorID"`
 }
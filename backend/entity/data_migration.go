@@ -0,0 +1,9 @@
+package entity
+
+import "time"
+
+// งานย้ายข้อมูลครั้งเดียวที่รันไปแล้ว (กันรันซ้ำตอนเปิดเซิร์ฟเวอร์ใหม่)
+type DataMigration struct {
+	Name      string    `json:"name" gorm:"primaryKey;size:100"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -8,13 +8,18 @@ import (
 
 type Game struct {
 	gorm.Model
-	GameName string `json:"game_name"`
+	GameName    string `json:"game_name"`
+	Description string `json:"description" gorm:"type:text"`
 	//KeyGameID      uint       `json:"key_id"`
 	CategoriesID   int         `json:"categories_id"`
 	Categories     Categories  `json:"categories" gorm:"foreignkey:CategoriesID"`
 	Date           time.Time   `json:"release_date" gorm:"autoCreateTime"`
 	BasePrice      int         `json:"base_price"`
-	Status         string      `json:"status" gorm:"type:varchar(20);default:'pending';not null;index"`
+	Status         string      `json:"status" gorm:"type:varchar(20);default:'draft';not null;index"`
+	ReleaseAt      *time.Time  `json:"release_at"`   // วันวางขายที่ตั้งไว้ (approved แล้วจะ publish อัตโนมัติเมื่อถึงเวลา)
+	PublishedAt    *time.Time  `json:"published_at"` // เวลาที่ขึ้นหน้าร้านล่าสุด
+	ReviewNote     string      `json:"review_note" gorm:"type:text"`
+	OwnerID        *uint       `json:"owner_id" gorm:"index"` // ผู้สร้างเกม (ส่งตรวจได้เอง)
 	Minimum_specID uint        `json:"minimum_spec_id"`
 	MinimumSpec    MinimumSpec `json:"minimum_spec" gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
 	AgeRating      int         `json:"age_rating"`
@@ -24,12 +29,47 @@ type Game struct {
 	Threads   []Thread   `gorm:"foreignKey:GameID" json:"threads,omitempty"`
 	UserGames []UserGame `gorm:"foreignKey:GameID" json:"user_games,omitempty"`
 
-	Reviews     []Review      `gorm:"foreignKey:GameID" json:"reviews,omitempty"`
-	ReviewLikes []Review_Like `gorm:"foreignKey:GameID" json:"review_likes,omitempty"`
+	Reviews []Review `gorm:"foreignKey:GameID" json:"reviews,omitempty"`
 
-	Promotions     []Promotion      `json:"promotions,omitempty"       gorm:"many2many:promotion_games"`
-	PromotionGames []Promotion_Game `json:"promotion_games,omitempty"  gorm:"foreignKey:GameID"`
-	ImgSrc         string           `json:"img_src"    gorm:"type:varchar(512)"`
+	Promotions     []Promotion       `json:"promotions,omitempty"       gorm:"many2many:promotion_games"`
+	PromotionGames []Promotion_Game  `json:"promotion_games,omitempty"  gorm:"foreignKey:GameID"`
+	RegionPrices   []GameRegionPrice `json:"region_prices,omitempty"    gorm:"foreignKey:GameID"`
+	ImgSrc         string            `json:"img_src"    gorm:"type:varchar(512)"`
+
+	// metadata (many-to-many); Categories ด้านบนคือหมวดหลัก
+	CategoryList []Categories `json:"category_list,omitempty" gorm:"many2many:game_categories"`
+	Genres       []Genre      `json:"genres,omitempty"        gorm:"many2many:game_genres"`
+	Tags         []Tags       `json:"tags,omitempty"          gorm:"many2many:game_tags"`
+	Developers   []Company    `json:"developers,omitempty"    gorm:"many2many:game_developers"`
+	Publishers   []Company    `json:"publishers,omitempty"    gorm:"many2many:game_publishers"`
+	Platforms    []Platform   `json:"platforms,omitempty"     gorm:"many2many:game_platforms"`
+	Languages    []Language   `json:"languages,omitempty"     gorm:"many2many:game_languages"`
+	Media        []GameMedia  `json:"media,omitempty"         gorm:"foreignKey:GameID"`
+
+	Requirements []SystemRequirement `json:"requirements,omitempty" gorm:"foreignKey:GameID"`
+	Ratings      []GameRating        `json:"ratings,omitempty"      gorm:"foreignKey:GameID"`
+}
+
+// สถานะการเผยแพร่เกม: draft → submitted → approved → published → delisted
+// (submitted → rejected → submitted ได้อีกครั้ง)
+const (
+	GameDraft     = "draft"
+	GameSubmitted = "submitted"
+	GameApproved  = "approved"
+	GameRejected  = "rejected"
+	GamePublished = "published"
+	GameDelisted  = "delisted"
+)
+
+// ประวัติการเปลี่ยนสถานะ + หมายเหตุของผู้ตรวจ
+type GameStatusLog struct {
+	gorm.Model
+	GameID     uint   `json:"game_id" gorm:"index;not null"`
+	FromStatus string `json:"from_status" gorm:"type:varchar(20)"`
+	ToStatus   string `json:"to_status" gorm:"type:varchar(20)"`
+	Note       string `json:"note" gorm:"type:text"`
+	ActorID    uint   `json:"actor_id"`
+	Actor      *User  `json:"actor,omitempty" gorm:"foreignKey:ActorID"`
 }
 
 // Hook function ไว้หลังสร้างเกมเสร็จแล้ว keygame จะเจนเอง
@@ -0,0 +1,44 @@
+package entity
+
+import "gorm.io/gorm"
+
+// บริษัทผู้พัฒนา/ผู้จัดจำหน่าย (บริษัทเดียวเป็นได้ทั้งสองบทบาท)
+type Company struct {
+	gorm.Model
+	Name    string `json:"name" gorm:"size:120;uniqueIndex;not null"`
+	Website string `json:"website" gorm:"type:varchar(255)"`
+}
+
+type Genre struct {
+	gorm.Model
+	Name string `json:"name" gorm:"size:60;uniqueIndex;not null"`
+}
+
+// แพลตฟอร์มที่รองรับ เช่น windows, macos, linux
+type Platform struct {
+	gorm.Model
+	Code string `json:"code" gorm:"size:20;uniqueIndex;not null"`
+	Name string `json:"name"`
+}
+
+// ภาษาที่รองรับ (ISO 639-1 เช่น en, th)
+type Language struct {
+	gorm.Model
+	Code string `json:"code" gorm:"size:10;uniqueIndex;not null"`
+	Name string `json:"name"`
+}
+
+const (
+	MediaScreenshot = "screenshot" // รูปจาก /upload/game
+	MediaTrailer    = "trailer"    // ลิงก์วิดีโอภายนอก (http/https)
+)
+
+// แกลเลอรีของเกม เรียงตาม SortOrder
+type GameMedia struct {
+	gorm.Model
+	GameID    uint   `json:"game_id" gorm:"index;not null"`
+	Kind      string `json:"kind" gorm:"type:varchar(20);not null"`
+	URL       string `json:"url" gorm:"type:varchar(512);not null"`
+	Caption   string `json:"caption" gorm:"type:varchar(255)"`
+	SortOrder int    `json:"sort_order"`
+}
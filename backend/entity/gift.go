@@ -0,0 +1,38 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type GiftStatus string
+
+const (
+	GiftPending   GiftStatus = "PENDING"   // รอชำระเงิน
+	GiftDelivered GiftStatus = "DELIVERED" // ชำระแล้ว รอผู้รับกดรับ
+	GiftClaimed   GiftStatus = "CLAIMED"
+	GiftDeclined  GiftStatus = "DECLINED" // ผู้รับปฏิเสธ → คืนเงินผู้ซื้อ
+)
+
+// ของขวัญ: ออเดอร์ที่ซื้อให้ผู้ใช้อื่น (1 ออเดอร์ = 1 ของขวัญ)
+// เกมในออเดอร์จะเข้าคลังผู้รับตอนกดรับ ไม่ใช่ตอนอนุมัติการชำระเงิน
+type Gift struct {
+	gorm.Model
+	OrderID uint   `json:"order_id" gorm:"not null;uniqueIndex"`
+	Order   *Order `json:"order,omitempty" gorm:"foreignKey:OrderID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+
+	SenderID    uint  `json:"sender_id"    gorm:"not null;index"`
+	Sender      *User `json:"sender,omitempty"    gorm:"foreignKey:SenderID"`
+	RecipientID uint  `json:"recipient_id" gorm:"not null;index"`
+	Recipient   *User `json:"recipient,omitempty" gorm:"foreignKey:RecipientID"`
+
+	Message string     `json:"message" gorm:"type:text"`
+	Status  GiftStatus `json:"status"  gorm:"type:varchar(16);index;default:PENDING"`
+
+	DeliveredAt *time.Time `json:"delivered_at"`
+	ClaimedAt   *time.Time `json:"claimed_at"`
+	DeclinedAt  *time.Time `json:"declined_at"`
+
+	RefundRequestID *uint `json:"refund_request_id"`
+}
@@ -34,4 +34,7 @@ type Mod struct {
 
 	ViewCount     int64 `json:"view_count" gorm:"default:0"`
 	DownloadCount int64 `json:"download_count" gorm:"default:0"`
+
+	// ผู้ดูแลซ่อนจากหน้ารวม
+	Hidden bool `json:"hidden" gorm:"not null;default:false;index"`
 }
@@ -0,0 +1,84 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// บันทึกการกระทำของผู้ดูแลต่อเนื้อหาของคนอื่น (แก้/ลบเธรด คอมเมนต์ ฯลฯ)
+// รวมถึง action จากคิวรายงาน (hide/delete/lock/warn/mute/ban) ซึ่งเจ้าของเนื้อหายื่นอุทธรณ์ได้
+type ModerationLog struct {
+	gorm.Model
+	ActorID    uint   `json:"actor_id"    gorm:"index;not null"`
+	Actor      *User  `json:"actor,omitempty" gorm:"foreignKey:ActorID"`
+	TargetType string `json:"target_type" gorm:"type:varchar(20);index:idx_moderation_target,priority:1"`
+	TargetID   uint   `json:"target_id"   gorm:"index:idx_moderation_target,priority:2"`
+	AuthorID   uint   `json:"author_id"   gorm:"index"` // เจ้าของเนื้อหา
+	Action     string `json:"action"      gorm:"type:varchar(30)"`
+	Reason     string `json:"reason"      gorm:"type:text;not null"`
+	Snapshot   string `json:"snapshot"    gorm:"type:text"` // เนื้อหาก่อนถูกแก้/ลบ
+
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"` // mute/ban
+	RestrictionID *uint      `json:"restriction_id,omitempty"`
+	RevertedAt    *time.Time `json:"reverted_at,omitempty"` // อุทธรณ์สำเร็จ → ยกเลิกผลแล้ว
+}
+
+// หมวดของรายงาน
+const (
+	ReportSpam       = "spam"
+	ReportHarassment = "harassment"
+	ReportHate       = "hate"
+	ReportSexual     = "sexual"
+	ReportSpoiler    = "spoiler"
+	ReportMalware    = "malware" // ม็อดที่มีไฟล์อันตราย
+	ReportOther      = "other"
+)
+
+// สถานะรายงาน
+const (
+	ReportOpen      = "open"
+	ReportActioned  = "actioned"
+	ReportDismissed = "dismissed"
+)
+
+// รายงานเนื้อหาจากผู้ใช้ (thread/comment/review/mod)
+type ContentReport struct {
+	gorm.Model
+	ReporterID uint  `json:"reporter_id" gorm:"not null;index"`
+	Reporter   *User `json:"reporter,omitempty" gorm:"foreignKey:ReporterID"`
+
+	TargetType string `json:"target_type" gorm:"type:varchar(20);not null;index:idx_content_report_target,priority:1"`
+	TargetID   uint   `json:"target_id"   gorm:"not null;index:idx_content_report_target,priority:2"`
+	AuthorID   uint   `json:"author_id"   gorm:"index"` // เจ้าของเนื้อหา ณ ตอนรายงาน
+
+	Category string `json:"category" gorm:"type:varchar(20);not null"`
+	Details  string `json:"details"  gorm:"type:text"`
+
+	Status          string     `json:"status" gorm:"type:varchar(16);not null;default:open;index"`
+	ResolvedByID    *uint      `json:"resolved_by_id"`
+	ResolvedAt      *time.Time `json:"resolved_at"`
+	ModerationLogID *uint      `json:"moderation_log_id"` // action ที่ปิดรายงานนี้
+}
+
+// สถานะอุทธรณ์
+const (
+	AppealPending  = "pending"
+	AppealAccepted = "accepted"
+	AppealRejected = "rejected"
+)
+
+// อุทธรณ์ของเจ้าของเนื้อหาต่อ action ของผู้ดูแล (1 ครั้งต่อ action)
+type ModerationAppeal struct {
+	gorm.Model
+	ModerationLogID uint           `json:"moderation_log_id" gorm:"not null;uniqueIndex"`
+	ModerationLog   *ModerationLog `json:"moderation_log,omitempty" gorm:"foreignKey:ModerationLogID"`
+	UserID          uint           `json:"user_id" gorm:"not null;index"`
+	User            *User          `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	Message         string         `json:"message" gorm:"type:text;not null"`
+
+	Status     string     `json:"status" gorm:"type:varchar(16);not null;default:pending;index"`
+	ReviewerID *uint      `json:"reviewer_id"`
+	ReviewedAt *time.Time `json:"reviewed_at"`
+	Response   string     `json:"response" gorm:"type:text"`
+}
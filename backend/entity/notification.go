@@ -16,4 +16,15 @@ type Notification struct {
 	// ✅ อ้างอิงคำร้อง เพื่อให้หน้า UI เปิดดูรายละเอียด/ไฟล์แนบได้
 	ReportID *uint          `json:"report_id"`
 	Report   *ProblemReport `gorm:"foreignKey:ReportID" json:"report,omitempty"`
+
+	// อ้างอิงเกม (แจ้งเตือน wishlist: ราคาลด/วางขาย)
+	GameID *uint `json:"game_id" gorm:"index"`
+
+	// อ้างอิงเนื้อหาคอมมูนิตี้ (แจ้งเตือน @mention)
+	ThreadID  *uint `json:"thread_id"`
+	CommentID *uint `json:"comment_id"`
+	ReviewID  *uint `json:"review_id"`
+
+	// จำนวนเหตุการณ์ที่รวมไว้ในแจ้งเตือนนี้ (เช่น 5 ความเห็นใหม่ในกระทู้เดียวกัน)
+	Count int `json:"count" gorm:"not null;default:1"`
 }
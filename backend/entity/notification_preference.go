@@ -0,0 +1,13 @@
+package entity
+
+import "time"
+
+// ปิด/เปิดแจ้งเตือนรายชนิด (Notification.Type) ไม่มีแถว = เปิด
+type NotificationPreference struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	UserID  uint   `json:"user_id" gorm:"not null;uniqueIndex:ux_notification_pref_user_type,priority:1"`
+	Type    string `json:"type"    gorm:"size:40;not null;uniqueIndex:ux_notification_pref_user_type,priority:2"`
+	Enabled bool   `json:"enabled" gorm:"not null"`
+}
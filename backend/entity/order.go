@@ -25,11 +25,16 @@ type Order struct {
 	OrderCreate time.Time   `json:"order_create"`
 	OrderStatus OrderStatus `json:"order_status" gorm:"type:varchar(32);index"`
 
+	// ภูมิภาค/สกุลเงินตอนสั่งซื้อ (ราคาใน order ทั้งหมดเป็นสกุลนี้)
+	RegionCode string `json:"region_code" gorm:"type:varchar(8)"`
+	Currency   string `json:"currency"    gorm:"type:varchar(3)"`
+
 	UserID uint  `json:"user_id"`
 	User   *User `gorm:"foreignKey:UserID" json:"user,omitempty"`
 
 	OrderItems []OrderItem `gorm:"foreignKey:OrderID" json:"order_items,omitempty"`
 	Payments   []Payment   `gorm:"foreignKey:OrderID" json:"payments,omitempty"`
+	Gift       *Gift       `gorm:"foreignKey:OrderID" json:"gift,omitempty"`
 	// ❌ ลบ KeyGame []KeyGame ที่เคยอยู่บน Order ออก (คีย์ผูกกับ OrderItem)
 }
 
@@ -0,0 +1,52 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type PriceChangeReason string
+
+const (
+	PriceInitial         PriceChangeReason = "INITIAL"          // สร้างเกม/บันทึกครั้งแรก
+	PriceBaseChange      PriceChangeReason = "BASE_PRICE"       // แก้ base_price
+	PriceRegionOverride  PriceChangeReason = "REGION_PRICE"     // แก้ราคาราย region
+	PriceRateChange      PriceChangeReason = "EXCHANGE_RATE"    // แก้อัตราแลกเปลี่ยน/นโยบายปัดเศษ
+	PricePromotionStart  PriceChangeReason = "PROMOTION_START"  // โปรเริ่ม
+	PricePromotionEnd    PriceChangeReason = "PROMOTION_END"    // โปรจบ
+	PricePromotionUpdate PriceChangeReason = "PROMOTION_UPDATE" // แก้/ลบโปร หรือเปลี่ยนเกมในโปร
+	PriceSync            PriceChangeReason = "SYNC"             // ตรวจซ้ำตอนเปิดเซิร์ฟเวอร์
+)
+
+// ประวัติราคาของเกมต่อ region (บันทึกเฉพาะตอนที่ราคาเปลี่ยนจริง)
+// ใช้หา "ราคาต่ำสุดใน 30 วัน" สำหรับแสดงราคาเดิมตอนลดราคา
+type GamePriceHistory struct {
+	gorm.Model
+
+	GameID uint  `json:"game_id" gorm:"not null;index:idx_price_history,priority:1"`
+	Game   *Game `json:"-"       gorm:"foreignKey:GameID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+
+	RegionID uint    `json:"region_id" gorm:"not null;index:idx_price_history,priority:2"`
+	Region   *Region `json:"-"         gorm:"foreignKey:RegionID"`
+
+	EffectiveAt time.Time `json:"effective_at" gorm:"not null;index:idx_price_history,priority:3"`
+	Currency    string    `json:"currency"     gorm:"type:varchar(3)"`
+	BasePrice   float64   `json:"base_price"` // ราคาปกติใน region
+	Price       float64   `json:"price"`      // ราคาสุทธิหลังโปร
+
+	Reason      PriceChangeReason `json:"reason"       gorm:"type:varchar(30)"`
+	PromotionID *uint             `json:"promotion_id"`
+}
+
+// ราคาปัจจุบันของเกมต่อ region (แคชจาก SnapshotGamePrices)
+// ใช้กรอง/เรียงตามราคาใน SQL โดยไม่ต้องคำนวณโปรทุกเกมใน Go
+type GameCurrentPrice struct {
+	GameID   uint `json:"game_id"   gorm:"primaryKey;autoIncrement:false"`
+	RegionID uint `json:"region_id" gorm:"primaryKey;autoIncrement:false"`
+
+	BasePrice float64   `json:"base_price"`
+	Price     float64   `json:"price"   gorm:"index"`
+	OnSale    bool      `json:"on_sale" gorm:"index"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
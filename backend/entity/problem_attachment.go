@@ -13,4 +13,7 @@ type ProblemAttachment struct {
 	// ที่อยู่ไฟล์ + ชื่อไฟล์เดิม (ช่วยให้ UI โชว์ชื่อที่เข้าใจได้)
 	FilePath     string `json:"file_path"     gorm:"size:255;not null"`
 	OriginalName string `json:"original_name" gorm:"size:255"`
+
+	// signed URL สำหรับเปิดไฟล์ (เติมตอนตอบกลับ ไม่เก็บใน DB)
+	URL string `json:"url,omitempty" gorm:"-"`
 }
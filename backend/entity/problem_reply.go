@@ -32,4 +32,7 @@ type ProblemReplyAttachment struct {
 	// ที่อยู่ไฟล์บนเซิร์ฟเวอร์ + ชื่อไฟล์ต้นฉบับ (ใช้โชว์ใน UI)
 	FilePath     string `json:"file_path"     gorm:"size:255;not null"`
 	OriginalName string `json:"original_name" gorm:"size:255"`
+
+	// signed URL สำหรับเปิดไฟล์ (เติมตอนตอบกลับ ไม่เก็บใน DB)
+	URL string `json:"url,omitempty" gorm:"-"`
 }
@@ -1,15 +1,23 @@
 package entity
 
-import (
-	"gorm.io/gorm"
-)
-
+import "time"
 
+// รีแอคชันต่อเนื้อหา (thread/comment/review/mod) แทน ThreadLike / Review_Like เดิม
+// 1 คนกดชนิดเดียวกันกับเนื้อหาเดียวกันได้ครั้งเดียว (ยกเลิก = ลบแถวจริง)
 type Reaction struct {
-	gorm.Model
-	TargetType string `json:"target_type"` // "thread" | "comment" | อื่น ๆ
-	TargetID   uint   `json:"target_id"`
-	Type       string `json:"type"`        // "like", "love", ...
-	UserID     uint   `json:"user_id"`
-	User       *User  `gorm:"foreignKey:UserID" json:"user"`
-}
\ No newline at end of file
+	ID         uint      `json:"ID" gorm:"primarykey"`
+	CreatedAt  time.Time `json:"CreatedAt"`
+	TargetType string    `json:"target_type" gorm:"type:varchar(20);not null;uniqueIndex:uniq_reaction,priority:1"`
+	TargetID   uint      `json:"target_id"   gorm:"not null;uniqueIndex:uniq_reaction,priority:2"`
+	UserID     uint      `json:"user_id"     gorm:"not null;uniqueIndex:uniq_reaction,priority:3;index"`
+	Type       string    `json:"type"        gorm:"type:varchar(20);not null;uniqueIndex:uniq_reaction,priority:4"`
+	User       *User     `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
+
+// จำนวนรีแอคชันแต่ละชนิดของเนื้อหา (อัปเดตใน transaction เดียวกับ Reaction)
+type ReactionCount struct {
+	TargetType string `json:"target_type" gorm:"primaryKey;type:varchar(20)"`
+	TargetID   uint   `json:"target_id"   gorm:"primaryKey"`
+	Type       string `json:"type"        gorm:"primaryKey;type:varchar(20)"`
+	Count      int64  `json:"count"       gorm:"not null;default:0"`
+}
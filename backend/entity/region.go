@@ -0,0 +1,52 @@
+package entity
+
+import "gorm.io/gorm"
+
+type RoundingMode string
+
+const (
+	RoundNone    RoundingMode = "NONE"    // ปัดตามจำนวนทศนิยมของสกุลเงินเท่านั้น
+	RoundNearest RoundingMode = "NEAREST" // ปัดใกล้สุดตาม RoundingStep
+	RoundUp      RoundingMode = "UP"      // ปัดขึ้นตาม RoundingStep
+	RoundCharm   RoundingMode = "CHARM"   // ปัดขึ้นแล้วลบหน่วยย่อยสุด เช่น 10 → 9.99
+)
+
+// สกุลเงิน + นโยบายแปลงค่า/ปัดเศษ (แอดมินเป็นคนตั้ง)
+// RateFromBase = จำนวนหน่วยของสกุลนี้ต่อ 1 หน่วยของสกุลหลัก (THB)
+type Currency struct {
+	gorm.Model
+	Code         string       `json:"code"           gorm:"size:3;uniqueIndex;not null"`
+	Symbol       string       `json:"symbol"         gorm:"size:8"`
+	Decimals     int          `json:"decimals"       gorm:"default:2"`
+	RateFromBase float64      `json:"rate_from_base" gorm:"not null;default:1"`
+	RoundingMode RoundingMode `json:"rounding_mode"  gorm:"type:varchar(20);not null;default:NEAREST"`
+	RoundingStep float64      `json:"rounding_step"  gorm:"default:0"` // 0 = ใช้หน่วยย่อยสุดตาม Decimals
+}
+
+// ภูมิภาคของร้าน (TH, US, EU, ...) ผูกกับสกุลเงินเดียว
+type Region struct {
+	gorm.Model
+	Code      string `json:"code"       gorm:"size:8;uniqueIndex;not null"`
+	Name      string `json:"name"`
+	IsDefault bool   `json:"is_default" gorm:"default:false;index"`
+
+	CurrencyID uint      `json:"currency_id" gorm:"not null;index"`
+	Currency   *Currency `json:"currency"    gorm:"foreignKey:CurrencyID"`
+
+	// ระบบเรตติ้งอายุที่ใช้ในภูมิภาคนี้ (nil = ใช้เรตสูงสุดของเกม)
+	RatingSystemID *uint         `json:"rating_system_id"`
+	RatingSystem   *RatingSystem `json:"rating_system,omitempty" gorm:"foreignKey:RatingSystemID"`
+}
+
+// ราคาที่แอดมินกำหนดเองต่อภูมิภาค (ถ้าไม่มีแถวนี้ จะแปลงจาก Game.BasePrice ตามอัตราแลกเปลี่ยน)
+type GameRegionPrice struct {
+	gorm.Model
+
+	GameID uint  `json:"game_id" gorm:"not null;uniqueIndex:ux_game_region_price,priority:1"`
+	Game   *Game `json:"-"       gorm:"foreignKey:GameID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+
+	RegionID uint    `json:"region_id" gorm:"not null;uniqueIndex:ux_game_region_price,priority:2"`
+	Region   *Region `json:"region"    gorm:"foreignKey:RegionID"`
+
+	Price float64 `json:"price" gorm:"not null"`
+}
@@ -0,0 +1,33 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ขอบเขตการจำกัดสิทธิ์ผู้ใช้
+const (
+	RestrictCommunity = "community" // โพสต์/คอมเมนต์
+	RestrictReviews   = "reviews"
+	RestrictWorkshop  = "workshop" // อัปโหลดม็อด
+	RestrictPurchases = "purchases"
+	RestrictLogin     = "login" // แบน
+)
+
+// การจำกัดสิทธิ์ผู้ใช้ (mute/ban) มีผลจนถึง ExpiresAt (nil = ถาวร) หรือจนถูกยกเลิก
+type UserRestriction struct {
+	gorm.Model
+	UserID uint  `json:"user_id" gorm:"not null;index"`
+	User   *User `json:"user,omitempty" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+
+	Scope     string     `json:"scope" gorm:"type:varchar(20);not null;index"`
+	Reason    string     `json:"reason" gorm:"type:text;not null"`
+	ExpiresAt *time.Time `json:"expires_at"`
+
+	IssuedByID uint  `json:"issued_by_id"`
+	IssuedBy   *User `json:"issued_by,omitempty" gorm:"foreignKey:IssuedByID"`
+
+	LiftedAt   *time.Time `json:"lifted_at"`
+	LiftedByID *uint      `json:"lifted_by_id"`
+}
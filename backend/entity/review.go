@@ -8,7 +8,8 @@ type Review struct {
 
 	ReviewTitle string `json:"review_title" gorm:"type:varchar(255);not null"`
 	ReviewText  string `json:"review_text" gorm:"type:text"`
-	Rating      int    `json:"rating" gorm:"not null"` // 1–5
+	ReviewHTML  string `json:"review_html" gorm:"type:text"` // เรนเดอร์จาก Markdown ตอนบันทึก
+	Rating      int    `json:"rating" gorm:"not null"`       // 1–5
 
 	// เปลี่ยนชื่อ composite unique index -> ux_reviews_user_game
 	UserID uint  `json:"user_id" gorm:"not null;uniqueIndex:ux_reviews_user_game,priority:1"`
@@ -17,7 +18,8 @@ type Review struct {
 	GameID uint  `json:"game_id" gorm:"not null;uniqueIndex:ux_reviews_user_game,priority:2"`
 	Game   *Game `gorm:"foreignKey:GameID" json:"game"`
 
-	Likes []Review_Like `gorm:"foreignKey:ReviewID" json:"likes"`
+	// ผู้ดูแลซ่อนจากหน้ารวม
+	Hidden bool `json:"hidden" gorm:"not null;default:false;index"`
 }
 
 func (Review) TableName() string { return "reviews" }
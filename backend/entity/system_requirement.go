@@ -0,0 +1,46 @@
+package entity
+
+import "gorm.io/gorm"
+
+const (
+	SpecMinimum     = "minimum"
+	SpecRecommended = "recommended"
+)
+
+// ความต้องการระบบของเกม ต่อ OS และระดับ (minimum/recommended)
+// ค่า 0 / "" = ไม่ระบุ (ไม่ใช้ตัดสิน)
+type SystemRequirement struct {
+	gorm.Model
+	GameID uint   `json:"game_id" gorm:"not null;uniqueIndex:ux_game_requirement,priority:1"`
+	OS     string `json:"os"      gorm:"size:20;not null;uniqueIndex:ux_game_requirement,priority:2"` // ตรงกับ Platform.Code
+	Level  string `json:"level"   gorm:"size:20;not null;uniqueIndex:ux_game_requirement,priority:3"`
+
+	CPUTier   int    `json:"cpu_tier"` // ระดับ benchmark 1–10
+	CPUText   string `json:"cpu_text" gorm:"type:varchar(120)"`
+	GPUTier   int    `json:"gpu_tier"`
+	GPUText   string `json:"gpu_text" gorm:"type:varchar(120)"`
+	RAMMB     int    `json:"ram_mb"`
+	VRAMMB    int    `json:"vram_mb"`
+	StorageGB int    `json:"storage_gb"`
+	DirectX   int    `json:"directx"`                        // เช่น 11, 12
+	Vulkan    string `json:"vulkan" gorm:"type:varchar(10)"` // เช่น "1.2"
+	Notes     string `json:"notes" gorm:"type:text"`
+}
+
+// สเปกเครื่องของผู้ใช้ (คนละหนึ่งโปรไฟล์)
+type HardwareProfile struct {
+	gorm.Model
+	UserID uint  `json:"user_id" gorm:"uniqueIndex;not null"`
+	User   *User `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+
+	OS            string `json:"os" gorm:"size:20"`
+	CPUTier       int    `json:"cpu_tier"`
+	CPUText       string `json:"cpu_text" gorm:"type:varchar(120)"`
+	GPUTier       int    `json:"gpu_tier"`
+	GPUText       string `json:"gpu_text" gorm:"type:varchar(120)"`
+	RAMMB         int    `json:"ram_mb"`
+	VRAMMB        int    `json:"vram_mb"`
+	FreeStorageGB int    `json:"free_storage_gb"`
+	DirectX       int    `json:"directx"`
+	Vulkan        string `json:"vulkan" gorm:"type:varchar(10)"`
+}
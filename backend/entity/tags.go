@@ -5,6 +5,7 @@ import "gorm.io/gorm"
 type Tags struct {
 	gorm.Model
 	Title string `json:"title"`
+	Slug  string `json:"slug" gorm:"size:96;uniqueIndex"`
 
 	ModTags []ModTags `gorm:"foreignKey:TagID" json:"mod_tags"`
 }
@@ -8,6 +8,7 @@ type Thread struct {
 	gorm.Model
 	Title        string        `json:"title"`
 	Content      string        `json:"content" gorm:"type:text"`
+	ContentHTML  string        `json:"content_html" gorm:"type:text"` // เรนเดอร์จาก Markdown ตอนบันทึก
 	GameID       uint          `json:"game_id" gorm:"not null"`
 	Game         Game          `json:"game"`
 	UserID       uint          `json:"user_id" gorm:"not null"`
@@ -16,4 +17,18 @@ type Thread struct {
 	LikeCount    int64         `json:"like_count" gorm:"default:0"`
 	CommentCount int64         `json:"comment_count" gorm:"default:0"`
 	ThreadImages []ThreadImage `json:"images"`
+
+	// ผู้ดูแล: ซ่อนจากหน้ารวม / ล็อกไม่ให้คอมเมนต์เพิ่ม
+	Hidden bool `json:"hidden" gorm:"not null;default:false;index"`
+	Locked bool `json:"locked" gorm:"not null;default:false"`
+
+	// ปักหมุดไว้บนสุดของหน้ารวม
+	Pinned   bool       `json:"pinned" gorm:"not null;default:false;index"`
+	PinnedAt *time.Time `json:"pinned_at"`
+
+	FlairID *uint        `json:"flair_id" gorm:"index"`
+	Flair   *ThreadFlair `json:"flair,omitempty" gorm:"foreignKey:FlairID"`
+
+	// เวลาโพสต์/คอมเมนต์ล่าสุด (เรียงตาม activity)
+	LastActivityAt *time.Time `json:"last_activity_at" gorm:"index"`
 }
@@ -0,0 +1,12 @@
+package entity
+
+import "gorm.io/gorm"
+
+// ป้ายกำกับกระทู้ของแต่ละเกม (เช่น guide, bug, discussion)
+type ThreadFlair struct {
+	gorm.Model
+	GameID uint   `json:"game_id" gorm:"not null;uniqueIndex:idx_thread_flair_game_slug,priority:1"`
+	Slug   string `json:"slug"    gorm:"type:varchar(40);not null;uniqueIndex:idx_thread_flair_game_slug,priority:2"`
+	Name   string `json:"name"    gorm:"type:varchar(60);not null"`
+	Color  string `json:"color"   gorm:"type:varchar(16)"`
+}
@@ -0,0 +1,17 @@
+package entity
+
+import "time"
+
+// ผู้ติดตามกระทู้ (ได้แจ้งเตือนเมื่อมีความเห็นใหม่)
+// ตั้งกระทู้/แสดงความเห็น = ติดตามอัตโนมัติ, เลิกติดตามเองแล้วจะเก็บแถวไว้ (Following = false) ไม่ถูกติดตามอัตโนมัติซ้ำ
+type ThreadSubscription struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	UserID   uint    `json:"user_id"   gorm:"not null;uniqueIndex:ux_thread_sub_user_thread,priority:1"`
+	ThreadID uint    `json:"thread_id" gorm:"not null;uniqueIndex:ux_thread_sub_user_thread,priority:2;index"`
+	Thread   *Thread `json:"thread,omitempty" gorm:"foreignKey:ThreadID"`
+
+	Following bool `json:"following" gorm:"not null"`
+}
@@ -0,0 +1,23 @@
+package entity
+
+import "gorm.io/gorm"
+
+// ไฟล์ใน storage ที่ผู้ใช้อัปโหลด (1 แถวต่อ 1 key รวม thumbnail/webp) ใช้คิดพื้นที่ต่อผู้ใช้และ quota
+// รูปที่ dedupe ด้วย hash นับให้ผู้ที่อัปโหลดคนแรก
+type UploadObject struct {
+	gorm.Model
+	Key         string `json:"key"          gorm:"size:512;not null;uniqueIndex"`
+	UserID      uint   `json:"user_id"      gorm:"index"` // 0 = ระบบ/ไม่ทราบเจ้าของ
+	Size        int64  `json:"size"`
+	ContentType string `json:"content_type" gorm:"size:128"`
+}
+
+// โควต้าพื้นที่เฉพาะราย (ไม่มีแถว = ใช้ค่าเริ่มต้นจาก UPLOAD_QUOTA_BYTES)
+type StorageQuota struct {
+	gorm.Model
+	UserID     uint  `json:"user_id"     gorm:"not null;uniqueIndex"`
+	User       *User `json:"-"           gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	QuotaBytes int64 `json:"quota_bytes"` // 0 = ไม่จำกัด
+}
+
+func (StorageQuota) TableName() string { return "storage_quotas" }
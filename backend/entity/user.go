@@ -15,6 +15,9 @@ type User struct {
     LastName  string    `json:"last_name"`
     Birthday  time.Time `json:"birthday"`
 
+    // ภูมิภาคร้านค้าที่ผู้ใช้เลือก (ใช้กำหนดสกุลเงิน/ราคา) ว่าง = ภูมิภาคค่าเริ่มต้น
+    RegionCode string `gorm:"size:8" json:"region_code"`
+
     RoleID uint `gorm:"not null;index" json:"role_id"`
     Role   Role `gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;" json:"role"` // กันลบ role ที่ถูกอ้างอิง
 
@@ -26,8 +29,7 @@ type User struct {
 	Notifications []Notification `gorm:"foreignKey:UserID" json:"notifications,omitempty"`
 	UserGames     []UserGame     `gorm:"foreignKey:UserID" json:"user_games,omitempty"`
 
-	Reviews     []Review      `gorm:"foreignKey:UserID" json:"reviews,omitempty"`
-	ReviewLikes []Review_Like `gorm:"foreignKey:UserID" json:"review_likes,omitempty"`
+	Reviews []Review `gorm:"foreignKey:UserID" json:"reviews,omitempty"`
 
 	Promotions []Promotion `json:"promotions,omitempty" gorm:"foreignKey:UserID"`
 	Requests   []Request   `json:"request" gorm:"foreignKey:UserRefer"`
@@ -0,0 +1,29 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// เกมที่ผู้ใช้อยากได้ (1 แถวต่อผู้ใช้ต่อเกม) เรียงตาม Position
+type WishlistItem struct {
+	gorm.Model
+	UserID uint  `json:"user_id" gorm:"not null;uniqueIndex:ux_wishlist_user_game,priority:1"`
+	User   *User `json:"-"       gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	GameID uint  `json:"game_id" gorm:"not null;uniqueIndex:ux_wishlist_user_game,priority:2;index"`
+	Game   *Game `json:"game,omitempty" gorm:"foreignKey:GameID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+
+	Position int    `json:"position"`
+	Note     string `json:"note" gorm:"type:text"`
+
+	// ราคาตอนเพิ่ม (สกุลของ region ผู้ใช้) ใช้โชว์ว่าลดไปเท่าไร
+	AddedPrice float64 `json:"added_price"`
+
+	// กันแจ้งเตือนซ้ำ: แจ้งราคาลดเมื่อราคาต่ำกว่า BaselinePrice เท่านั้น
+	// แจ้งแล้ว baseline = ราคาที่แจ้ง, ราคากลับขึ้น baseline ก็ขึ้นตาม
+	BaselinePrice     float64    `json:"-"`
+	LastNotifiedPrice *float64   `json:"-"`
+	LastNotifiedAt    *time.Time `json:"-"`
+	ReleaseNotifiedAt *time.Time `json:"-"`
+}
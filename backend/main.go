@@ -1,14 +1,18 @@
 package main
 
 import (
+	"log"
 	"net/http"
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"example.com/sa-gameshop/configs"
 	"example.com/sa-gameshop/controllers"
 	"example.com/sa-gameshop/entity"
+	"example.com/sa-gameshop/middlewares"
+	"example.com/sa-gameshop/services"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
@@ -21,11 +25,34 @@ func main() {
 	configs.SetupDatabase()
 	configs.MigrateReportTables() // ✅ เพิ่มบรรทัดนี้เท่านั้น
 
+	// MinimumSpec เดิม → system requirements (เฉพาะเกมที่ยังไม่มี)
+	if err := services.BackfillRequirements(configs.DB()); err != nil {
+		log.Println("backfill requirements error:", err)
+	}
+
+	// บันทึกประวัติราคาเมื่อโปรโมชันเริ่ม/จบ
+	services.StartPriceHistoryWatcher(configs.DB(), time.Minute)
+
+	// publish เกมที่ตั้งวันวางขายไว้เมื่อถึงเวลา
+	services.StartGameReleaseScheduler(configs.DB(), time.Minute)
+
+	// ลบแจ้งเตือนที่เกินระยะเก็บ (NOTIFICATION_*_RETENTION_DAYS)
+	services.StartNotificationCleanup(configs.DB(), 6*time.Hour)
+
+	// ดัชนีค้นหา (FTS5 ต้อง build ด้วย -tags sqlite_fts5 — ใช้ make run/build ใน backend/Makefile)
+	services.SetupSearchIndex(configs.DB())
+
+	// ที่เก็บไฟล์อัปโหลด (STORAGE_DRIVER=local|s3)
+	if err := services.SetupStorage(); err != nil {
+		log.Fatal("storage: ", err)
+	}
+
 	r := gin.New()
 
 	// 2) Static & CORS
 	r.Use(gin.Logger(), gin.Recovery(), CORSMiddleware())
-	r.Static("/uploads", "./uploads")
+	r.GET("/uploads/*filepath", controllers.ServeUpload)
+	r.HEAD("/uploads/*filepath", controllers.ServeUpload)
 
 	// 3) health check
 	r.GET("/ping", func(c *gin.Context) { c.String(http.StatusOK, "pong") })
@@ -66,16 +93,29 @@ func main() {
 		router.DELETE("/rolepermissions/:id", controllers.DeleteRolePermission)
 
 		// -------- Games --------
-		router.POST("/new-game", controllers.CreateGame)
 		router.GET("/game", controllers.FindGames)
 		router.GET("/games/:id", controllers.FindGameByID)
-		router.PUT("/update-game/:id", controllers.UpdateGamebyID)
-		router.POST("/upload/game", controllers.UploadGame) // ลงทะเบียนครั้งเดียว
+		router.GET("/games/:id/prices", controllers.FindGamePrices)
+		router.GET("/games/:id/price-history", controllers.FindGamePriceHistory)
+		router.GET("/search", controllers.Search)
+		router.GET("/games/:id/requirements", controllers.FindGameRequirements)
+		router.GET("/games/:id/compatibility", controllers.CheckGameCompatibility)
+		router.GET("/genres", controllers.FindGenres)
+		router.GET("/platforms", controllers.FindPlatforms)
+		router.GET("/languages", controllers.FindLanguages)
+		router.GET("/companies", controllers.FindCompanies)
+		router.GET("/rating-systems", controllers.FindRatingSystems)
+
+		// -------- Regions / Currencies --------
+		router.GET("/regions", controllers.FindRegions)
+		router.GET("/currencies", controllers.FindCurrencies)
 
 		// -------- Threads (READ only = public) --------
 		router.GET("/threads", controllers.FindThreads)                       // ?game_id=&q=
 		router.GET("/threads/:id", controllers.FindThreadByID)                // รายละเอียดเธรด
-		router.GET("/threads/:id/comments", controllers.FindCommentsByThread) // คอมเมนต์แบบแถวเดียว
+		router.GET("/threads/:id/comments", controllers.FindCommentsByThread) // ?format=flat|tree
+		router.GET("/games/:id/flairs", controllers.FindGameFlairs)
+		router.GET("/comments/:id/revisions", controllers.FindCommentRevisions)
 
 		// -------- UserGames --------
 		router.POST("/user-games", controllers.CreateUserGame)
@@ -85,47 +125,30 @@ func main() {
 		router.DELETE("/user-games/:id", controllers.DeleteUserGameByID)
 
 		// -------- Reactions --------
-		router.POST("/reactions", controllers.CreateReaction)
-		router.GET("/reactions", controllers.FindReactions) // ?target_type=&target_id=&user_id=
+		router.GET("/reactions", controllers.FindReactions) // ?target_type=&target_id=&user_id=&type=
+		router.GET("/reactions/types", controllers.FindReactionTypes)
+		router.GET("/reactions/summary", controllers.FindReactionSummaries) // ?target_type=&target_ids=1,2
 		router.GET("/reactions/:id", controllers.FindReactionByID)
-		router.PUT("/reactions/:id", controllers.UpdateReaction)
-		router.DELETE("/reactions/:id", controllers.DeleteReactionByID)
 
 		// -------- Attachments --------
-		router.POST("/attachments", controllers.CreateAttachment)
 		router.GET("/attachments", controllers.FindAttachments) // ?target_type=&target_id=&user_id=
 		router.GET("/attachments/:id", controllers.FindAttachmentByID)
-		router.PUT("/attachments/:id", controllers.UpdateAttachment)
-		router.DELETE("/attachments/:id", controllers.DeleteAttachmentByID)
-
-		// -------- Notifications --------
-		router.POST("/notifications", controllers.CreateNotification)
-		router.GET("/notifications", controllers.FindNotifications) // ?user_id=
-		router.GET("/notifications/:id", controllers.FindNotificationByID)
-		router.PUT("/notifications/:id/read", controllers.MarkNotificationRead)
-		router.PUT("/notifications/read-all", controllers.MarkAllNotificationsRead)
-		router.DELETE("/notifications/:id", controllers.DeleteNotificationByID)
 
 		// -------- Promotions --------
-		router.POST("/promotions", controllers.CreatePromotion)
 		router.GET("/promotions", controllers.FindPromotions)
 		router.GET("/promotions/:id", controllers.GetPromotionByID)
-		router.PUT("/promotions/:id", controllers.UpdatePromotion)
-		router.DELETE("/promotions/:id", controllers.DeletePromotion)
-		router.POST("/promotions/:id/games", controllers.SetPromotionGames)
 		router.GET("/promotions-active", controllers.FindActivePromotions)
 
 		// -------- Reviews --------
-		router.POST("/reviews", controllers.CreateReview)
 		router.GET("/reviews", controllers.FindReviews)
 		router.GET("/reviews/:id", controllers.GetReviewByID)
-		router.PUT("/reviews/:id", controllers.UpdateReview)
-		router.DELETE("/reviews/:id", controllers.DeleteReview)
-		router.POST("/reviews/:id/toggle_like", controllers.ToggleReviewLike)
 		router.GET("/games/:id/reviews", controllers.FindReviewsByGame)
 
 		// -------- Categories --------
-		router.GET("/categories", controllers.FindCategories)
+		router.GET("/categories", controllers.FindCategories) // ?tree=true
+		router.GET("/categories/:id", controllers.FindCategoryByID)
+		router.GET("/tags", controllers.GetTags)
+		router.GET("/tags/:id", controllers.GetTagById)
 
 		// -------- KeyGames --------
 		router.POST("/keygames", controllers.CreateKeyGame)
@@ -136,18 +159,6 @@ func main() {
 		router.POST("/new-minimumspec", controllers.CreateMinimumSpec)
 		router.GET("/minimumspec", controllers.FindMinimumSpec)
 
-		// -------- Problem Reports --------
-		router.POST("/reports", controllers.CreateReport)
-		router.GET("/reports", controllers.FindReports)
-		router.GET("/reports/:id", controllers.GetReportByID)
-		router.PUT("/reports/:id", controllers.UpdateReport)
-		router.DELETE("/reports/:id", controllers.DeleteReport)
-		// เปลี่ยน handler ให้รองรับตาราง Reply/ReplyAttachment
-		router.POST("/reports/:id/reply", controllers.ReplyReport)
-		// เพิ่มเส้นทางสำหรับแอดมิน (ตอบกลับ/ปิดงาน)
-		router.POST("/admin/reports/:id/replies", controllers.AdminCreateReply)
-		router.PATCH("/admin/reports/:id/resolve", controllers.AdminResolveReport)
-
 		// -------- Requests --------
 		router.POST("/new-request", controllers.CreateRequest)
 		router.GET("/request", controllers.FindRequest)
@@ -167,7 +178,27 @@ func main() {
 	}
 
 	// 5) เส้นทางที่ต้อง Auth (แนบ Bearer หรือ X-User-ID)
-	authList := r.Group("/", AuthRequired())
+	// ผู้ถูกแบนยังดู/อุทธรณ์การจำกัดสิทธิ์ของตัวเองได้
+	restricted := r.Group("/", AuthRequired())
+	{
+		restricted.GET("/restrictions/mine", controllers.FindMyRestrictions)
+		restricted.POST("/moderation/logs/:id/appeal", controllers.CreateModerationAppeal) // เจ้าของเนื้อหาเท่านั้น
+		restricted.GET("/moderation/appeals/mine", controllers.FindMyModerationAppeals)
+
+		// -------- Notifications (ของผู้ใช้จาก token เท่านั้น) --------
+		restricted.GET("/notifications", controllers.FindNotifications) // ?limit=&cursor=&type=&unread=1
+		restricted.GET("/notifications/unread-count", controllers.CountUnreadNotifications)
+		restricted.GET("/notifications/:id", controllers.FindNotificationByID)
+		restricted.PUT("/notifications/:id/read", controllers.MarkNotificationRead)
+		restricted.PUT("/notifications/read-all", controllers.MarkAllNotificationsRead) // ?type=
+		restricted.POST("/notifications/bulk", controllers.BulkUpdateNotifications)
+		restricted.DELETE("/notifications/:id", controllers.DeleteNotificationByID)
+	}
+
+	// แจ้งเตือนแบบสด (SSE) — EventSource ใส่ header เองไม่ได้ จึงรับ token ทาง ?access_token= ด้วย
+	r.GET("/notifications/stream", TokenFromQuery(), AuthRequired(), middlewares.RequireNotRestricted(entity.RestrictLogin), controllers.StreamNotifications)
+
+	authList := r.Group("/", AuthRequired(), middlewares.RequireNotRestricted(entity.RestrictLogin))
 	{
 		// ฉีด user_id อัตโนมัติให้ GET /orders และ GET /payments
 		withUserQuery := authList.Group("/", InjectUserIDQuery())
@@ -177,16 +208,16 @@ func main() {
 		}
 
 		// Orders (write)
-		authList.POST("/orders", controllers.CreateOrder)
+		authList.POST("/orders", middlewares.RequireNotRestricted(entity.RestrictPurchases), controllers.CreateOrder)
 
 		// Order Items
-		authList.POST("/order-items", controllers.CreateOrderItem)
+		authList.POST("/order-items", middlewares.RequireNotRestricted(entity.RestrictPurchases), controllers.CreateOrderItem)
 		authList.GET("/order-items", controllers.FindOrderItems)
 		authList.PUT("/order-items/:id/qty", controllers.UpdateOrderItemQty)
 		authList.DELETE("/order-items/:id", controllers.DeleteOrderItem)
 
 		// Payments (write/action)
-		authList.POST("/payments", controllers.CreatePayment)
+		authList.POST("/payments", middlewares.RequireNotRestricted(entity.RestrictPurchases), controllers.CreatePayment)
 		authList.PATCH("/payments/:id", controllers.UpdatePayment)
 		authList.POST("/payments/:id/approve", controllers.ApprovePayment) // ตรวจ role ใน handler
 		authList.POST("/payments/:id/reject", controllers.RejectPayment)
@@ -196,8 +227,63 @@ func main() {
 		authList.PUT("/threads/:id", controllers.UpdateThread) // แก้ title/content
 		authList.DELETE("/threads/:id", controllers.DeleteThread)
 		authList.POST("/threads/:id/comments", controllers.CreateComment)
+		authList.PATCH("/comments/:id", controllers.UpdateComment)
 		authList.DELETE("/comments/:id", controllers.DeleteComment)
 		authList.POST("/threads/:id/toggle_like", controllers.ToggleThreadLike)
+		authList.POST("/threads/:id/follow", controllers.FollowThread)
+		authList.DELETE("/threads/:id/follow", controllers.UnfollowThread)
+		authList.GET("/thread-subscriptions", controllers.FindMyThreadSubscriptions)
+		authList.POST("/threads/:id/pin", middlewares.RequirePermission(services.PermCommunityModerate), controllers.SetThreadPinned)
+		authList.POST("/threads/:id/lock", middlewares.RequirePermission(services.PermCommunityModerate), controllers.SetThreadLocked)
+		authList.POST("/games/:id/flairs", middlewares.RequirePermission(services.PermCommunityModerate), controllers.CreateGameFlair)
+		authList.DELETE("/thread-flairs/:id", middlewares.RequirePermission(services.PermCommunityModerate), controllers.DeleteThreadFlair)
+
+		// -------- Reviews (WRITE = ผู้เขียนจาก token) --------
+		authList.POST("/reviews", controllers.CreateReview)
+		authList.PUT("/reviews/:id", controllers.UpdateReview)    // เจ้าของ หรือ reviews.moderate + reason
+		authList.DELETE("/reviews/:id", controllers.DeleteReview) // ?reason=
+		authList.POST("/reviews/:id/toggle_like", controllers.ToggleReviewLike)
+
+		// -------- Reactions / Attachments (ผู้ใช้จาก token เท่านั้น) --------
+		authList.POST("/reactions", controllers.CreateReaction)
+		authList.POST("/reactions/toggle", controllers.ToggleReaction)
+		authList.PUT("/reactions/:id", controllers.UpdateReaction)
+		authList.DELETE("/reactions/:id", controllers.DeleteReactionByID)
+		authList.POST("/attachments", controllers.CreateAttachment)
+		authList.PUT("/attachments/:id", controllers.UpdateAttachment)
+		authList.DELETE("/attachments/:id", controllers.DeleteAttachmentByID)
+		// แก้/ลบของคนอื่นต้องมี community.moderate + reason (บันทึกใน moderation log)
+		authList.GET("/moderation/logs", middlewares.RequirePermission("community.moderate"), controllers.FindModerationLogs)
+
+		// -------- Problem Reports (เจ้าของคำร้อง หรือ staff ที่มี reports.read/manage) --------
+		authList.POST("/reports", controllers.CreateReport)
+		authList.GET("/reports", controllers.FindReports)
+		authList.GET("/reports/:id", controllers.GetReportByID)
+		reportAdmin := authList.Group("/", middlewares.RequirePermission("reports.manage"))
+		{
+			reportAdmin.PUT("/reports/:id", controllers.UpdateReport)
+			reportAdmin.DELETE("/reports/:id", controllers.DeleteReport)
+			// เปลี่ยน handler ให้รองรับตาราง Reply/ReplyAttachment
+			reportAdmin.POST("/reports/:id/reply", controllers.ReplyReport)
+			// เพิ่มเส้นทางสำหรับแอดมิน (ตอบกลับ/ปิดงาน)
+			reportAdmin.POST("/admin/reports/:id/replies", controllers.AdminCreateReply)
+			reportAdmin.PATCH("/admin/reports/:id/resolve", controllers.AdminResolveReport)
+		}
+
+		// -------- รายงานเนื้อหา / คิวผู้ดูแล / อุทธรณ์ --------
+		authList.POST("/content-reports", controllers.CreateContentReport)
+		authList.GET("/content-reports/mine", controllers.FindMyContentReports)
+		moderation := authList.Group("/moderation", middlewares.RequirePermission(services.ModerationPerms...))
+		{
+			moderation.GET("/queue", controllers.FindModerationQueue) // ?target_type=&status=
+			moderation.POST("/actions", controllers.TakeModerationAction)
+			moderation.GET("/appeals", controllers.FindModerationAppeals)
+			moderation.POST("/appeals/:id/resolve", controllers.ResolveModerationAppeal)
+		}
+		// จำกัดสิทธิ์ผู้ใช้ (ตรวจสิทธิ์ตามขอบเขตใน handler)
+		authList.GET("/users/:id/restrictions", middlewares.RequirePermission(services.RestrictionViewPerms...), controllers.FindUserRestrictions)
+		authList.POST("/users/:id/restrictions", middlewares.RequirePermission(services.RestrictionViewPerms...), controllers.CreateUserRestriction)
+		authList.POST("/restrictions/:id/lift", middlewares.RequirePermission(services.RestrictionViewPerms...), controllers.LiftUserRestriction)
 
 		authList.GET("/orders/:id/keys", controllers.FindOrderKeys)
 		authList.POST("/orders/:id/keys/:key_id/reveal", controllers.RevealOrderKey)
@@ -207,8 +293,85 @@ func main() {
 		authList.POST("/mods", controllers.CreateMod)
 		authList.PATCH("/mods/:id", controllers.UpdateMod)
 		authList.DELETE("/mods/:id", controllers.DeleteMod)
+		authList.PUT("/mods/:id/file", controllers.ReplaceModFile)
+		authList.PUT("/mods/:id/image", controllers.ReplaceModImage)
 		authList.GET("/mods/mine", controllers.GetMyMods)
 
+		// -------- พื้นที่อัปโหลด (quota / เก็บกวาดไฟล์กำพร้า) --------
+		authList.GET("/storage/usage", controllers.GetMyStorageUsage)
+		storageAdmin := authList.Group("/admin/storage", middlewares.RequirePermission("users.manage"))
+		{
+			storageAdmin.GET("/usage", controllers.FindStorageUsage)
+			storageAdmin.PUT("/quotas/:user_id", controllers.SetStorageQuota)
+			storageAdmin.POST("/gc", controllers.RunUploadGC)
+		}
+
+		// -------- ของขวัญ (สั่งซื้อผ่าน POST /orders {"gift": {...}}) --------
+		authList.GET("/gifts", controllers.FindMyGifts)
+		authList.POST("/gifts/:id/claim", controllers.ClaimGift)
+		authList.POST("/gifts/:id/decline", controllers.DeclineGift)
+
+		// -------- Wishlist (แจ้งเตือนราคาลด/วางขาย) --------
+		authList.GET("/wishlist", controllers.FindMyWishlist)
+		authList.POST("/wishlist", controllers.AddWishlistItem)
+		authList.PUT("/wishlist/order", controllers.ReorderWishlist)
+		authList.PATCH("/wishlist/:game_id", controllers.UpdateWishlistItem)
+		authList.DELETE("/wishlist/:game_id", controllers.RemoveWishlistItem)
+
+		// ประกาศแจ้งเตือนระบบ (ทุกคน หรือเฉพาะ role)
+		authList.POST("/notifications", middlewares.RequirePermission("users.manage"), controllers.CreateNotification)
+
+		// -------- ตั้งค่าการแจ้งเตือน (เปิด/ปิดรายชนิด) --------
+		authList.GET("/notification-preferences", controllers.FindNotificationPreferences)
+		authList.PUT("/notification-preferences", controllers.UpdateNotificationPreferences)
+
+		// -------- Hardware profile (ใช้เช็คสเปกกับเกม) --------
+		authList.GET("/hardware-profile", controllers.GetHardwareProfile)
+		authList.PUT("/hardware-profile", controllers.SaveHardwareProfile)
+
+		// -------- Game publishing workflow --------
+		authList.POST("/games/:id/status", controllers.ChangeGameStatus)
+		authList.GET("/games/:id/status-logs", middlewares.RequirePermission("games.manage"), controllers.FindGameStatusLogs)
+
+		// -------- Game metadata (ต้องมีสิทธิ์ games.manage) --------
+		catalog := authList.Group("/", middlewares.RequirePermission("games.manage"))
+		{
+			catalog.POST("/new-game", controllers.CreateGame)
+			catalog.PUT("/update-game/:id", controllers.UpdateGamebyID)
+			catalog.POST("/upload/game", controllers.UploadGame)
+			catalog.POST("/games/:id/media", controllers.AddGameMedia)
+			catalog.DELETE("/games/:id/media/:media_id", controllers.DeleteGameMedia)
+			catalog.PUT("/games/:id/requirements", controllers.SetGameRequirements)
+			catalog.POST("/genres", controllers.CreateGenre)
+			catalog.POST("/platforms", controllers.CreatePlatform)
+			catalog.POST("/languages", controllers.CreateLanguage)
+			catalog.POST("/companies", controllers.CreateCompany)
+			catalog.PUT("/games/:id/ratings", controllers.SetGameRatings)
+			catalog.POST("/categories", controllers.CreateCategory)
+			catalog.PATCH("/categories/:id", controllers.UpdateCategory)
+			catalog.DELETE("/categories/:id", controllers.DeleteCategory) // ?move_to=
+			catalog.POST("/categories/:id/merge", controllers.MergeCategory)
+			catalog.POST("/tags", controllers.CreateTag)
+			catalog.PATCH("/tags/:id", controllers.UpdateTag)
+			catalog.DELETE("/tags/:id", controllers.DeleteTag)
+			catalog.POST("/tags/:id/merge", controllers.MergeTag)
+			catalog.POST("/rating-systems", controllers.CreateRatingSystem)
+			catalog.POST("/rating-systems/:id/levels", controllers.CreateRatingLevel)
+		}
+
+		// -------- Regional pricing / โปรโมชัน (ต้องมีสิทธิ์ games.manage) --------
+		pricing := authList.Group("/", middlewares.RequirePermission("games.manage"))
+		{
+			pricing.POST("/regions", controllers.CreateRegion)
+			pricing.PUT("/regions/:id", controllers.UpdateRegion)
+			pricing.POST("/currencies", controllers.CreateCurrency)
+			pricing.PUT("/currencies/:id", controllers.UpdateCurrency)
+			pricing.PUT("/games/:id/prices", controllers.SetGamePrices)
+			pricing.POST("/promotions", controllers.CreatePromotion)
+			pricing.PUT("/promotions/:id", controllers.UpdatePromotion)
+			pricing.DELETE("/promotions/:id", controllers.DeletePromotion)
+			pricing.POST("/promotions/:id/games", controllers.SetPromotionGames)
+		}
 	}
 
 	// 6) Run server
@@ -222,8 +385,9 @@ func CORSMiddleware() gin.HandlerFunc {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "http://localhost:5173")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
 		c.Writer.Header().Set("Access-Control-Allow-Headers",
-			"Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-User-ID")
+			"Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-User-ID, Last-Event-ID")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE, PATCH")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "X-Next-Cursor")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(http.StatusNoContent)
@@ -308,6 +472,16 @@ func AuthRequired() gin.HandlerFunc {
 	}
 }
 
+// TokenFromQuery: ?access_token= → Authorization: Bearer (เฉพาะเส้นทางที่ส่ง header ไม่ได้ เช่น EventSource)
+func TokenFromQuery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if t := c.Query("access_token"); t != "" && c.GetHeader("Authorization") == "" {
+			c.Request.Header.Set("Authorization", "Bearer "+t)
+		}
+		c.Next()
+	}
+}
+
 // รองรับ role_id ที่อาจประกาศเป็น pointer หรือไม่เป็น
 func getRoleField(u entity.User) interface{} { return any(u.RoleID) }
 
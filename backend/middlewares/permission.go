@@ -0,0 +1,26 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"example.com/sa-gameshop/configs"
+	"example.com/sa-gameshop/services"
+	"github.com/gin-gonic/gin"
+)
+
+// RequirePermission: ใช้หลัง AuthRequired
+// ผ่านได้เมื่อ role ของผู้ใช้มี permission key อย่างน้อยหนึ่งตัวในรายการ
+func RequirePermission(keys ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid := c.GetUint("userID")
+		if uid == 0 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		if !services.UserHasPermission(configs.DB(), uid, keys...) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+			return
+		}
+		c.Next()
+	}
+}
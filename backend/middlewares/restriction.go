@@ -0,0 +1,26 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"example.com/sa-gameshop/configs"
+	"example.com/sa-gameshop/services"
+	"github.com/gin-gonic/gin"
+)
+
+// RequireNotRestricted: ใช้หลัง AuthRequired
+// ผู้ใช้ที่ถูกจำกัดสิทธิ์ในขอบเขตนี้ (หรือถูกแบน) ได้ 403 พร้อมรายละเอียด
+func RequireNotRestricted(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r, err := services.CheckRestriction(configs.DB(), c.GetUint("userID"), scope)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if r != nil {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "account restricted", "restriction": r})
+			return
+		}
+		c.Next()
+	}
+}
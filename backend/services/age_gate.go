@@ -0,0 +1,122 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"time"
+
+	"example.com/sa-gameshop/entity"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrAgeRestricted = errors.New("age restricted")
+	ErrAgeUnverified = errors.New("birthday required for age-restricted content")
+)
+
+// อายุสูงสุดของเนื้อหาที่ผู้ใช้ไม่ระบุตัว/ไม่มีวันเกิดดูได้ (ตั้งผ่าน AGE_GATE_UNVERIFIED_MAX)
+func UnverifiedMaxAge() int {
+	if v, err := strconv.Atoi(os.Getenv("AGE_GATE_UNVERIFIED_MAX")); err == nil && v >= 0 {
+		return v
+	}
+	return 12
+}
+
+// AgeOn อายุเต็มปี ณ วันที่ now (-1 = ไม่มีวันเกิด)
+func AgeOn(birthday, now time.Time) int {
+	if birthday.IsZero() || birthday.After(now) {
+		return -1
+	}
+	age := now.Year() - birthday.Year()
+	// ยังไม่ถึงวันเกิดปีนี้ (เทียบเดือน/วัน ไม่ใช้ YearDay เพราะปีอธิกสุรทิน)
+	if now.Month() < birthday.Month() || (now.Month() == birthday.Month() && now.Day() < birthday.Day()) {
+		age--
+	}
+	return age
+}
+
+// Viewer คนที่กำลังดู/ซื้อ — Age = -1 เมื่อไม่ทราบ
+type Viewer struct {
+	UserID uint
+	Age    int
+}
+
+// MaxAllowedAge อายุขั้นต่ำสูงสุดของเนื้อหาที่ viewer เข้าถึงได้
+func (v Viewer) MaxAllowedAge() int {
+	if v.Age < 0 {
+		return UnverifiedMaxAge()
+	}
+	return v.Age
+}
+
+// ViewerFor อ่านวันเกิดจากฐานข้อมูล (ไม่เชื่อค่าจาก client)
+func ViewerFor(db *gorm.DB, userID uint) Viewer {
+	v := Viewer{UserID: userID, Age: -1}
+	if userID == 0 {
+		return v
+	}
+	var u entity.User
+	if err := db.Select("id, birthday").First(&u, userID).Error; err == nil {
+		v.Age = AgeOn(u.Birthday, time.Now())
+	}
+	return v
+}
+
+// GameMinAgeSQL นิพจน์ SQL อายุขั้นต่ำของเกม (อ้าง games.id)
+// ลำดับ: เรตในระบบของ region → เรตสูงสุดจากทุกระบบ → games.age_rating
+func GameMinAgeSQL(region entity.Region) (string, []interface{}) {
+	var sysID uint
+	if region.RatingSystemID != nil {
+		sysID = *region.RatingSystemID
+	}
+	return `COALESCE(
+		(SELECT rl.min_age FROM game_ratings gr JOIN rating_levels rl ON rl.id = gr.rating_level_id
+		  WHERE gr.game_id = games.id AND gr.rating_system_id = ? AND gr.deleted_at IS NULL),
+		(SELECT MAX(rl.min_age) FROM game_ratings gr JOIN rating_levels rl ON rl.id = gr.rating_level_id
+		  WHERE gr.game_id = games.id AND gr.deleted_at IS NULL),
+		games.age_rating, 0)`, []interface{}{sysID}
+}
+
+// GameMinAges อายุขั้นต่ำของหลายเกมพร้อมกัน
+func GameMinAges(db *gorm.DB, gameIDs []uint, region entity.Region) (map[uint]int, error) {
+	out := map[uint]int{}
+	if len(gameIDs) == 0 {
+		return out, nil
+	}
+	expr, args := GameMinAgeSQL(region)
+	var rows []struct {
+		ID     uint
+		MinAge int
+	}
+	if err := db.Table("games").Select("games.id AS id, "+expr+" AS min_age", args...).
+		Where("games.id IN ?", gameIDs).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		out[r.ID] = r.MinAge
+	}
+	return out, nil
+}
+
+// CheckGameAge คืน error ถ้า viewer ไม่มีสิทธิ์เข้าถึงเกมนี้
+func CheckGameAge(db *gorm.DB, v Viewer, gameID uint, region entity.Region) (int, error) {
+	ages, err := GameMinAges(db, []uint{gameID}, region)
+	if err != nil {
+		return 0, err
+	}
+	minAge := ages[gameID]
+	if minAge <= v.MaxAllowedAge() {
+		return minAge, nil
+	}
+	if v.Age < 0 {
+		return minAge, ErrAgeUnverified
+	}
+	return minAge, ErrAgeRestricted
+}
+
+// RestrictedGameIDsSQL subquery ของเกมที่ viewer เข้าไม่ได้ (ใช้กับ NOT IN)
+func RestrictedGameIDsSQL(v Viewer, region entity.Region) (string, []interface{}) {
+	expr, args := GameMinAgeSQL(region)
+	return "SELECT games.id FROM games WHERE " + expr + " > ?", append(args, v.MaxAllowedAge())
+}
@@ -0,0 +1,213 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"example.com/sa-gameshop/entity"
+	"gorm.io/gorm"
+)
+
+const (
+	CompatPass = "pass"
+	CompatWarn = "warn" // ผ่านขั้นต่ำแต่ต่ำกว่าที่แนะนำ หรือไม่ได้ระบุสเปก
+	CompatFail = "fail"
+)
+
+type ComponentResult struct {
+	Component   string `json:"component"`
+	Result      string `json:"result"`
+	Have        string `json:"have"`
+	Minimum     string `json:"minimum"`
+	Recommended string `json:"recommended"`
+	Message     string `json:"message,omitempty"`
+}
+
+type CompatibilityReport struct {
+	GameID     uint              `json:"game_id"`
+	OS         string            `json:"os"`
+	Overall    string            `json:"overall"`
+	Components []ComponentResult `json:"components"`
+}
+
+// CheckCompatibility เทียบสเปกเครื่องกับความต้องการของเกมบน OS เดียวกัน
+func CheckCompatibility(gameID uint, reqs []entity.SystemRequirement, hw entity.HardwareProfile) CompatibilityReport {
+	rep := CompatibilityReport{GameID: gameID, OS: hw.OS, Overall: CompatPass, Components: []ComponentResult{}}
+
+	var minReq, recReq *entity.SystemRequirement
+	for i := range reqs {
+		if reqs[i].OS != hw.OS {
+			continue
+		}
+		switch reqs[i].Level {
+		case entity.SpecMinimum:
+			minReq = &reqs[i]
+		case entity.SpecRecommended:
+			recReq = &reqs[i]
+		}
+	}
+	if minReq == nil && recReq == nil {
+		rep.Overall = CompatFail
+		rep.Components = append(rep.Components, ComponentResult{
+			Component: "os", Result: CompatFail, Have: hw.OS,
+			Message: "game does not list requirements for this OS",
+		})
+		return rep
+	}
+	// มีแค่ระดับเดียว → ใช้แทนกัน
+	if minReq == nil {
+		minReq = recReq
+	}
+	if recReq == nil {
+		recReq = minReq
+	}
+
+	add := func(r ComponentResult) {
+		rep.Components = append(rep.Components, r)
+		if worse(r.Result, rep.Overall) {
+			rep.Overall = r.Result
+		}
+	}
+	add(ComponentResult{Component: "os", Result: CompatPass, Have: hw.OS, Minimum: minReq.OS, Recommended: recReq.OS})
+	add(compareInt("cpu", hw.CPUTier, minReq.CPUTier, recReq.CPUTier, strings.TrimSpace(hw.CPUText)))
+	add(compareInt("gpu", hw.GPUTier, minReq.GPUTier, recReq.GPUTier, strings.TrimSpace(hw.GPUText)))
+	add(compareInt("ram_mb", hw.RAMMB, minReq.RAMMB, recReq.RAMMB, ""))
+	add(compareInt("vram_mb", hw.VRAMMB, minReq.VRAMMB, recReq.VRAMMB, ""))
+	add(compareInt("storage_gb", hw.FreeStorageGB, minReq.StorageGB, recReq.StorageGB, ""))
+	if minReq.DirectX > 0 || recReq.DirectX > 0 {
+		add(compareInt("directx", hw.DirectX, minReq.DirectX, recReq.DirectX, ""))
+	}
+	if minReq.Vulkan != "" || recReq.Vulkan != "" {
+		add(compareInt("vulkan", versionNum(hw.Vulkan), versionNum(minReq.Vulkan), versionNum(recReq.Vulkan), hw.Vulkan))
+	}
+	return rep
+}
+
+func compareInt(name string, have, minV, recV int, label string) ComponentResult {
+	r := ComponentResult{Component: name, Have: strconv.Itoa(have), Minimum: strconv.Itoa(minV), Recommended: strconv.Itoa(recV)}
+	if label != "" {
+		r.Have = label
+	}
+	switch {
+	case minV == 0 && recV == 0:
+		r.Result = CompatPass
+		r.Message = "not specified by game"
+	case have == 0:
+		r.Result = CompatWarn
+		r.Message = "not specified in hardware profile"
+	case have < minV:
+		r.Result = CompatFail
+	case have < recV:
+		r.Result = CompatWarn
+		r.Message = "below recommended"
+	default:
+		r.Result = CompatPass
+	}
+	return r
+}
+
+func worse(a, b string) bool {
+	rank := map[string]int{CompatPass: 0, CompatWarn: 1, CompatFail: 2}
+	return rank[a] > rank[b]
+}
+
+// "1.2" → 1002, "1.3.250" → 1003 (เทียบแค่ major.minor)
+func versionNum(v string) int {
+	parts := strings.Split(strings.TrimSpace(v), ".")
+	if len(parts) == 0 || parts[0] == "" {
+		return 0
+	}
+	major, _ := strconv.Atoi(parts[0])
+	minor := 0
+	if len(parts) > 1 {
+		minor, _ = strconv.Atoi(parts[1])
+	}
+	return major*1000 + minor
+}
+
+// ===== ย้ายข้อมูลจาก MinimumSpec (ข้อความอิสระ) =====
+
+var sizeRe = regexp.MustCompile(`(?i)(\d+(?:\.\d+)?)\s*(tb|gb|mb|g|m)?`)
+
+// ParseSizeMB แปลงข้อความอย่าง "8GB", "512 MB" เป็น MB (ไม่มีหน่วย = GB)
+func ParseSizeMB(s string) int {
+	m := sizeRe.FindStringSubmatch(s)
+	if m == nil {
+		return 0
+	}
+	v, _ := strconv.ParseFloat(m[1], 64)
+	switch strings.ToLower(m[2]) {
+	case "tb":
+		v *= 1024 * 1024
+	case "mb", "m":
+	default:
+		v *= 1024
+	}
+	return int(v)
+}
+
+// NormalizeOS แปลงชื่อ OS อิสระเป็นรหัสแพลตฟอร์ม (windows/macos/linux)
+func NormalizeOS(s string) string {
+	s = strings.ToLower(s)
+	switch {
+	case strings.Contains(s, "mac") || strings.Contains(s, "osx"):
+		return "macos"
+	case strings.Contains(s, "linux") || strings.Contains(s, "ubuntu") || strings.Contains(s, "steamos"):
+		return "linux"
+	default:
+		return "windows"
+	}
+}
+
+const requirementsBackfill = "backfill_system_requirements"
+
+// BackfillRequirements สร้าง requirement ระดับ minimum จาก MinimumSpec เดิม
+// สำหรับเกมที่ยังไม่มี requirement เลย (ตัวเลขได้เท่าที่อ่านจากข้อความได้)
+// ทำครั้งเดียว — ถ้าแอดมินลบ requirement ทีหลังจะไม่ถูกสร้างกลับ
+func BackfillRequirements(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var done int64
+		if err := tx.Model(&entity.DataMigration{}).Where("name = ?", requirementsBackfill).Count(&done).Error; err != nil {
+			return err
+		}
+		if done > 0 {
+			return nil
+		}
+		if err := backfillRequirements(tx); err != nil {
+			return err
+		}
+		return tx.Create(&entity.DataMigration{Name: requirementsBackfill}).Error
+	})
+}
+
+func backfillRequirements(db *gorm.DB) error {
+	var games []entity.Game
+	if err := db.Preload("MinimumSpec").
+		Where("id NOT IN (SELECT game_id FROM system_requirements WHERE deleted_at IS NULL)").
+		Where("minimum_spec_id > 0").
+		Find(&games).Error; err != nil {
+		return err
+	}
+	for _, g := range games {
+		ms := g.MinimumSpec
+		if ms.ID == 0 {
+			continue
+		}
+		req := entity.SystemRequirement{
+			GameID:    g.ID,
+			OS:        NormalizeOS(ms.OS),
+			Level:     entity.SpecMinimum,
+			CPUText:   ms.Processor,
+			GPUText:   ms.Graphics,
+			RAMMB:     ParseSizeMB(ms.Memory),
+			StorageGB: ParseSizeMB(ms.Storage) / 1024,
+			Notes:     fmt.Sprintf("migrated from minimum spec #%d", ms.ID),
+		}
+		if err := db.Create(&req).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package services
+
+import (
+	"testing"
+
+	"example.com/sa-gameshop/entity"
+)
+
+func TestBackfillRequirementsRunsOnce(t *testing.T) {
+	db := newTestDB(t, &entity.MinimumSpec{}, &entity.Game{}, &entity.SystemRequirement{}, &entity.DataMigration{})
+	spec := entity.MinimumSpec{OS: "Windows 10", Memory: "8 GB", Storage: "50 GB"}
+	db.Create(&spec)
+	db.Create(&entity.Game{GameName: "g", Minimum_specID: spec.ID})
+
+	count := func() (n int64) {
+		db.Model(&entity.SystemRequirement{}).Count(&n)
+		return
+	}
+	if err := BackfillRequirements(db); err != nil {
+		t.Fatal(err)
+	}
+	if n := count(); n != 1 {
+		t.Fatalf("after first backfill: %d requirements, want 1", n)
+	}
+
+	// แอดมินล้าง requirement (PUT แบบว่าง) → รีสตาร์ทแล้วต้องไม่กลับมา
+	db.Unscoped().Where("1 = 1").Delete(&entity.SystemRequirement{})
+	if err := BackfillRequirements(db); err != nil {
+		t.Fatal(err)
+	}
+	if n := count(); n != 0 {
+		t.Fatalf("backfill ran again: %d requirements", n)
+	}
+}
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"example.com/sa-gameshop/entity"
+	"gorm.io/gorm"
+)
+
+// ชนิดเหตุการณ์ใน /notifications/stream
+const (
+	EventNotification = "notification" // แจ้งเตือนใหม่/ถูกรวม (data = Notification)
+	EventUnreadCount  = "unread_count" // {"unread": n}
+	EventComment      = "comment"      // ความเห็นใหม่ในกระทู้ที่เปิดดูอยู่ (topic thread:<id>)
+	EventPayment      = "payment"      // สถานะการชำระเงินของผู้ใช้เปลี่ยน
+)
+
+// Event เหตุการณ์หนึ่งรายการ ส่งถึงผู้ใช้คนเดียว (UserID) หรือทุกคนที่ติดตาม Topic
+type Event struct {
+	ID     uint64
+	Type   string
+	UserID uint
+	Topic  string
+	Data   interface{}
+}
+
+// EventSubscription การเชื่อมต่อหนึ่งสาย อ่านเหตุการณ์จาก C
+// ถ้าอ่านไม่ทันจน buffer เต็ม hub จะปิด C (client ต่อใหม่ด้วย Last-Event-ID แล้วได้ส่วนที่พลาดไป)
+type EventSubscription struct {
+	C      chan Event
+	userID uint
+	topics map[string]bool
+}
+
+func (s *EventSubscription) match(e Event) bool {
+	if e.Topic != "" {
+		return s.topics[e.Topic]
+	}
+	return e.UserID == s.userID
+}
+
+// EventHub pub/sub ภายในโปรเซส เก็บเหตุการณ์ล่าสุดไว้ size รายการสำหรับ resume
+// ID ที่ส่งให้ client เป็น "<boot>-<seq>" เพื่อรู้ว่า Last-Event-ID มาจากก่อนรีสตาร์ทหรือไม่
+type EventHub struct {
+	mu      sync.Mutex
+	boot    int64
+	seq     uint64
+	size    int
+	backlog []Event
+	subs    map[*EventSubscription]struct{}
+}
+
+func NewEventHub(size int) *EventHub {
+	return &EventHub{
+		boot: time.Now().UnixNano(),
+		size: size,
+		subs: map[*EventSubscription]struct{}{},
+	}
+}
+
+// Events hub หลักของแอป
+var Events = NewEventHub(1000)
+
+// EventID รูปแบบ id ที่ส่งใน stream
+func (h *EventHub) EventID(seq uint64) string {
+	return fmt.Sprintf("%d-%d", h.boot, seq)
+}
+
+func (h *EventHub) parseEventID(s string) (uint64, bool) {
+	boot, seq, ok := strings.Cut(strings.TrimSpace(s), "-")
+	if !ok || boot != strconv.FormatInt(h.boot, 10) {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(seq, 10, 64)
+	return n, err == nil && n <= h.seq
+}
+
+// Publish ส่งเหตุการณ์ (ไม่บล็อกผู้ส่ง) คืนเหตุการณ์พร้อม ID
+func (h *EventHub) Publish(e Event) Event {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.seq++
+	e.ID = h.seq
+	h.backlog = append(h.backlog, e)
+	if len(h.backlog) > h.size {
+		h.backlog = h.backlog[len(h.backlog)-h.size:]
+	}
+	for s := range h.subs {
+		if !s.match(e) {
+			continue
+		}
+		select {
+		case s.C <- e:
+		default:
+			delete(h.subs, s)
+			close(s.C)
+		}
+	}
+	return e
+}
+
+// Subscribe เริ่มรับเหตุการณ์ของผู้ใช้ + topics
+// lastEventID = ค่าจาก Last-Event-ID: คืนเหตุการณ์ที่พลาดไปจาก backlog
+// resumed = false เมื่อไม่มี lastEventID หรือเก่าเกิน backlog/มาจากก่อนรีสตาร์ท (ผู้เรียกควรส่งสถานะล่าสุดให้แทน)
+// cursor = ID ล่าสุด ณ ตอนสมัคร
+func (h *EventHub) Subscribe(userID uint, topics []string, lastEventID string) (sub *EventSubscription, missed []Event, resumed bool, cursor string) {
+	sub = &EventSubscription{C: make(chan Event, 64), userID: userID, topics: map[string]bool{}}
+	for _, t := range topics {
+		sub.topics[t] = true
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if last, ok := h.parseEventID(lastEventID); ok {
+		resumed = len(h.backlog) == 0 || h.backlog[0].ID <= last+1
+		for _, e := range h.backlog {
+			if e.ID > last && sub.match(e) {
+				missed = append(missed, e)
+			}
+		}
+	}
+	h.subs[sub] = struct{}{}
+	return sub, missed, resumed, h.EventID(h.seq)
+}
+
+// Unsubscribe เลิกรับ (เรียกซ้ำได้)
+func (h *EventHub) Unsubscribe(sub *EventSubscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subs[sub]; ok {
+		delete(h.subs, sub)
+		close(sub.C)
+	}
+}
+
+// ThreadTopic topic ของความเห็นใหม่ในกระทู้
+func ThreadTopic(threadID uint) string {
+	return "thread:" + strconv.FormatUint(uint64(threadID), 10)
+}
+
+// UnreadNotificationCount จำนวนแจ้งเตือนที่ยังไม่อ่าน
+func UnreadNotificationCount(db *gorm.DB, userID uint) int64 {
+	var n int64
+	db.Model(&entity.Notification{}).Where("user_id = ? AND is_read = ?", userID, false).Count(&n)
+	return n
+}
+
+// PublishUnreadCount ส่งจำนวนที่ยังไม่อ่านล่าสุดให้ผู้ใช้
+func PublishUnreadCount(db *gorm.DB, userID uint) {
+	Events.Publish(Event{Type: EventUnreadCount, UserID: userID, Data: map[string]int64{"unread": UnreadNotificationCount(db, userID)}})
+}
+
+// PublishNotification ส่งแจ้งเตือนที่เพิ่งสร้าง/อัปเดต + จำนวนที่ยังไม่อ่าน
+// ถ้า db ผูก NotificationOutbox ไว้ (อยู่ใน transaction) จะเก็บไว้ส่งหลัง commit แทน
+func PublishNotification(db *gorm.DB, n entity.Notification) {
+	if o, ok := db.Statement.Context.Value(notificationOutboxKey{}).(*NotificationOutbox); ok {
+		o.rows = append(o.rows, n)
+		return
+	}
+	Events.Publish(Event{Type: EventNotification, UserID: n.UserID, Data: n})
+	PublishUnreadCount(db, n.UserID)
+}
+
+type notificationOutboxKey struct{}
+
+// NotificationOutbox เก็บแจ้งเตือนที่สร้างใน transaction ไว้ส่ง event หลัง commit
+// (ไม่งั้น client ได้ event ของแถวที่อาจถูก rollback และจำนวนที่ยังไม่อ่านนับไม่ตรง)
+type NotificationOutbox struct {
+	rows []entity.Notification
+}
+
+// Bind คืน tx ที่ Notify/PublishNotification จะเก็บ event ลง outbox นี้แทนการส่งทันที
+func (o *NotificationOutbox) Bind(tx *gorm.DB) *gorm.DB {
+	ctx := tx.Statement.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return tx.WithContext(context.WithValue(ctx, notificationOutboxKey{}, o))
+}
+
+// Publish ส่ง event ที่เก็บไว้ (เรียกหลัง transaction commit สำเร็จเท่านั้น)
+func (o *NotificationOutbox) Publish(db *gorm.DB) {
+	rows := o.rows
+	o.rows = nil
+	for _, n := range rows {
+		PublishNotification(db, n)
+	}
+}
+
+// PublishPaymentStatus แจ้งเจ้าของออเดอร์ว่าสถานะการชำระเงินเปลี่ยน
+func PublishPaymentStatus(p entity.Payment) {
+	Events.Publish(Event{Type: EventPayment, UserID: p.Order.UserID, Data: map[string]interface{}{
+		"payment_id":    p.ID,
+		"order_id":      p.OrderID,
+		"status":        p.Status,
+		"order_status":  p.Order.OrderStatus,
+		"reject_reason": p.RejectReason,
+	}})
+}
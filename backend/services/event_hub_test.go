@@ -0,0 +1,161 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"example.com/sa-gameshop/entity"
+	"gorm.io/gorm"
+)
+
+func recv(t *testing.T, sub *EventSubscription) Event {
+	t.Helper()
+	select {
+	case e, ok := <-sub.C:
+		if !ok {
+			t.Fatal("subscription closed")
+		}
+		return e
+	default:
+		t.Fatal("no event delivered")
+	}
+	return Event{}
+}
+
+func expectNone(t *testing.T, sub *EventSubscription) {
+	t.Helper()
+	select {
+	case e, ok := <-sub.C:
+		if ok {
+			t.Fatalf("unexpected event %+v", e)
+		}
+	default:
+	}
+}
+
+func TestEventHubDeliversByUserAndTopic(t *testing.T) {
+	h := NewEventHub(10)
+	alice, _, _, _ := h.Subscribe(1, []string{ThreadTopic(7)}, "")
+	bob, _, _, _ := h.Subscribe(2, nil, "")
+
+	h.Publish(Event{Type: EventUnreadCount, UserID: 1})
+	if e := recv(t, alice); e.UserID != 1 || e.ID != 1 {
+		t.Fatalf("alice got %+v", e)
+	}
+	expectNone(t, bob)
+
+	h.Publish(Event{Type: EventComment, Topic: ThreadTopic(7)})
+	if e := recv(t, alice); e.Topic != ThreadTopic(7) {
+		t.Fatalf("alice got %+v", e)
+	}
+	expectNone(t, bob)
+
+	// topic ที่ไม่ได้ติดตามไม่ส่ง แม้ UserID ตรง
+	h.Publish(Event{Type: EventComment, UserID: 1, Topic: ThreadTopic(8)})
+	expectNone(t, alice)
+
+	h.Unsubscribe(alice)
+	h.Unsubscribe(alice) // เรียกซ้ำได้
+	if _, ok := <-alice.C; ok {
+		t.Fatal("channel should be closed after Unsubscribe")
+	}
+}
+
+func TestEventHubDropsSlowConsumer(t *testing.T) {
+	h := NewEventHub(1000)
+	slow, _, _, _ := h.Subscribe(1, nil, "")
+	fast, _, _, _ := h.Subscribe(2, nil, "")
+
+	n := cap(slow.C) + 1
+	for i := 0; i < n; i++ {
+		h.Publish(Event{Type: EventNotification, UserID: 1})
+		h.Publish(Event{Type: EventNotification, UserID: 2})
+		<-fast.C
+	}
+
+	got := 0
+	for range slow.C {
+		got++
+	}
+	if got != cap(slow.C) {
+		t.Fatalf("slow consumer got %d events before drop, want %d", got, cap(slow.C))
+	}
+	h.Publish(Event{Type: EventNotification, UserID: 2})
+	recv(t, fast)
+
+	// ต่อใหม่จาก event สุดท้ายที่อ่านได้ → ได้ส่วนที่พลาดจาก backlog
+	_, missed, resumed, _ := h.Subscribe(1, nil, h.EventID(uint64(2*got-1)))
+	if !resumed || len(missed) != 1 || missed[0].UserID != 1 {
+		t.Fatalf("resume: resumed=%v missed=%+v", resumed, missed)
+	}
+}
+
+func TestEventHubResume(t *testing.T) {
+	h := NewEventHub(3)
+	for i := 0; i < 2; i++ {
+		h.Publish(Event{Type: EventNotification, UserID: 1})
+		h.Publish(Event{Type: EventNotification, UserID: 2})
+	}
+
+	_, missed, resumed, cursor := h.Subscribe(1, nil, h.EventID(1))
+	if !resumed || len(missed) != 1 || missed[0].ID != 3 {
+		t.Fatalf("resume from 1: resumed=%v missed=%+v", resumed, missed)
+	}
+	if cursor != h.EventID(4) {
+		t.Fatalf("cursor = %q, want %q", cursor, h.EventID(4))
+	}
+
+	// backlog เก็บแค่ 2..4 → id 0 เก่าเกิน
+	if _, _, resumed, _ := h.Subscribe(1, nil, h.EventID(0)); resumed {
+		t.Fatal("resume older than backlog should not be resumed")
+	}
+
+	// id จากก่อนรีสตาร์ท (boot ต่างกัน) หรือจากอนาคต → เริ่มใหม่
+	stale := NewEventHub(3)
+	stale.boot = h.boot - 1
+	for _, id := range []string{stale.EventID(4), h.EventID(99), "garbage", ""} {
+		_, missed, resumed, _ := h.Subscribe(1, nil, id)
+		if resumed || len(missed) != 0 {
+			t.Fatalf("Last-Event-ID %q: resumed=%v missed=%d", id, resumed, len(missed))
+		}
+	}
+}
+
+func TestNotificationOutboxPublishesAfterCommit(t *testing.T) {
+	db := newTestDB(t, &entity.Notification{}, &entity.NotificationPreference{})
+	prev := Events
+	Events = NewEventHub(10)
+	defer func() { Events = prev }()
+	sub, _, _, _ := Events.Subscribe(5, nil, "")
+
+	var outbox NotificationOutbox
+	err := db.Transaction(func(tx *gorm.DB) error {
+		tx = outbox.Bind(tx)
+		if err := Notify(tx, &entity.Notification{Title: "a", Type: NotificationSystem, UserID: 5}); err != nil {
+			return err
+		}
+		expectNone(t, sub)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	outbox.Publish(db)
+	if e := recv(t, sub); e.Type != EventNotification {
+		t.Fatalf("got %+v", e)
+	}
+	if e := recv(t, sub); e.Type != EventUnreadCount || e.Data.(map[string]int64)["unread"] != 1 {
+		t.Fatalf("got %+v", e)
+	}
+
+	// rollback → ไม่มี event
+	outbox = NotificationOutbox{}
+	_ = db.Transaction(func(tx *gorm.DB) error {
+		tx = outbox.Bind(tx)
+		if err := Notify(tx, &entity.Notification{Title: "b", Type: NotificationSystem, UserID: 5}); err != nil {
+			return err
+		}
+		return errors.New("rollback")
+	})
+	expectNone(t, sub)
+}
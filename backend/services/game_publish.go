@@ -0,0 +1,139 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"example.com/sa-gameshop/entity"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidTransition = errors.New("invalid status transition")
+	ErrReleaseInPast     = errors.New("release_at must be in the future")
+	ErrGameNotAvailable  = errors.New("game is not available for purchase")
+)
+
+// EnsureGamePurchasable ซื้อได้เฉพาะเกมที่ published
+func EnsureGamePurchasable(db *gorm.DB, gameID uint) error {
+	var n int64
+	if err := db.Model(&entity.Game{}).Where("id = ? AND status = ?", gameID, entity.GamePublished).Count(&n).Error; err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrGameNotAvailable
+	}
+	return nil
+}
+
+// สถานะปลายทางที่ไปได้จากแต่ละสถานะ
+var gameTransitions = map[string][]string{
+	entity.GameDraft:     {entity.GameSubmitted},
+	entity.GameSubmitted: {entity.GameApproved, entity.GameRejected},
+	entity.GameRejected:  {entity.GameSubmitted},
+	entity.GameApproved:  {entity.GamePublished, entity.GameRejected},
+	entity.GamePublished: {entity.GameDelisted},
+	entity.GameDelisted:  {entity.GamePublished},
+}
+
+// CanTransitionGame ตรวจว่าเปลี่ยนสถานะ from → to ได้หรือไม่
+func CanTransitionGame(from, to string) bool {
+	for _, s := range gameTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// TransitionGame เปลี่ยนสถานะเกม + บันทึก log
+// - approve พร้อม releaseAt ในอนาคต → รอ scheduler publish ให้
+// - publish ทันทีจะล้าง release_at ที่ตั้งไว้
+func TransitionGame(db *gorm.DB, game *entity.Game, to string, actorID uint, note string, releaseAt *time.Time) error {
+	from := game.Status
+	if !CanTransitionGame(from, to) {
+		return ErrInvalidTransition
+	}
+	now := time.Now()
+	if releaseAt != nil && !releaseAt.After(now) {
+		return ErrReleaseInPast
+	}
+
+	updates := map[string]interface{}{"status": to}
+	if note != "" || to == entity.GameRejected {
+		updates["review_note"] = note
+	}
+	switch to {
+	case entity.GameApproved:
+		if releaseAt != nil {
+			updates["release_at"] = *releaseAt
+		}
+	case entity.GamePublished:
+		updates["published_at"] = now
+		updates["release_at"] = nil
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// กันสองคนเปลี่ยนพร้อมกัน: อัปเดตเฉพาะเมื่อสถานะยังเป็น from
+		res := tx.Model(&entity.Game{}).Where("id = ? AND status = ?", game.ID, from).Updates(updates)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrInvalidTransition
+		}
+		if err := tx.Create(&entity.GameStatusLog{
+			GameID:     game.ID,
+			FromStatus: from,
+			ToStatus:   to,
+			Note:       note,
+			ActorID:    actorID,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.First(game, game.ID).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	if to == entity.GamePublished {
+		if err := NotifyWishlistRelease(db, *game); err != nil {
+			log.Println("[Wishlist] release notify error:", err)
+		}
+	}
+	return nil
+}
+
+// PublishDueGames publish เกมที่ approved และถึงวันวางขายแล้ว
+func PublishDueGames(db *gorm.DB, now time.Time) error {
+	var games []entity.Game
+	if err := db.Where("status = ? AND release_at IS NOT NULL AND release_at <= ?", entity.GameApproved, now).
+		Find(&games).Error; err != nil {
+		return err
+	}
+	for i := range games {
+		if err := TransitionGame(db, &games[i], entity.GamePublished, 0, "scheduled release", nil); err != nil &&
+			!errors.Is(err, ErrInvalidTransition) {
+			return err
+		}
+	}
+	return nil
+}
+
+// StartGameReleaseScheduler ตรวจเกมที่ถึงวันวางขายทุก interval
+func StartGameReleaseScheduler(db *gorm.DB, interval time.Duration) {
+	if err := PublishDueGames(db, time.Now()); err != nil {
+		log.Println("[GameRelease] error:", err)
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			if err := PublishDueGames(db, now); err != nil {
+				log.Println("[GameRelease] error:", err)
+			}
+		}
+	}()
+}
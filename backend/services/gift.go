@@ -0,0 +1,239 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"example.com/sa-gameshop/entity"
+	"gorm.io/gorm"
+)
+
+// ชนิดแจ้งเตือนของขวัญ (Notification.Type)
+const (
+	NotificationGiftReceived = "gift_received"
+	NotificationGiftClaimed  = "gift_claimed"
+	NotificationGiftDeclined = "gift_declined"
+)
+
+// สถานะคำร้องคืนเงินที่ระบบสร้างให้อัตโนมัติ (refund_statuses.status_name)
+const RefundStatusApproved = "APPROVED"
+
+var (
+	ErrGiftRecipientNotFound = errors.New("gift recipient not found")
+	ErrGiftToSelf            = errors.New("cannot send a gift to yourself")
+	ErrGiftAlreadyOwned      = errors.New("recipient already owns this game")
+	ErrGiftNotFound          = errors.New("gift not found")
+	ErrGiftNotClaimable      = errors.New("gift is not waiting to be claimed")
+)
+
+// FindGiftRecipient หาผู้รับจาก username หรือ email (ไม่สนตัวพิมพ์)
+func FindGiftRecipient(db *gorm.DB, ident string) (entity.User, error) {
+	var u entity.User
+	ident = strings.ToLower(strings.TrimSpace(ident))
+	if ident == "" {
+		return u, ErrGiftRecipientNotFound
+	}
+	tx := db.Where("LOWER(username) = ? OR LOWER(email) = ?", ident, ident).Limit(1).Find(&u)
+	if tx.Error != nil {
+		return u, tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return u, ErrGiftRecipientNotFound
+	}
+	return u, nil
+}
+
+// OwnedGameIDs เกมในรายการที่ผู้ใช้มีอยู่แล้ว
+func OwnedGameIDs(db *gorm.DB, userID uint, gameIDs []uint) ([]uint, error) {
+	var owned []uint
+	if len(gameIDs) == 0 {
+		return owned, nil
+	}
+	err := db.Model(&entity.UserGame{}).
+		Where("user_id = ? AND game_id IN ?", userID, gameIDs).
+		Pluck("game_id", &owned).Error
+	return owned, err
+}
+
+func orderGameNames(tx *gorm.DB, orderID uint) string {
+	var names []string
+	tx.Table("order_items oi").
+		Joins("JOIN games g ON g.id = oi.game_id").
+		Where("oi.order_id = ? AND oi.deleted_at IS NULL", orderID).
+		Order("oi.id ASC").
+		Pluck("g.game_name", &names)
+	return strings.Join(names, ", ")
+}
+
+// DeliverGift เรียกใน transaction ตอนอนุมัติการชำระเงิน
+// ผู้เรียกต้อง Bind NotificationOutbox กับ tx แล้ว Publish หลัง commit
+// คืน true ถ้าออเดอร์เป็นของขวัญ (ผู้เรียกต้องไม่ให้เกมเข้าคลังผู้ซื้อ)
+func DeliverGift(tx *gorm.DB, orderID uint) (bool, error) {
+	var g entity.Gift
+	if err := tx.Preload("Sender").Where("order_id = ?", orderID).Limit(1).Find(&g).Error; err != nil {
+		return false, err
+	}
+	if g.ID == 0 {
+		return false, nil
+	}
+	if g.Status != entity.GiftPending {
+		return true, nil
+	}
+	now := time.Now()
+	if err := tx.Model(&g).Updates(map[string]interface{}{
+		"status": entity.GiftDelivered, "delivered_at": now,
+	}).Error; err != nil {
+		return true, err
+	}
+	sender := "ผู้ใช้"
+	if g.Sender != nil {
+		sender = g.Sender.Username
+	}
+	msg := fmt.Sprintf("%s ส่ง %s ให้คุณเป็นของขวัญ", sender, orderGameNames(tx, orderID))
+	if g.Message != "" {
+		msg += ": " + g.Message
+	}
+	return true, Notify(tx, &entity.Notification{
+		Title:   "คุณได้รับของขวัญ",
+		Type:    NotificationGiftReceived,
+		Message: msg,
+		UserID:  g.RecipientID,
+	})
+}
+
+func loadGiftForRecipient(tx *gorm.DB, giftID, userID uint) (entity.Gift, error) {
+	var g entity.Gift
+	if err := tx.Preload("Recipient").Preload("Order").
+		Where("id = ? AND recipient_id = ?", giftID, userID).Limit(1).Find(&g).Error; err != nil {
+		return g, err
+	}
+	if g.ID == 0 || g.Order == nil {
+		return g, ErrGiftNotFound
+	}
+	if g.Status != entity.GiftDelivered {
+		return g, ErrGiftNotClaimable
+	}
+	return g, nil
+}
+
+// ClaimGift ผู้รับกดรับ → เกมเข้าคลังผู้รับ (เกมที่มีอยู่แล้วข้ามไป)
+func ClaimGift(db *gorm.DB, giftID, userID uint) (entity.Gift, error) {
+	var g entity.Gift
+	var outbox NotificationOutbox
+	err := db.Transaction(func(tx *gorm.DB) error {
+		tx = outbox.Bind(tx)
+		var err error
+		if g, err = loadGiftForRecipient(tx, giftID, userID); err != nil {
+			return err
+		}
+		var payment entity.Payment
+		tx.Where("order_id = ? AND status = ?", g.OrderID, entity.PaymentApproved).Order("id DESC").Limit(1).Find(&payment)
+
+		var gameIDs []uint
+		if err := tx.Model(&entity.OrderItem{}).Where("order_id = ?", g.OrderID).Distinct().Pluck("game_id", &gameIDs).Error; err != nil {
+			return err
+		}
+		owned, err := OwnedGameIDs(tx, userID, gameIDs)
+		if err != nil {
+			return err
+		}
+		has := map[uint]bool{}
+		for _, id := range owned {
+			has[id] = true
+		}
+		now := time.Now()
+		for _, id := range gameIDs {
+			if has[id] {
+				continue
+			}
+			if err := tx.Create(&entity.UserGame{
+				UserID:             userID,
+				GameID:             id,
+				GrantedAt:          now,
+				GrantedByPaymentID: payment.ID,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&g).Updates(map[string]interface{}{
+			"status": entity.GiftClaimed, "claimed_at": now,
+		}).Error; err != nil {
+			return err
+		}
+		return Notify(tx, &entity.Notification{
+			Title:   "ของขวัญถูกรับแล้ว",
+			Type:    NotificationGiftClaimed,
+			Message: fmt.Sprintf("%s รับ %s ที่คุณส่งให้แล้ว", g.Recipient.Username, orderGameNames(tx, g.OrderID)),
+			UserID:  g.SenderID,
+		})
+	})
+	if err == nil {
+		outbox.Publish(db)
+	}
+	return g, err
+}
+
+// DeclineGift ผู้รับปฏิเสธ → คืนคีย์เข้าคลัง สร้างคำร้องคืนเงิน (อนุมัติทันที) และออเดอร์เป็น REFUNDED
+func DeclineGift(db *gorm.DB, giftID, userID uint, reason string) (entity.Gift, error) {
+	var g entity.Gift
+	var outbox NotificationOutbox
+	err := db.Transaction(func(tx *gorm.DB) error {
+		tx = outbox.Bind(tx)
+		var err error
+		if g, err = loadGiftForRecipient(tx, giftID, userID); err != nil {
+			return err
+		}
+		now := time.Now()
+
+		itemIDs := tx.Model(&entity.OrderItem{}).Select("id").Where("order_id = ?", g.OrderID)
+		if err := tx.Model(&entity.KeyGame{}).Where("owned_by_order_item_id IN (?)", itemIDs).
+			Update("owned_by_order_item_id", nil).Error; err != nil {
+			return err
+		}
+
+		status := entity.RefundStatus{StatusName: RefundStatusApproved}
+		if err := tx.Where("status_name = ?", status.StatusName).FirstOrCreate(&status).Error; err != nil {
+			return err
+		}
+		refundReason := "ผู้รับปฏิเสธของขวัญ"
+		if reason = strings.TrimSpace(reason); reason != "" {
+			refundReason += ": " + reason
+		}
+		refund := entity.RefundRequest{
+			OrderID:        g.OrderID,
+			UserID:         g.SenderID,
+			Reason:         refundReason,
+			RequestDate:    now,
+			ProcessedDate:  now,
+			Amount:         g.Order.TotalAmount,
+			RefundStatusID: status.ID,
+		}
+		if err := tx.Create(&refund).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&entity.Order{}).Where("id = ?", g.OrderID).
+			Update("order_status", entity.OrderRefunded).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&g).Updates(map[string]interface{}{
+			"status": entity.GiftDeclined, "declined_at": now, "refund_request_id": refund.ID,
+		}).Error; err != nil {
+			return err
+		}
+		return Notify(tx, &entity.Notification{
+			Title: "ของขวัญถูกปฏิเสธ",
+			Type:  NotificationGiftDeclined,
+			Message: fmt.Sprintf("%s ปฏิเสธ %s ระบบคืนเงิน %.2f %s ให้คุณแล้ว",
+				g.Recipient.Username, orderGameNames(tx, g.OrderID), g.Order.TotalAmount, g.Order.Currency),
+			UserID: g.SenderID,
+		})
+	})
+	if err == nil {
+		outbox.Publish(db)
+	}
+	return g, err
+}
@@ -0,0 +1,286 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+
+	"github.com/HugoSmits86/nativewebp"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // ให้ image.Decode อ่าน webp ได้
+)
+
+// pipeline รูปที่ผู้ใช้อัปโหลด (เกม/ม็อด/โปรโมชัน/กระทู้)
+// ตรวจชนิดจากเนื้อไฟล์จริง → ตรวจขนาด → decode/encode ใหม่ (ตัด EXIF และ metadata อื่นทิ้ง)
+// → ย่อ thumbnail + ทำ WebP แล้วตั้งชื่อไฟล์ตาม sha256 ของไฟล์ต้นฉบับ (อัปโหลดซ้ำได้ไฟล์เดิม)
+//
+// ไฟล์ที่ได้ใน uploads/<dir>/:
+//
+//	<hash>.<jpg|png>         รูปหลัก (ย่อให้ไม่เกิน ImageMaxSide)
+//	<hash>_thumb.<jpg|png>   thumbnail กว้าง/สูงไม่เกิน ImageThumbSide
+//	<hash>.webp, <hash>_thumb.webp
+
+var (
+	ErrImageType      = errors.New("only jpg/jpeg/png/webp allowed")
+	ErrImageTooLarge  = errors.New("image file is too large")
+	ErrImageDimension = errors.New("image dimensions are out of range")
+)
+
+// IsImageRejected บอกว่า error มาจากไฟล์ของผู้ใช้ (ตอบ 400) ไม่ใช่ฝั่งเซิร์ฟเวอร์
+func IsImageRejected(err error) bool {
+	return errors.Is(err, ErrImageType) || errors.Is(err, ErrImageTooLarge) || errors.Is(err, ErrImageDimension)
+}
+
+const (
+	ImageThumbSide = 320
+	ImageMaxSide   = 2048 // รูปที่ใหญ่กว่านี้ถูกย่อก่อนเก็บ
+	imageMinSide   = 16
+	imageMaxPixels = 40_000_000 // กัน decompression bomb (ตรวจจาก header ก่อน decode)
+	imageJPEGQ     = 88
+)
+
+// ImageMaxBytes ขนาดไฟล์สูงสุด (ตั้งผ่าน IMAGE_MAX_BYTES, ค่าเริ่มต้น 8MB)
+func ImageMaxBytes() int64 {
+	if v, err := strconv.ParseInt(os.Getenv("IMAGE_MAX_BYTES"), 10, 64); err == nil && v > 0 {
+		return v
+	}
+	return 8 << 20
+}
+
+// StoredImage ผลลัพธ์ของ pipeline — path ไม่มี / นำหน้า เช่น uploads/games/<hash>.jpg
+type StoredImage struct {
+	Path          string `json:"path"`
+	ThumbPath     string `json:"thumb_path"`
+	WebPPath      string `json:"webp_path"`
+	ThumbWebPPath string `json:"thumb_webp_path"`
+	ContentType   string `json:"content_type"`
+	Width         int    `json:"width"`
+	Height        int    `json:"height"`
+	Hash          string `json:"hash"`
+}
+
+// SaveImage อ่านรูปจาก r แล้วเก็บทุก variant ไว้ใน uploads/<dir> (ผ่าน Files())
+func SaveImage(ctx context.Context, r io.Reader, dir string) (StoredImage, error) {
+	var out StoredImage
+	limit := ImageMaxBytes()
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return out, err
+	}
+	if int64(len(data)) > limit {
+		return out, ErrImageTooLarge
+	}
+
+	// ชนิดไฟล์จาก magic bytes ไม่เชื่อนามสกุล/Content-Type ที่ client ส่งมา
+	ctype := http.DetectContentType(data)
+	ext := ""
+	switch ctype {
+	case "image/jpeg":
+		ext = ".jpg"
+	case "image/png":
+		ext = ".png"
+	case "image/webp":
+		ext = ".png" // ต้นฉบับ webp เก็บรูปหลักเป็น png (ไม่สูญเสีย) คู่กับ .webp
+	default:
+		return out, ErrImageType
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return out, ErrImageType
+	}
+	if cfg.Width < imageMinSide || cfg.Height < imageMinSide || cfg.Width*cfg.Height > imageMaxPixels {
+		return out, ErrImageDimension
+	}
+
+	sum := sha256.Sum256(data)
+	out.Hash = hex.EncodeToString(sum[:16])
+	base := path.Join("uploads", dir, out.Hash)
+	out.Path = base + ext
+	out.ThumbPath = base + "_thumb" + ext
+	out.WebPPath = base + ".webp"
+	out.ThumbWebPPath = base + "_thumb.webp"
+	out.ContentType = mimeOfExt(ext)
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return out, ErrImageType
+	}
+	if ctype == "image/jpeg" {
+		img = applyOrientation(img, jpegOrientation(data))
+	}
+	img = fitWithin(img, ImageMaxSide)
+	out.Width, out.Height = img.Bounds().Dx(), img.Bounds().Dy()
+
+	// content-addressed: มีไฟล์ครบแล้วไม่ต้องทำซ้ำ
+	if _, err := files.Stat(ctx, StorageKey(out.ThumbWebPPath)); err == nil {
+		if _, err := files.Stat(ctx, StorageKey(out.Path)); err == nil {
+			return out, nil
+		}
+	}
+	thumb := fitWithin(img, ImageThumbSide)
+	for _, v := range []struct {
+		p   string
+		img image.Image
+	}{
+		{out.Path, img}, {out.ThumbPath, thumb}, {out.WebPPath, img}, {out.ThumbWebPPath, thumb},
+	} {
+		if err := writeImage(ctx, v.p, v.img); err != nil {
+			return out, err
+		}
+	}
+	return out, nil
+}
+
+func mimeOfExt(ext string) string {
+	switch ext {
+	case ".jpg":
+		return "image/jpeg"
+	case ".webp":
+		return "image/webp"
+	}
+	return "image/png"
+}
+
+// encode ในหน่วยความจำก่อน แล้วค่อยส่งเข้า storage (encode ล้มจะไม่มีไฟล์ครึ่ง ๆ กลาง ๆ)
+func writeImage(ctx context.Context, p string, img image.Image) error {
+	var buf bytes.Buffer
+	var err error
+	ext := path.Ext(p)
+	switch ext {
+	case ".jpg":
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: imageJPEGQ})
+	case ".webp":
+		err = nativewebp.Encode(&buf, img, nil)
+	default:
+		err = png.Encode(&buf, img)
+	}
+	if err != nil {
+		return fmt.Errorf("encode %s: %w", path.Base(p), err)
+	}
+	return PutBytes(ctx, StorageKey(p), buf.Bytes(), mimeOfExt(ext))
+}
+
+// ย่อให้ด้านยาวไม่เกิน side (ไม่ขยายรูปเล็ก)
+func fitWithin(img image.Image, side int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= side && h <= side {
+		return img
+	}
+	if w >= h {
+		h = max(h*side/w, 1)
+		w = side
+	} else {
+		w = max(w*side/h, 1)
+		h = side
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Src, nil)
+	return dst
+}
+
+// ===== EXIF orientation =====
+// EXIF ถูกตัดทิ้งตอน encode ใหม่ จึงต้องหมุนรูปตาม orientation ก่อน ไม่งั้นรูปจากมือถือจะตะแคง
+
+// jpegOrientation อ่าน tag 0x0112 จาก APP1/Exif (ไม่เจอ = 1)
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+	i := 2
+	for i+4 <= len(data) {
+		if data[i] != 0xFF {
+			return 1
+		}
+		marker := data[i+1]
+		size := int(binary.BigEndian.Uint16(data[i+2:]))
+		if marker == 0xDA || size < 2 || i+2+size > len(data) {
+			return 1 // ถึงข้อมูลภาพแล้ว
+		}
+		seg := data[i+4 : i+2+size]
+		if marker == 0xE1 && len(seg) > 14 && string(seg[:6]) == "Exif\x00\x00" {
+			return tiffOrientation(seg[6:])
+		}
+		i += 2 + size
+	}
+	return 1
+}
+
+func tiffOrientation(t []byte) int {
+	var bo binary.ByteOrder
+	switch string(t[:2]) {
+	case "II":
+		bo = binary.LittleEndian
+	case "MM":
+		bo = binary.BigEndian
+	default:
+		return 1
+	}
+	off := int(bo.Uint32(t[4:]))
+	if off+2 > len(t) {
+		return 1
+	}
+	n := int(bo.Uint16(t[off:]))
+	for k := 0; k < n; k++ {
+		e := off + 2 + k*12
+		if e+12 > len(t) {
+			return 1
+		}
+		if bo.Uint16(t[e:]) == 0x0112 {
+			if v := int(bo.Uint16(t[e+8:])); v >= 1 && v <= 8 {
+				return v
+			}
+			return 1
+		}
+	}
+	return 1
+}
+
+// applyOrientation หมุน/กลับรูปตามค่า EXIF 1-8
+func applyOrientation(img image.Image, o int) image.Image {
+	if o <= 1 || o > 8 {
+		return img
+	}
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if o >= 5 {
+		w, h = h, w
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			var dx, dy int
+			switch o {
+			case 2:
+				dx, dy = w-1-x, y
+			case 3:
+				dx, dy = w-1-x, h-1-y
+			case 4:
+				dx, dy = x, h-1-y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = w-1-y, x
+			case 7:
+				dx, dy = w-1-y, h-1-x
+			case 8:
+				dx, dy = y, h-1-x
+			}
+			dst.Set(dx, dy, img.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return dst
+}
@@ -0,0 +1,242 @@
+package services
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"example.com/sa-gameshop/entity"
+	"gorm.io/gorm"
+)
+
+// Markdown ชุดย่อยสำหรับโพสต์ในคอมมูนิตี้ (กระทู้/คอมเมนต์/รีวิว)
+//
+//	# หัวข้อ, **หนา**, *เอียง*, ~~ขีดฆ่า~~, `โค้ด`, ```บล็อกโค้ด```, > อ้างอิง,
+//	- รายการ, 1. รายการ, ---, [ข้อความ](https://...), ||สปอยล์||, @username
+//
+// HTML ที่ผู้ใช้พิมพ์มาจะถูก escape ทั้งหมด (ไม่มี whitelist tag) ลิงก์รับเฉพาะ http/https/mailto และใส่ rel=nofollow
+
+// NotificationMention ชนิดแจ้งเตือนเมื่อถูก @ ถึง
+const NotificationMention = "mention"
+
+var (
+	mdHeading    = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	mdUList      = regexp.MustCompile(`^\s*[-*+]\s+(.*)$`)
+	mdOList      = regexp.MustCompile(`^\s*\d{1,9}[.)]\s+(.*)$`)
+	mdRule       = regexp.MustCompile(`^(-\s*){3,}$|^(\*\s*){3,}$|^(_\s*){3,}$`)
+	mdCodeSpan   = regexp.MustCompile("`([^`\n]+)`")
+	mdLink       = regexp.MustCompile(`\[([^\]\n]+)\]\(\s*((?:[^()\s]|\([^()\s]*\))+)\s*\)`)
+	mdAutoLink   = regexp.MustCompile(`https?://[^\s<>"'\x00]+[^\s<>"'\x00.,;:!?)\]]`)
+	mdStrong     = regexp.MustCompile(`\*\*([^*\n]+)\*\*`)
+	mdEm         = regexp.MustCompile(`\*([^*\n]+)\*`)
+	mdStrike     = regexp.MustCompile(`~~([^~\n]+)~~`)
+	mdSpoiler    = regexp.MustCompile(`\|\|([^|\n]+)\|\|`)
+	mdMention    = regexp.MustCompile(`(^|[^\w@./])@([A-Za-z0-9_][A-Za-z0-9_.-]{1,31})`)
+	mdFenceStrip = regexp.MustCompile("(?s)```.*?(```|$)")
+	mdSlot       = regexp.MustCompile("\x00(\\d+)\x00")
+)
+
+// MentionNames ชื่อผู้ใช้ที่ถูก @ ในข้อความ (ไม่นับในโค้ด) ไม่ซ้ำ ตัวพิมพ์เล็ก
+func MentionNames(src string) []string {
+	src = mdFenceStrip.ReplaceAllString(src, " ")
+	src = mdCodeSpan.ReplaceAllString(src, " ")
+	seen := map[string]bool{}
+	var out []string
+	for _, m := range mdMention.FindAllStringSubmatch(src, -1) {
+		name := strings.ToLower(strings.TrimRight(m[2], ".-"))
+		if name != "" && !seen[name] {
+			seen[name] = true
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+// ResolveMentions username (ตัวพิมพ์เล็ก) → user id ของผู้ใช้ที่มีอยู่จริง
+func ResolveMentions(db *gorm.DB, names []string) map[string]uint {
+	out := map[string]uint{}
+	if len(names) == 0 {
+		return out
+	}
+	var users []entity.User
+	db.Select("id, username").Where("LOWER(username) IN ?", names).Find(&users)
+	for _, u := range users {
+		out[strings.ToLower(u.Username)] = u.ID
+	}
+	return out
+}
+
+// RenderMarkdown แปลงข้อความเป็น HTML ที่ปลอดภัย คืน user id ที่ถูก @ (ไม่ซ้ำ ตามลำดับที่พบ)
+func RenderMarkdown(db *gorm.DB, src string) (string, []uint) {
+	users := ResolveMentions(db, MentionNames(src))
+	r := &mdRenderer{users: users}
+	out := r.blocks(strings.Split(strings.ReplaceAll(strings.ReplaceAll(src, "\r\n", "\n"), "\x00", ""), "\n"))
+	return out, r.mentioned
+}
+
+type mdRenderer struct {
+	users     map[string]uint
+	mentioned []uint
+}
+
+func (r *mdRenderer) blocks(lines []string) string {
+	var b strings.Builder
+	var para []string
+	flush := func() {
+		if len(para) > 0 {
+			b.WriteString("<p>" + strings.Join(para, "<br>") + "</p>")
+			para = nil
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			flush()
+
+		case strings.HasPrefix(trimmed, "```"):
+			flush()
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```"); i++ {
+				code = append(code, lines[i])
+			}
+			b.WriteString("<pre><code>" + html.EscapeString(strings.Join(code, "\n")) + "</code></pre>")
+
+		case mdHeading.MatchString(trimmed):
+			flush()
+			m := mdHeading.FindStringSubmatch(trimmed)
+			n := len(m[1])
+			b.WriteString(fmt.Sprintf("<h%d>%s</h%d>", n, r.inline(m[2]), n))
+
+		case mdRule.MatchString(trimmed):
+			flush()
+			b.WriteString("<hr>")
+
+		case strings.HasPrefix(trimmed, ">"):
+			flush()
+			var inner []string
+			for ; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), ">"); i++ {
+				inner = append(inner, strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(lines[i]), ">"), " "))
+			}
+			i--
+			b.WriteString("<blockquote>" + r.blocks(inner) + "</blockquote>")
+
+		case mdUList.MatchString(line), mdOList.MatchString(line):
+			flush()
+			re, tag := mdUList, "ul"
+			if !mdUList.MatchString(line) {
+				re, tag = mdOList, "ol"
+			}
+			b.WriteString("<" + tag + ">")
+			for ; i < len(lines) && re.MatchString(lines[i]); i++ {
+				b.WriteString("<li>" + r.inline(re.FindStringSubmatch(lines[i])[1]) + "</li>")
+			}
+			i--
+			b.WriteString("</" + tag + ">")
+
+		default:
+			para = append(para, r.inline(trimmed))
+		}
+	}
+	flush()
+	return b.String()
+}
+
+// ลิงก์ที่อนุญาต: http(s), mailto และ path ภายในเว็บ
+// "//x" และ "/\x" เบราว์เซอร์ตีความเป็น protocol-relative (ออกนอกเว็บ) จึงไม่นับเป็น path ภายใน
+func safeURL(u string) bool {
+	if strings.ContainsRune(u, '\x00') {
+		return false
+	}
+	l := strings.ToLower(u)
+	return strings.HasPrefix(l, "http://") || strings.HasPrefix(l, "https://") ||
+		strings.HasPrefix(l, "mailto:") ||
+		(strings.HasPrefix(l, "/") && !strings.HasPrefix(l, "//") && !strings.HasPrefix(l, "/\\"))
+}
+
+func linkHTML(href, text string) string {
+	return `<a href="` + html.EscapeString(href) + `" rel="nofollow noopener noreferrer" target="_blank">` + text + `</a>`
+}
+
+// inline จัดรูปแบบในบรรทัด: ส่วนที่ไม่ต้องแปลงต่อ (โค้ด/ลิงก์/mention) เก็บไว้ใน slot ก่อน escape
+func (r *mdRenderer) inline(s string) string {
+	var slots []string
+	hold := func(h string) string {
+		slots = append(slots, h)
+		return "\x00" + strconv.Itoa(len(slots)-1) + "\x00"
+	}
+
+	s = mdCodeSpan.ReplaceAllStringFunc(s, func(m string) string {
+		return hold("<code>" + html.EscapeString(m[1:len(m)-1]) + "</code>")
+	})
+	s = mdLink.ReplaceAllStringFunc(s, func(m string) string {
+		p := mdLink.FindStringSubmatch(m)
+		if !safeURL(p[2]) {
+			return hold(html.EscapeString(p[1]))
+		}
+		return hold(linkHTML(p[2], html.EscapeString(p[1])))
+	})
+	s = mdAutoLink.ReplaceAllStringFunc(s, func(m string) string {
+		return hold(linkHTML(m, html.EscapeString(m)))
+	})
+	s = mdMention.ReplaceAllStringFunc(s, func(m string) string {
+		p := mdMention.FindStringSubmatch(m)
+		name := strings.TrimRight(p[2], ".-")
+		id, ok := r.users[strings.ToLower(name)]
+		if !ok {
+			return m
+		}
+		r.addMention(id)
+		return p[1] + hold(`<a class="mention" href="/users/`+strconv.FormatUint(uint64(id), 10)+`">@`+html.EscapeString(name)+`</a>`) + p[2][len(name):]
+	})
+
+	s = html.EscapeString(s)
+	s = mdSpoiler.ReplaceAllString(s, `<span class="spoiler">$1</span>`)
+	s = mdStrong.ReplaceAllString(s, "<strong>$1</strong>")
+	s = mdEm.ReplaceAllString(s, "<em>$1</em>")
+	s = mdStrike.ReplaceAllString(s, "<del>$1</del>")
+
+	// slot ซ้อนได้ (เช่น `โค้ด` ในข้อความลิงก์) — slot อ้างถึงได้เฉพาะ slot ที่สร้างก่อนหน้า จึงไม่วน
+	var resolve func(string) string
+	resolve = func(s string) string {
+		return mdSlot.ReplaceAllStringFunc(s, func(m string) string {
+			n, _ := strconv.Atoi(m[1 : len(m)-1])
+			return resolve(slots[n])
+		})
+	}
+	return resolve(s)
+}
+
+func (r *mdRenderer) addMention(id uint) {
+	for _, x := range r.mentioned {
+		if x == id {
+			return
+		}
+	}
+	r.mentioned = append(r.mentioned, id)
+}
+
+// NotifyMentions แจ้งผู้ถูก @ (ยกเว้นผู้เขียนเองและคนที่เคยถูกแจ้งจากโพสต์นี้แล้ว)
+// n คือแม่แบบ (title/message/อ้างอิงเนื้อหา) ส่วน UserID/Type จะถูกตั้งให้
+func NotifyMentions(db *gorm.DB, authorID uint, mentioned, previously []uint, n entity.Notification) error {
+	skip := map[uint]bool{authorID: true}
+	for _, id := range previously {
+		skip[id] = true
+	}
+	for _, uid := range mentioned {
+		if skip[uid] {
+			continue
+		}
+		row := n
+		row.UserID = uid
+		row.Type = NotificationMention
+		if err := Notify(db, &row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"example.com/sa-gameshop/entity"
+	"gorm.io/gorm"
+)
+
+// สิทธิ์ผู้ดูแลคอมมูนิตี้ (แก้/ลบเนื้อหาของคนอื่นได้ ต้องระบุเหตุผล)
+const PermCommunityModerate = "community.moderate"
+
+var (
+	ErrNotAuthor        = errors.New("forbidden")
+	ErrModerationReason = errors.New("reason required for moderator action")
+)
+
+// AuthorizeCommunityWrite เจ้าของทำได้เสมอ คนอื่นต้องมี community.moderate และระบุเหตุผล
+// คืน true ถ้าเป็นการกระทำของผู้ดูแล (ผู้เรียกต้อง LogModeration)
+func AuthorizeCommunityWrite(db *gorm.DB, userID, authorID uint, reason string) (bool, error) {
+	return AuthorizeModeratedWrite(db, userID, authorID, PermCommunityModerate, reason)
+}
+
+// AuthorizeModeratedWrite เหมือน AuthorizeCommunityWrite แต่ระบุสิทธิ์ผู้ดูแลเอง (เช่น reviews.moderate)
+func AuthorizeModeratedWrite(db *gorm.DB, userID, authorID uint, perm, reason string) (bool, error) {
+	if userID != 0 && userID == authorID {
+		return false, nil
+	}
+	if !UserHasPermission(db, userID, perm) {
+		return false, ErrNotAuthor
+	}
+	if strings.TrimSpace(reason) == "" {
+		return true, ErrModerationReason
+	}
+	return true, nil
+}
+
+// LogModeration บันทึกการกระทำของผู้ดูแล
+func LogModeration(db *gorm.DB, actorID uint, targetType string, targetID, authorID uint, action, reason, snapshot string) error {
+	return db.Create(&entity.ModerationLog{
+		ActorID:    actorID,
+		TargetType: targetType,
+		TargetID:   targetID,
+		AuthorID:   authorID,
+		Action:     action,
+		Reason:     strings.TrimSpace(reason),
+		Snapshot:   snapshot,
+	}).Error
+}
+
+// ===== รายงานเนื้อหา / คิวผู้ดูแล =====
+
+// ชนิดแจ้งเตือนงานผู้ดูแล (Notification.Type)
+const (
+	NotificationReportResult     = "moderation_report" // ถึงผู้รายงาน
+	NotificationModerationAction = "moderation_action" // ถึงเจ้าของเนื้อหา
+	NotificationAppealResult     = "moderation_appeal"
+)
+
+// action ที่ผู้ดูแลใช้กับเนื้อหาได้
+const (
+	ActionHide    = "hide"
+	ActionDelete  = "delete"
+	ActionLock    = "lock"
+	ActionWarn    = "warn"
+	ActionMute    = "mute"
+	ActionBan     = "ban"
+	ActionDismiss = "dismiss" // ปิดรายงานโดยไม่ทำอะไร
+)
+
+// ระยะเวลาที่เจ้าของเนื้อหายื่นอุทธรณ์ได้หลังโดน action
+const AppealWindow = 30 * 24 * time.Hour
+
+var (
+	ErrUnknownTarget  = errors.New("unknown target_type")
+	ErrTargetNotFound = errors.New("target not found")
+)
+
+type moderationKind struct {
+	Table string
+	Perm  string // สิทธิ์ผู้ดูแลของเนื้อหาชนิดนี้
+	Scope string // ขอบเขตเมื่อ mute เจ้าของ
+}
+
+var moderationKinds = map[string]moderationKind{
+	"thread":  {"threads", PermCommunityModerate, entity.RestrictCommunity},
+	"comment": {"comments", PermCommunityModerate, entity.RestrictCommunity},
+	"review":  {"reviews", "reviews.moderate", entity.RestrictReviews},
+	"mod":     {"mods", "workshop.moderate", entity.RestrictWorkshop},
+}
+
+// ModerationPerms สิทธิ์ผู้ดูแลทั้งหมด (มีอย่างใดอย่างหนึ่งก็เข้าคิวได้)
+var ModerationPerms = []string{PermCommunityModerate, "reviews.moderate", "workshop.moderate"}
+
+var reportCategories = []string{
+	entity.ReportSpam, entity.ReportHarassment, entity.ReportHate, entity.ReportSexual,
+	entity.ReportSpoiler, entity.ReportMalware, entity.ReportOther,
+}
+
+func ValidReportCategory(s string) bool {
+	for _, c := range reportCategories {
+		if c == s {
+			return true
+		}
+	}
+	return false
+}
+
+// ModerationPermFor สิทธิ์ที่ต้องใช้จัดการเนื้อหาชนิดนี้
+func ModerationPermFor(targetType string) (string, bool) {
+	k, ok := moderationKinds[targetType]
+	return k.Perm, ok
+}
+
+// ModeratableTypes ชนิดเนื้อหาที่ผู้ใช้นี้จัดการได้
+func ModeratableTypes(db *gorm.DB, userID uint) []string {
+	var out []string
+	for _, t := range []string{"thread", "comment", "review", "mod"} {
+		if UserHasPermission(db, userID, moderationKinds[t].Perm) {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// ModerationTarget เนื้อหาที่ถูกรายงาน/ดำเนินการ (โหลดรวมที่ถูกลบแล้วด้วย)
+type ModerationTarget struct {
+	Type     string `json:"target_type"`
+	ID       uint   `json:"target_id"`
+	AuthorID uint   `json:"author_id"`
+	ThreadID uint   `json:"thread_id,omitempty"` // คอมเมนต์: เธรดที่สังกัด
+	Title    string `json:"title"`
+	Content  string `json:"content"`
+	Hidden   bool   `json:"hidden"`
+	Locked   bool   `json:"locked,omitempty"`
+	Removed  bool   `json:"removed"`
+}
+
+// Snapshot เนื้อหาเต็มเก็บใน log (ใช้คืนค่าคอมเมนต์ตอนอุทธรณ์สำเร็จ)
+func (t ModerationTarget) Snapshot() string {
+	if t.Title == "" {
+		return t.Content
+	}
+	return t.Title + "\n\n" + t.Content
+}
+
+func LoadModerationTarget(db *gorm.DB, targetType string, id uint) (ModerationTarget, error) {
+	t := ModerationTarget{Type: targetType, ID: id}
+	q := db.Unscoped()
+	var found int64
+	switch targetType {
+	case "thread":
+		var r entity.Thread
+		found = q.Limit(1).Find(&r, id).RowsAffected
+		t.AuthorID, t.Title, t.Content, t.Hidden, t.Locked = r.UserID, r.Title, r.Content, r.Hidden, r.Locked
+		t.Removed = r.DeletedAt.Valid
+	case "comment":
+		var r entity.Comment
+		found = q.Limit(1).Find(&r, id).RowsAffected
+		t.AuthorID, t.ThreadID, t.Content, t.Hidden = r.UserID, r.ThreadID, r.Content, r.Hidden
+		t.Removed = r.DeletedAt.Valid || r.Removed
+	case "review":
+		var r entity.Review
+		found = q.Limit(1).Find(&r, id).RowsAffected
+		t.AuthorID, t.Title, t.Content, t.Hidden = r.UserID, r.ReviewTitle, r.ReviewText, r.Hidden
+		t.Removed = r.DeletedAt.Valid
+	case "mod":
+		var r entity.Mod
+		found = q.Limit(1).Find(&r, id).RowsAffected
+		t.AuthorID, t.Title, t.Content, t.Hidden = r.UserID, r.Title, r.Description, r.Hidden
+		t.Removed = r.DeletedAt.Valid
+	default:
+		return t, ErrUnknownTarget
+	}
+	if found == 0 {
+		return t, ErrTargetNotFound
+	}
+	return t, nil
+}
+
+// SetTargetHidden ซ่อน/เลิกซ่อนเนื้อหา
+func SetTargetHidden(tx *gorm.DB, targetType string, id uint, hidden bool) error {
+	k, ok := moderationKinds[targetType]
+	if !ok {
+		return ErrUnknownTarget
+	}
+	return tx.Table(k.Table).Where("id = ?", id).Update("hidden", hidden).Error
+}
+
+// RestoreTarget เอาเนื้อหาที่ถูกลบ (soft delete) กลับมา
+func RestoreTarget(tx *gorm.DB, targetType string, id uint) error {
+	k, ok := moderationKinds[targetType]
+	if !ok {
+		return ErrUnknownTarget
+	}
+	return tx.Table(k.Table).Where("id = ?", id).Update("deleted_at", nil).Error
+}
+
+// MuteScopeFor ขอบเขตการ mute ตามชนิดเนื้อหาที่ทำผิด
+func MuteScopeFor(targetType string) string {
+	return moderationKinds[targetType].Scope
+}
+
+// NotifyUser สร้างแจ้งเตือนทั่วไป
+func NotifyUser(db *gorm.DB, userID uint, typ, title, msg string) error {
+	if userID == 0 {
+		return nil
+	}
+	return Notify(db, &entity.Notification{
+		Title:   title,
+		Type:    typ,
+		Message: msg,
+		UserID:  userID,
+	})
+}
@@ -0,0 +1,243 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"example.com/sa-gameshop/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ชนิดแจ้งเตือนคอมมูนิตี้ (Notification.Type)
+const (
+	NotificationThreadReply  = "thread_reply"  // ความเห็นใหม่ในกระทู้ที่ติดตาม
+	NotificationCommentReply = "comment_reply" // มีคนตอบความเห็นของเรา
+	NotificationSystem       = "system"
+)
+
+type NotificationType struct {
+	Key      string `json:"key"`
+	Label    string `json:"label"`
+	Required bool   `json:"required"` // ปิดไม่ได้
+}
+
+// NotificationTypes ชนิดแจ้งเตือนทั้งหมดที่ผู้ใช้ตั้งค่าได้ (แถวของตารางตั้งค่า)
+var NotificationTypes = []NotificationType{
+	{NotificationThreadReply, "ความเห็นใหม่ในกระทู้ที่ติดตาม", false},
+	{NotificationCommentReply, "มีคนตอบความเห็นของคุณ", false},
+	{NotificationMention, "มีคนกล่าวถึงคุณ", false},
+	{NotificationWishlistPrice, "เกมในรายการที่อยากได้ลดราคา", false},
+	{NotificationWishlistRelease, "เกมในรายการที่อยากได้วางขาย", false},
+	{NotificationGiftReceived, "ได้รับของขวัญ", false},
+	{NotificationGiftClaimed, "ของขวัญที่ส่งถูกรับแล้ว", false},
+	{NotificationGiftDeclined, "ของขวัญที่ส่งถูกปฏิเสธ", false},
+	{"report_new", "มีคำร้องใหม่ (ผู้ดูแล)", false},
+	{"report_reply", "ตอบกลับคำร้อง", false},
+	{"report_resolved", "ปิดงานคำร้อง", false},
+	{NotificationReportResult, "ผลการรายงานเนื้อหา", false},
+	{NotificationModerationAction, "การดำเนินการของผู้ดูแล", true},
+	{NotificationAppealResult, "ผลการอุทธรณ์", true},
+	{NotificationSystem, "ประกาศจากระบบ", true},
+}
+
+var (
+	ErrUnknownNotificationType  = errors.New("unknown notification type")
+	ErrRequiredNotificationType = errors.New("notification type cannot be disabled")
+)
+
+func notificationType(key string) (NotificationType, bool) {
+	for _, t := range NotificationTypes {
+		if t.Key == key {
+			return t, true
+		}
+	}
+	return NotificationType{}, false
+}
+
+// NotificationEnabled ผู้ใช้เปิดรับแจ้งเตือนชนิดนี้ (ชนิดบังคับ/ไม่รู้จัก = เปิดเสมอ)
+func NotificationEnabled(db *gorm.DB, userID uint, typ string) bool {
+	if t, ok := notificationType(typ); !ok || t.Required {
+		return true
+	}
+	var p entity.NotificationPreference
+	db.Where("user_id = ? AND type = ?", userID, typ).Limit(1).Find(&p)
+	return p.ID == 0 || p.Enabled
+}
+
+// Notify สร้างแจ้งเตือนถ้าผู้รับเปิดรับชนิดนี้ไว้ (ปิดไว้ = ข้ามเงียบ ๆ)
+func Notify(db *gorm.DB, n *entity.Notification) error {
+	if n.UserID == 0 || !NotificationEnabled(db, n.UserID, n.Type) {
+		return nil
+	}
+	if err := db.Create(n).Error; err != nil {
+		return err
+	}
+	PublishNotification(db, *n)
+	return nil
+}
+
+type NotificationPreferenceView struct {
+	NotificationType
+	Enabled bool `json:"enabled"`
+}
+
+// NotificationPreferences ตารางตั้งค่าของผู้ใช้ (ครบทุกชนิด)
+func NotificationPreferences(db *gorm.DB, userID uint) ([]NotificationPreferenceView, error) {
+	var rows []entity.NotificationPreference
+	if err := db.Where("user_id = ?", userID).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	saved := map[string]bool{}
+	for _, r := range rows {
+		saved[r.Type] = r.Enabled
+	}
+	out := make([]NotificationPreferenceView, 0, len(NotificationTypes))
+	for _, t := range NotificationTypes {
+		enabled, ok := saved[t.Key]
+		out = append(out, NotificationPreferenceView{NotificationType: t, Enabled: t.Required || !ok || enabled})
+	}
+	return out, nil
+}
+
+// SetNotificationPreferences บันทึกหลายชนิดพร้อมกัน {"thread_reply": false, ...}
+// ชนิดบังคับปิดไม่ได้ (ส่ง true มาได้ ไม่มีผล)
+func SetNotificationPreferences(db *gorm.DB, userID uint, prefs map[string]bool) error {
+	for key, enabled := range prefs {
+		t, ok := notificationType(key)
+		if !ok {
+			return ErrUnknownNotificationType
+		}
+		if t.Required && !enabled {
+			return ErrRequiredNotificationType
+		}
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		for key, enabled := range prefs {
+			if err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "user_id"}, {Name: "type"}},
+				DoUpdates: clause.AssignmentColumns([]string{"enabled", "updated_at"}),
+			}).Create(&entity.NotificationPreference{UserID: userID, Type: key, Enabled: enabled}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// replaceNotification แทนแถวเดิมด้วยแถวใหม่ (id/created_at ใหม่)
+// แจ้งเตือนที่ถูกรวมจึงขึ้นบนสุดเมื่อเรียง/แบ่งหน้าด้วย id DESC
+func replaceNotification(db *gorm.DB, old entity.Notification, n *entity.Notification) error {
+	n.Model = gorm.Model{}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Delete(&entity.Notification{}, old.ID).Error; err != nil {
+			return err
+		}
+		return tx.Create(n).Error
+	})
+}
+
+// batchThreadNotification รวมแจ้งเตือนชนิดเดียวกันของกระทู้เดียวกันที่ยังไม่อ่านไว้ในแถวเดียว
+// ครั้งแรกใช้ข้อความ one, ครั้งต่อไปนับเพิ่มแล้วใช้ many(จำนวน)
+func batchThreadNotification(db *gorm.DB, userID uint, typ, title string, threadID, commentID uint, one string, many func(int) string) error {
+	if !NotificationEnabled(db, userID, typ) {
+		return nil
+	}
+	var n entity.Notification
+	if err := db.Where("user_id = ? AND thread_id = ? AND type = ? AND is_read = ?", userID, threadID, typ, false).
+		Order("id DESC").Limit(1).Find(&n).Error; err != nil {
+		return err
+	}
+	if n.ID != 0 {
+		cid := commentID
+		merged := n
+		merged.Count = n.Count + 1
+		merged.Message = many(merged.Count)
+		merged.CommentID = &cid
+		if err := replaceNotification(db, n, &merged); err != nil {
+			return err
+		}
+		PublishNotification(db, merged)
+		return nil
+	}
+	tid, cid := threadID, commentID
+	n = entity.Notification{
+		Title:     title,
+		Type:      typ,
+		Message:   one,
+		UserID:    userID,
+		ThreadID:  &tid,
+		CommentID: &cid,
+	}
+	if err := db.Create(&n).Error; err != nil {
+		return err
+	}
+	PublishNotification(db, n)
+	return nil
+}
+
+// ระยะเก็บแจ้งเตือน (วัน): อ่านแล้ว NOTIFICATION_READ_RETENTION_DAYS (90), ทุกแถว NOTIFICATION_RETENTION_DAYS (365)
+func notificationRetention() (read, all time.Duration) {
+	days := func(key string, def int) time.Duration {
+		if v, err := strconv.Atoi(os.Getenv(key)); err == nil && v > 0 {
+			def = v
+		}
+		return time.Duration(def) * 24 * time.Hour
+	}
+	return days("NOTIFICATION_READ_RETENTION_DAYS", 90), days("NOTIFICATION_RETENTION_DAYS", 365)
+}
+
+// CleanupNotifications ลบแจ้งเตือนที่เกินระยะเก็บ (ลบจริง) คืนจำนวนที่ลบ
+func CleanupNotifications(db *gorm.DB, now time.Time) (int64, error) {
+	read, all := notificationRetention()
+	res := db.Unscoped().
+		Where("(is_read = ? AND created_at < ?) OR created_at < ?", true, now.Add(-read), now.Add(-all)).
+		Delete(&entity.Notification{})
+	return res.RowsAffected, res.Error
+}
+
+// StartNotificationCleanup เก็บกวาดตอนเริ่มและทุก interval
+func StartNotificationCleanup(db *gorm.DB, interval time.Duration) {
+	run := func(now time.Time) {
+		if n, err := CleanupNotifications(db, now); err != nil {
+			log.Println("[Notification] cleanup error:", err)
+		} else if n > 0 {
+			log.Printf("[Notification] cleanup removed %d", n)
+		}
+	}
+	run(time.Now())
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			run(now)
+		}
+	}()
+}
+
+// BroadcastNotification ประกาศจากระบบถึงผู้ใช้ทุกคน หรือเฉพาะ role ใน roleIDs คืนจำนวนผู้รับ
+func BroadcastNotification(db *gorm.DB, title, msg string, roleIDs []uint) (int, error) {
+	q := db.Model(&entity.User{})
+	if len(roleIDs) > 0 {
+		q = q.Where("role_id IN ?", roleIDs)
+	}
+	var ids []uint
+	if err := q.Pluck("id", &ids).Error; err != nil || len(ids) == 0 {
+		return 0, err
+	}
+	rows := make([]entity.Notification, 0, len(ids))
+	for _, id := range ids {
+		rows = append(rows, entity.Notification{Title: title, Type: NotificationSystem, Message: msg, UserID: id})
+	}
+	if err := db.CreateInBatches(&rows, 200).Error; err != nil {
+		return 0, err
+	}
+	for _, n := range rows {
+		PublishNotification(db, n)
+	}
+	return len(rows), nil
+}
@@ -0,0 +1,23 @@
+package services
+
+import (
+	"example.com/sa-gameshop/entity"
+	"gorm.io/gorm"
+)
+
+// UserHasPermission เช็คว่า role ของผู้ใช้มี permission key ใดๆ ในรายการหรือไม่
+func UserHasPermission(db *gorm.DB, userID uint, keys ...string) bool {
+	if userID == 0 || len(keys) == 0 {
+		return false
+	}
+	var cnt int64
+	if err := db.Model(&entity.RolePermission{}).
+		Joins("JOIN users u ON u.role_id = role_permissions.role_id").
+		Joins("JOIN permissions p ON p.id = role_permissions.permission_id").
+		Where("u.id = ? AND p.key IN ?", userID, keys).
+		Where("u.deleted_at IS NULL AND p.deleted_at IS NULL").
+		Count(&cnt).Error; err != nil {
+		return false
+	}
+	return cnt > 0
+}
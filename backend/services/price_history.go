@@ -0,0 +1,217 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"example.com/sa-gameshop/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ช่วงเวลาที่ใช้หา "ราคาต่ำสุดก่อนลดราคา" (ตามกฎหมายคุ้มครองผู้บริโภค)
+const LowestPriceWindow = 30 * 24 * time.Hour
+
+// SnapshotGamePrices บันทึกราคาปัจจุบันของเกมในทุก region
+// บันทึกเฉพาะ region ที่ราคาต่างจากแถวล่าสุด จึงเรียกซ้ำได้อย่างปลอดภัย
+func SnapshotGamePrices(db *gorm.DB, gameID uint, reason entity.PriceChangeReason, promotionID *uint, at time.Time) error {
+	var g entity.Game
+	if err := db.Preload("RegionPrices").First(&g, gameID).Error; err != nil {
+		return err
+	}
+	var regions []entity.Region
+	if err := db.Preload("Currency").Find(&regions).Error; err != nil {
+		return err
+	}
+
+	for _, r := range regions {
+		base := RegionalBasePrice(g, r)
+		price, err := GetDiscountedPriceForGame(db, g.ID, r, at)
+		if err != nil {
+			return err
+		}
+
+		// แคชราคาปัจจุบันเสมอ (แม้ประวัติจะไม่เปลี่ยน)
+		if err := db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&entity.GameCurrentPrice{
+			GameID:    g.ID,
+			RegionID:  r.ID,
+			BasePrice: base,
+			Price:     price,
+			OnSale:    price < base,
+			UpdatedAt: at,
+		}).Error; err != nil {
+			return err
+		}
+
+		var last entity.GamePriceHistory
+		err = db.Where("game_id = ? AND region_id = ?", g.ID, r.ID).
+			Order("effective_at DESC, id DESC").
+			First(&last).Error
+		if err == nil && last.Price == price && last.BasePrice == base {
+			continue
+		}
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		row := entity.GamePriceHistory{
+			GameID:      g.ID,
+			RegionID:    r.ID,
+			EffectiveAt: at,
+			BasePrice:   base,
+			Price:       price,
+			Reason:      reason,
+			PromotionID: promotionID,
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) && reason != entity.PriceBaseChange {
+			row.Reason = entity.PriceInitial
+		}
+		if r.Currency != nil {
+			row.Currency = r.Currency.Code
+		}
+		if err := db.Create(&row).Error; err != nil {
+			return err
+		}
+
+		// ราคาเปลี่ยน → แจ้งคนที่มีเกมนี้ใน wishlist
+		if err := NotifyWishlistPrice(db, g, r, price, at); err != nil {
+			log.Println("[Wishlist] price notify error:", err)
+		}
+	}
+	return nil
+}
+
+// SnapshotGamesPrices เหมือน SnapshotGamePrices แต่หลายเกม (ข้ามเกมที่ซ้ำ)
+func SnapshotGamesPrices(db *gorm.DB, gameIDs []uint, reason entity.PriceChangeReason, promotionID *uint, at time.Time) error {
+	seen := map[uint]bool{}
+	for _, id := range gameIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if err := SnapshotGamePrices(db, id, reason, promotionID, at); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SnapshotAllGamePrices ตรวจราคาของทุกเกม (ใช้ตอนเปิดเซิร์ฟเวอร์/เปลี่ยนอัตราแลกเปลี่ยน)
+func SnapshotAllGamePrices(db *gorm.DB, reason entity.PriceChangeReason, at time.Time) error {
+	var ids []uint
+	if err := db.Model(&entity.Game{}).Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+	return SnapshotGamesPrices(db, ids, reason, nil, at)
+}
+
+// LowestPrices คืน "ราคาต่ำสุดก่อนลดราคา" ของแต่ละเกมใน region ณ เวลา at
+// เกมที่กำลังลดราคา = ราคาต่ำสุดใน LowestPriceWindow ก่อนการลดครั้งนี้เริ่ม (ไม่นับราคาช่วงโปรปัจจุบัน)
+// เกมที่ไม่ได้ลด = ราคาต่ำสุดใน LowestPriceWindow ล่าสุด
+// นับรวมราคาที่มีผลอยู่ตอนเริ่มช่วง (แถวล่าสุดก่อนต้นช่วง) ด้วย / gameIDs ว่าง = ทุกเกม
+func LowestPrices(db *gorm.DB, regionID uint, gameIDs []uint, at time.Time) (map[uint]float64, error) {
+	q := db.Model(&entity.GamePriceHistory{}).
+		Select("game_id, effective_at, base_price, price").
+		Where("region_id = ? AND effective_at <= ?", regionID, at)
+	if len(gameIDs) > 0 {
+		q = q.Where("game_id IN ?", gameIDs)
+	}
+	var rows []entity.GamePriceHistory
+	if err := q.Order("game_id, effective_at, id").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	out := map[uint]float64{}
+	for start := 0; start < len(rows); {
+		end := start
+		for end < len(rows) && rows[end].GameID == rows[start].GameID {
+			end++
+		}
+		if v, ok := lowestBeforeReduction(rows[start:end], at); ok {
+			out[rows[start].GameID] = v
+		}
+		start = end
+	}
+	return out, nil
+}
+
+// rows = ประวัติของเกมเดียวเรียงตามเวลา
+func lowestBeforeReduction(rows []entity.GamePriceHistory, at time.Time) (float64, bool) {
+	// ถอยจากแถวล่าสุดจนเจอราคาเต็ม → ต้นช่วงลดราคาปัจจุบัน
+	cut := len(rows)
+	for cut > 0 && rows[cut-1].Price < rows[cut-1].BasePrice {
+		cut--
+	}
+	until, inclusive := at, true
+	if cut < len(rows) {
+		until, inclusive = rows[cut].EffectiveAt, false
+	}
+	since := until.Add(-LowestPriceWindow)
+
+	lowest, found := 0.0, false
+	for i, r := range rows {
+		if r.EffectiveAt.After(until) || (!inclusive && !r.EffectiveAt.Before(until)) {
+			break
+		}
+		// แถวก่อนต้นช่วงนับเฉพาะแถวที่ยังมีผลอยู่ตอนต้นช่วง
+		if r.EffectiveAt.Before(since) && i+1 < len(rows) && rows[i+1].EffectiveAt.Before(since) {
+			continue
+		}
+		if !found || r.Price < lowest {
+			lowest, found = r.Price, true
+		}
+	}
+	return lowest, found
+}
+
+// StartPriceHistoryWatcher บันทึกราคาเมื่อโปรโมชันเริ่ม/จบตามเวลา
+// ตอนเริ่มจะ sync ทุกเกมหนึ่งรอบ (ชดเชยช่วงที่เซิร์ฟเวอร์ปิดอยู่)
+func StartPriceHistoryWatcher(db *gorm.DB, interval time.Duration) {
+	last := time.Now()
+	if err := SnapshotAllGamePrices(db, entity.PriceSync, last); err != nil {
+		log.Println("[PriceHistory] initial sync error:", err)
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			if err := snapshotPromotionBoundaries(db, last, now); err != nil {
+				log.Println("[PriceHistory] watcher error:", err)
+			}
+			last = now
+		}
+	}()
+}
+
+// หาโปรที่เริ่มหรือจบในช่วง (from, to] แล้วบันทึกราคาของเกมในโปรนั้น
+func snapshotPromotionBoundaries(db *gorm.DB, from, to time.Time) error {
+	type edge struct {
+		PromotionID uint
+		GameID      uint
+		StartDate   time.Time
+	}
+	var rows []edge
+	if err := db.Raw(`
+		SELECT p.id AS promotion_id, pg.game_id, p.start_date
+		FROM promotions p
+		JOIN promotion_games pg ON pg.promotion_id = p.id
+		WHERE p.deleted_at IS NULL AND p.status = 1
+		  AND ((p.start_date > ? AND p.start_date <= ?) OR (p.end_date > ? AND p.end_date <= ?))
+	`, from, to, from, to).Scan(&rows).Error; err != nil {
+		return err
+	}
+
+	for _, r := range rows {
+		reason := entity.PricePromotionEnd
+		if r.StartDate.After(from) && !r.StartDate.After(to) {
+			reason = entity.PricePromotionStart
+		}
+		pid := r.PromotionID
+		if err := SnapshotGamePrices(db, r.GameID, reason, &pid, to); err != nil {
+			return err
+		}
+	}
+	return nil
+}
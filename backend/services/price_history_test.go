@@ -0,0 +1,59 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"example.com/sa-gameshop/entity"
+)
+
+func TestLowestPricesIgnoresCurrentSale(t *testing.T) {
+	db, region, game := newPricingTestDB(t)
+	other := entity.Game{GameName: "other", BasePrice: 100}
+	fresh := entity.Game{GameName: "fresh", BasePrice: 100}
+	db.Omit("MinimumSpec").Create(&other)
+	db.Omit("MinimumSpec").Create(&fresh)
+
+	now := time.Now()
+	day := 24 * time.Hour
+	add := func(gameID uint, ago time.Duration, base, price float64) {
+		t.Helper()
+		if err := db.Create(&entity.GamePriceHistory{GameID: gameID, RegionID: region.ID,
+			EffectiveAt: now.Add(-ago), BasePrice: base, Price: price}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	// game: ลด 80 เมื่อ 20 วันก่อน, กลับ 100, แล้วลดเหลือ 50 ตั้งแต่ 5 วันก่อน (กำลังลดอยู่)
+	add(game.ID, 60*day, 100, 100)
+	add(game.ID, 45*day, 100, 70) // ก่อนช่วง 30 วันของการลดครั้งนี้ → ไม่นับ
+	add(game.ID, 42*day, 100, 100)
+	add(game.ID, 20*day, 100, 80)
+	add(game.ID, 15*day, 100, 100)
+	add(game.ID, 5*day, 100, 60)
+	add(game.ID, 2*day, 100, 50) // โปรเปลี่ยนระหว่างลด ยังนับเป็นการลดครั้งเดียวกัน
+	// other: ไม่ได้ลด แค่เปลี่ยนราคาปกติ
+	add(other.ID, 60*day, 120, 120)
+	add(other.ID, 10*day, 100, 100)
+	// fresh: ลดตั้งแต่แถวแรก ไม่มีราคาก่อนลดให้เทียบ
+	add(fresh.ID, 3*day, 100, 40)
+
+	got, err := LowestPrices(db, region.ID, nil, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got[game.ID] != 80 {
+		t.Errorf("on sale: lowest = %v, want 80 (not the current sale price)", got[game.ID])
+	}
+	if got[other.ID] != 100 {
+		t.Errorf("not on sale: lowest = %v, want 100", got[other.ID])
+	}
+	if v, ok := got[fresh.ID]; ok {
+		t.Errorf("sale without prior price: lowest = %v, want none", v)
+	}
+
+	// ก่อนการลดครั้งนี้เริ่ม → ช่วง 30 วันล่าสุด ณ ตอนนั้น (นับราคา 80)
+	got, _ = LowestPrices(db, region.ID, []uint{game.ID}, now.Add(-10*day))
+	if got[game.ID] != 80 {
+		t.Errorf("before sale: lowest = %v, want 80", got[game.ID])
+	}
+}
@@ -11,41 +11,54 @@ import (
 // ปัดทศนิยม 2 ตำแหน่ง
 func round2(v float64) float64 { return math.Round(v*100) / 100 }
 
-// GetDiscountedPriceForGame คืน "ราคาสุทธิ" ของเกม ณ เวลานั้นๆ โดยพยายามมองหาโปรโมชันที่ active อยู่
-// - ถ้าหาโปรไม่ได้/สคีม่าไม่ตรง → fallback เป็นราคาปกติของเกม (base price)
-func GetDiscountedPriceForGame(db *gorm.DB, gameID uint, now time.Time) (float64, error) {
+// ApplyPromotionsInRegion เลือกโปรที่ให้ "ราคาต่ำสุด" จากราคาใน region
+// ส่วนลดแบบ AMOUNT ตั้งไว้เป็นสกุลหลัก จึงต้องแปลงเป็นสกุลของ region ก่อนหัก
+// ราคาหลังลดปัดแค่ทศนิยม (ไม่ใช้นโยบายปัดขึ้น) เพื่อไม่ให้ส่วนลดหายไป
+func ApplyPromotionsInRegion(base float64, promos []entity.Promotion, region entity.Region) float64 {
+	price := base
+	for _, p := range promos {
+		discounted := base
+		switch p.DiscountType {
+		case entity.DiscountAmount:
+			if p.DiscountValue > 0 {
+				discounted = base - ConvertFromBase(float64(p.DiscountValue), region.Currency)
+			}
+		default:
+			discounted = ApplyDiscount(base, p.DiscountType, p.DiscountValue)
+		}
+		if discounted < price {
+			price = discounted
+		}
+	}
+	if price < 0 {
+		price = 0
+	}
+	if price == base {
+		return base
+	}
+	return roundDecimals(price, region.Currency)
+}
+
+// GetDiscountedPriceForGame คืน "ราคาสุทธิ" ของเกมใน region ณ เวลานั้นๆ โดยพยายามมองหาโปรโมชันที่ active อยู่
+// - ถ้าหาโปรไม่ได้/สคีม่าไม่ตรง → fallback เป็นราคาปกติของเกมใน region นั้น
+func GetDiscountedPriceForGame(db *gorm.DB, gameID uint, region entity.Region, now time.Time) (float64, error) {
 	var g entity.Game
-	if err := db.First(&g, gameID).Error; err != nil {
+	if err := db.Preload("RegionPrices", "region_id = ?", region.ID).First(&g, gameID).Error; err != nil {
 		return 0, err
 	}
-	base := float64(g.BasePrice)
+	base := RegionalBasePrice(g, region)
 
 	// อ่านโปรโมชันที่กำลังใช้งานอยู่
-	type promoRow struct {
-		DiscountType  entity.DiscountType
-		DiscountValue int
-	}
-	var promos []promoRow
+	var promos []entity.Promotion
 	if err := db.Raw(`
                 SELECT p.discount_type, p.discount_value
                 FROM promotions p
                 JOIN promotion_games pg ON pg.promotion_id = p.id
-                WHERE pg.game_id = ? AND p.status = 1
+                WHERE pg.game_id = ? AND p.status = 1 AND p.deleted_at IS NULL
                       AND p.start_date <= ? AND p.end_date >= ?
         `, gameID, now, now).Scan(&promos).Error; err != nil {
 		return 0, err
 	}
 
-	// เลือกวิธีลดที่ให้ "ราคาต่ำสุด" อย่างปลอดภัย
-	price := base
-	for _, p := range promos {
-		if discounted := ApplyDiscount(base, p.DiscountType, p.DiscountValue); discounted < price {
-			price = discounted
-		}
-	}
-
-	if price < 0 {
-		price = 0
-	}
-	return round2(price), nil
+	return ApplyPromotionsInRegion(base, promos, region), nil
 }
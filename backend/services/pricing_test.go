@@ -0,0 +1,85 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"example.com/sa-gameshop/entity"
+	"gorm.io/gorm"
+)
+
+// ร้านทดสอบ: region TH (THB อัตรา 1) เกมราคา 100
+func newPricingTestDB(t *testing.T) (*gorm.DB, entity.Region, entity.Game) {
+	t.Helper()
+	db := newTestDB(t, &entity.Currency{}, &entity.Region{}, &entity.Game{}, &entity.GameRegionPrice{},
+		&entity.Promotion{}, &entity.Promotion_Game{}, &entity.GamePriceHistory{}, &entity.GameCurrentPrice{},
+		&entity.User{}, &entity.UserGame{}, &entity.WishlistItem{})
+	cur := entity.Currency{Code: "THB", Decimals: 2, RateFromBase: 1, RoundingMode: entity.RoundNone}
+	if err := db.Create(&cur).Error; err != nil {
+		t.Fatal(err)
+	}
+	region := entity.Region{Code: "TH", IsDefault: true, CurrencyID: cur.ID, Currency: &cur}
+	if err := db.Omit("Currency").Create(&region).Error; err != nil {
+		t.Fatal(err)
+	}
+	game := entity.Game{GameName: "g", BasePrice: 100}
+	if err := db.Omit("MinimumSpec").Create(&game).Error; err != nil {
+		t.Fatal(err)
+	}
+	return db, region, game
+}
+
+// สร้างโปรลด percent% ที่ใช้ได้ตั้งแต่ start ถึง end
+func addPromotion(t *testing.T, db *gorm.DB, gameID uint, percent int, start, end time.Time) entity.Promotion {
+	t.Helper()
+	p := entity.Promotion{Title: "sale", DiscountType: entity.DiscountPercent, DiscountValue: percent,
+		StartDate: start, EndDate: end, Status: true}
+	if err := db.Omit("Games", "PromotionGames", "User").Create(&p).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&entity.Promotion_Game{PromotionID: p.ID, GameID: gameID}).Error; err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func currentPrice(t *testing.T, db *gorm.DB, gameID, regionID uint) entity.GameCurrentPrice {
+	t.Helper()
+	var cp entity.GameCurrentPrice
+	if err := db.Where("game_id = ? AND region_id = ?", gameID, regionID).First(&cp).Error; err != nil {
+		t.Fatal(err)
+	}
+	return cp
+}
+
+func TestDeletedPromotionNoLongerDiscounts(t *testing.T) {
+	db, region, game := newPricingTestDB(t)
+	now := time.Now()
+	promo := addPromotion(t, db, game.ID, 50, now.Add(-time.Hour), now.Add(time.Hour))
+
+	if err := SnapshotGamePrices(db, game.ID, entity.PricePromotionUpdate, &promo.ID, now); err != nil {
+		t.Fatal(err)
+	}
+	if cp := currentPrice(t, db, game.ID, region.ID); cp.Price != 50 || !cp.OnSale {
+		t.Fatalf("during promotion: %+v", cp)
+	}
+
+	// ลบโปร (soft delete) แล้วบันทึกราคาใหม่เหมือน DeletePromotion
+	if err := db.Delete(&promo).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := SnapshotGamePrices(db, game.ID, entity.PricePromotionUpdate, &promo.ID, now); err != nil {
+		t.Fatal(err)
+	}
+	if price, err := GetDiscountedPriceForGame(db, game.ID, region, now); err != nil || price != 100 {
+		t.Fatalf("discounted price after delete = %v, %v", price, err)
+	}
+	if cp := currentPrice(t, db, game.ID, region.ID); cp.Price != 100 || cp.OnSale {
+		t.Fatalf("after delete: %+v", cp)
+	}
+	var last entity.GamePriceHistory
+	db.Where("game_id = ? AND region_id = ?", game.ID, region.ID).Order("id DESC").First(&last)
+	if last.Price != 100 {
+		t.Fatalf("last history row = %+v", last)
+	}
+}
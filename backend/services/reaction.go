@@ -0,0 +1,235 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"example.com/sa-gameshop/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ReactionLike ชนิดที่นับเป็น "ไลก์" (threads.like_count ใช้เรียงกระทู้)
+const ReactionLike = "like"
+
+type ReactionType struct {
+	Key   string `json:"key"`
+	Emoji string `json:"emoji"`
+}
+
+// ReactionTypes ชุดรีแอคชันที่ใช้ได้ (ตายตัว)
+var ReactionTypes = []ReactionType{
+	{ReactionLike, "👍"},
+	{"love", "❤️"},
+	{"haha", "😂"},
+	{"wow", "😮"},
+	{"sad", "😢"},
+	{"angry", "😡"},
+}
+
+var (
+	ErrInvalidReactionType = errors.New("invalid reaction type")
+	ErrReactionTarget      = errors.New("invalid target")
+)
+
+func ValidReactionType(s string) bool {
+	for _, t := range ReactionTypes {
+		if t.Key == s {
+			return true
+		}
+	}
+	return false
+}
+
+// ReactionTargetExists เนื้อหายังอยู่และไม่ถูกซ่อน/ลบ
+func ReactionTargetExists(db *gorm.DB, targetType string, id uint) bool {
+	k, ok := moderationKinds[targetType]
+	if !ok || id == 0 {
+		return false
+	}
+	q := db.Table(k.Table).Where("id = ? AND deleted_at IS NULL AND hidden = ?", id, false)
+	if targetType == "comment" {
+		q = q.Where("removed = ?", false)
+	}
+	var n int64
+	q.Count(&n)
+	return n > 0
+}
+
+// ปรับตัวนับของเนื้อหา (+1/-1) และ threads.like_count สำหรับไลก์กระทู้
+func bumpReactionCount(tx *gorm.DB, targetType string, targetID uint, typ string, delta int64) error {
+	if delta > 0 {
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "target_type"}, {Name: "target_id"}, {Name: "type"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{"count": gorm.Expr("reaction_counts.count + ?", delta)}),
+		}).Create(&entity.ReactionCount{TargetType: targetType, TargetID: targetID, Type: typ, Count: delta}).Error; err != nil {
+			return err
+		}
+	} else if err := tx.Model(&entity.ReactionCount{}).
+		Where("target_type = ? AND target_id = ? AND type = ?", targetType, targetID, typ).
+		UpdateColumn("count", gorm.Expr("MAX(count + ?, 0)", delta)).Error; err != nil {
+		return err
+	}
+	if targetType == "thread" && typ == ReactionLike {
+		return tx.Model(&entity.Thread{}).Where("id = ?", targetID).
+			UpdateColumn("like_count", gorm.Expr("MAX(like_count + ?, 0)", delta)).Error
+	}
+	return nil
+}
+
+func normalizeReaction(targetType, typ string) (string, string, error) {
+	targetType = strings.ToLower(strings.TrimSpace(targetType))
+	typ = strings.ToLower(strings.TrimSpace(typ))
+	if !ValidReactionType(typ) {
+		return targetType, typ, ErrInvalidReactionType
+	}
+	if _, ok := moderationKinds[targetType]; !ok {
+		return targetType, typ, ErrReactionTarget
+	}
+	return targetType, typ, nil
+}
+
+// AddReaction กดรีแอคชัน (กดซ้ำไม่มีผล) คืน true ถ้าเพิ่มใหม่
+func AddReaction(db *gorm.DB, userID uint, targetType string, targetID uint, typ string) (bool, error) {
+	targetType, typ, err := normalizeReaction(targetType, typ)
+	if err != nil {
+		return false, err
+	}
+	if !ReactionTargetExists(db, targetType, targetID) {
+		return false, ErrReactionTarget
+	}
+	added := false
+	err = db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&entity.Reaction{
+			TargetType: targetType, TargetID: targetID, UserID: userID, Type: typ,
+		})
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		added = true
+		return bumpReactionCount(tx, targetType, targetID, typ, 1)
+	})
+	return added, err
+}
+
+// RemoveReaction ยกเลิกรีแอคชัน คืน true ถ้ามีอยู่และลบแล้ว
+func RemoveReaction(db *gorm.DB, userID uint, targetType string, targetID uint, typ string) (bool, error) {
+	targetType, typ, err := normalizeReaction(targetType, typ)
+	if err != nil {
+		return false, err
+	}
+	removed := false
+	err = db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("target_type = ? AND target_id = ? AND user_id = ? AND type = ?", targetType, targetID, userID, typ).
+			Delete(&entity.Reaction{})
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		removed = true
+		return bumpReactionCount(tx, targetType, targetID, typ, -1)
+	})
+	return removed, err
+}
+
+// ToggleReaction กด/ยกเลิก คืนสถานะหลังกด
+func ToggleReaction(db *gorm.DB, userID uint, targetType string, targetID uint, typ string) (bool, error) {
+	removed, err := RemoveReaction(db, userID, targetType, targetID, typ)
+	if err != nil || removed {
+		return false, err
+	}
+	return AddReaction(db, userID, targetType, targetID, typ)
+}
+
+// ReactionSummary จำนวนแต่ละชนิด + ชนิดที่ผู้ชมกดไว้
+type ReactionSummary struct {
+	TargetType string           `json:"target_type"`
+	TargetID   uint             `json:"target_id"`
+	Counts     map[string]int64 `json:"counts"`
+	Mine       []string         `json:"mine"`
+}
+
+// ReactionSummaries สรุปรีแอคชันของหลายเนื้อหาชนิดเดียวกัน (viewer 0 = ไม่ระบุ mine)
+func ReactionSummaries(db *gorm.DB, targetType string, ids []uint, viewer uint) ([]ReactionSummary, error) {
+	out := make([]ReactionSummary, 0, len(ids))
+	if len(ids) == 0 {
+		return out, nil
+	}
+	idx := map[uint]int{}
+	for _, id := range ids {
+		if _, dup := idx[id]; dup {
+			continue
+		}
+		idx[id] = len(out)
+		out = append(out, ReactionSummary{TargetType: targetType, TargetID: id, Counts: map[string]int64{}, Mine: []string{}})
+	}
+
+	var counts []entity.ReactionCount
+	if err := db.Where("target_type = ? AND target_id IN ? AND count > 0", targetType, ids).Find(&counts).Error; err != nil {
+		return out, err
+	}
+	for _, rc := range counts {
+		out[idx[rc.TargetID]].Counts[rc.Type] = rc.Count
+	}
+	if viewer != 0 {
+		var mine []entity.Reaction
+		if err := db.Select("target_id, type").
+			Where("target_type = ? AND target_id IN ? AND user_id = ?", targetType, ids, viewer).
+			Order("id ASC").Find(&mine).Error; err != nil {
+			return out, err
+		}
+		for _, r := range mine {
+			s := &out[idx[r.TargetID]]
+			s.Mine = append(s.Mine, r.Type)
+		}
+	}
+	return out, nil
+}
+
+// ReactionCountsOf จำนวนรีแอคชันชนิดเดียวของหลายเนื้อหา (เช่นไลก์ของรีวิวในหน้าเกม)
+func ReactionCountsOf(db *gorm.DB, targetType string, ids []uint, typ string) (map[uint]int64, error) {
+	out := map[uint]int64{}
+	if len(ids) == 0 {
+		return out, nil
+	}
+	var rows []entity.ReactionCount
+	err := db.Where("target_type = ? AND target_id IN ? AND type = ?", targetType, ids, typ).Find(&rows).Error
+	for _, r := range rows {
+		out[r.TargetID] = r.Count
+	}
+	return out, err
+}
+
+// UserReacted ผู้ใช้กดรีแอคชันชนิดนี้ไว้หรือไม่
+func UserReacted(db *gorm.DB, userID uint, targetType string, targetID uint, typ string) bool {
+	if userID == 0 {
+		return false
+	}
+	var n int64
+	db.Model(&entity.Reaction{}).
+		Where("target_type = ? AND target_id = ? AND user_id = ? AND type = ?", targetType, targetID, userID, typ).
+		Count(&n)
+	return n > 0
+}
+
+// RebuildReactionCounts คำนวณตัวนับใหม่ทั้งหมดจากตาราง reactions (ใช้หลังย้ายข้อมูลเก่า)
+func RebuildReactionCounts(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM reaction_counts").Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(`INSERT INTO reaction_counts (target_type, target_id, type, count)
+			SELECT target_type, target_id, type, COUNT(*) FROM reactions GROUP BY target_type, target_id, type`).Error; err != nil {
+			return err
+		}
+		return tx.Exec(`UPDATE threads SET like_count = COALESCE((SELECT rc.count FROM reaction_counts rc
+			WHERE rc.target_type = 'thread' AND rc.target_id = threads.id AND rc.type = ?), 0)`, ReactionLike).Error
+	})
+}
+
+// ClearReactions ลบรีแอคชันทั้งหมดของเนื้อหาที่ถูกลบถาวร
+func ClearReactions(tx *gorm.DB, targetType string, targetID uint) error {
+	if err := tx.Where("target_type = ? AND target_id = ?", targetType, targetID).Delete(&entity.Reaction{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("target_type = ? AND target_id = ?", targetType, targetID).Delete(&entity.ReactionCount{}).Error
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"strings"
+
+	"example.com/sa-gameshop/entity"
+	"gorm.io/gorm"
+)
+
+// สกุลเงินหลักของ Game.BasePrice และส่วนลดแบบ AMOUNT
+const BaseCurrency = "THB"
+
+// ResolveRegion คืน region ตาม code พร้อม Currency
+// - code ว่าง/ไม่พบ → ใช้ region ที่ตั้งเป็นค่าเริ่มต้น
+func ResolveRegion(db *gorm.DB, code string) (entity.Region, error) {
+	var r entity.Region
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code != "" {
+		err := db.Preload("Currency").Where("code = ?", code).First(&r).Error
+		if err == nil {
+			return r, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return r, err
+		}
+	}
+	err := db.Preload("Currency").Where("is_default = ?", true).Order("id ASC").First(&r).Error
+	return r, err
+}
+
+// RegionForUser อ่าน region จากโปรไฟล์ผู้ใช้ (ไม่มี/ไม่พบผู้ใช้ → ค่าเริ่มต้น)
+func RegionForUser(db *gorm.DB, userID uint) (entity.Region, error) {
+	var u entity.User
+	if userID != 0 {
+		_ = db.Select("id, region_code").First(&u, userID).Error
+	}
+	return ResolveRegion(db, u.RegionCode)
+}
+
+// ConvertFromBase แปลงจำนวนเงินสกุลหลักเป็นสกุลของ region (ยังไม่ปัดเศษ)
+func ConvertFromBase(amount float64, cur *entity.Currency) float64 {
+	if cur == nil || cur.RateFromBase <= 0 {
+		return amount
+	}
+	return amount * cur.RateFromBase
+}
+
+// RoundPrice ปัดราคาตามนโยบายของสกุลเงิน
+func RoundPrice(v float64, cur *entity.Currency) float64 {
+	if v <= 0 {
+		return 0
+	}
+	if cur == nil {
+		return round2(v)
+	}
+	unit := math.Pow10(-cur.Decimals)
+	step := cur.RoundingStep
+	if step <= 0 {
+		step = unit
+	}
+
+	switch cur.RoundingMode {
+	case entity.RoundNone:
+		// ตามทศนิยมของสกุลเงิน
+	case entity.RoundUp:
+		v = math.Ceil(v/step-1e-9) * step
+	case entity.RoundCharm:
+		if up := math.Ceil(v/step-1e-9) * step; up-unit > 0 {
+			v = up - unit
+		}
+	default:
+		v = math.Round(v/step) * step
+	}
+	return roundDecimals(v, cur)
+}
+
+// ปัดตามจำนวนทศนิยมของสกุลเงินอย่างเดียว
+func roundDecimals(v float64, cur *entity.Currency) float64 {
+	if cur == nil {
+		return round2(v)
+	}
+	p := math.Pow10(cur.Decimals)
+	return math.Round(v*p) / p
+}
+
+// RegionalBasePrice ราคาก่อนส่วนลดของเกมใน region
+// ใช้ราคาที่แอดมินกำหนด (GameRegionPrice) ถ้ามี ไม่งั้นแปลงจาก BasePrice
+func RegionalBasePrice(g entity.Game, region entity.Region) float64 {
+	for _, rp := range g.RegionPrices {
+		if rp.RegionID == region.ID {
+			return rp.Price
+		}
+	}
+	return RoundPrice(ConvertFromBase(float64(g.BasePrice), region.Currency), region.Currency)
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"example.com/sa-gameshop/entity"
+	"gorm.io/gorm"
+)
+
+var ErrUnknownScope = errors.New("unknown restriction scope")
+
+// สิทธิ์ที่ใช้ออก/ยกเลิกการจำกัดสิทธิ์แต่ละขอบเขต (purchases/login เป็นระดับบัญชี)
+var restrictionPerms = map[string]string{
+	entity.RestrictCommunity: PermCommunityModerate,
+	entity.RestrictReviews:   "reviews.moderate",
+	entity.RestrictWorkshop:  "workshop.moderate",
+	entity.RestrictPurchases: "users.manage",
+	entity.RestrictLogin:     "users.manage",
+}
+
+// RestrictionPermFor สิทธิ์ที่ต้องใช้จัดการขอบเขตนี้
+func RestrictionPermFor(scope string) (string, bool) {
+	p, ok := restrictionPerms[scope]
+	return p, ok
+}
+
+// RestrictionViewPerms ผู้ที่เห็นประวัติการจำกัดสิทธิ์ในโปรไฟล์ผู้ใช้
+var RestrictionViewPerms = append([]string{"users.manage"}, ModerationPerms...)
+
+// IssueRestriction จำกัดสิทธิ์ผู้ใช้ (expires nil = ถาวร)
+func IssueRestriction(tx *gorm.DB, userID uint, scope, reason string, expires *time.Time, actorID uint) (entity.UserRestriction, error) {
+	r := entity.UserRestriction{
+		UserID:     userID,
+		Scope:      scope,
+		Reason:     strings.TrimSpace(reason),
+		ExpiresAt:  expires,
+		IssuedByID: actorID,
+	}
+	err := tx.Create(&r).Error
+	return r, err
+}
+
+// LiftRestriction ยกเลิกการจำกัดสิทธิ์ก่อนหมดอายุ
+func LiftRestriction(tx *gorm.DB, id, actorID uint) error {
+	return tx.Model(&entity.UserRestriction{}).Where("id = ? AND lifted_at IS NULL", id).
+		Updates(map[string]interface{}{"lifted_at": time.Now(), "lifted_by_id": actorID}).Error
+}
+
+// ActiveRestriction การจำกัดสิทธิ์ที่ยังมีผลในขอบเขตใดขอบเขตหนึ่ง (ไม่มี = nil)
+// ถ้ามีหลายอัน คืนอันที่หมดอายุช้าสุด (ถาวรมาก่อน)
+func ActiveRestriction(db *gorm.DB, userID uint, scopes ...string) (*entity.UserRestriction, error) {
+	if userID == 0 || len(scopes) == 0 {
+		return nil, nil
+	}
+	var r entity.UserRestriction
+	tx := db.Where("user_id = ? AND scope IN ? AND lifted_at IS NULL", userID, scopes).
+		Where("expires_at IS NULL OR expires_at > ?", time.Now()).
+		Order("expires_at IS NOT NULL, expires_at DESC").
+		Limit(1).Find(&r)
+	if tx.Error != nil || tx.RowsAffected == 0 {
+		return nil, tx.Error
+	}
+	return &r, nil
+}
+
+// CheckRestriction การจำกัดสิทธิ์ที่ทำให้ผู้ใช้ทำสิ่งในขอบเขตนี้ไม่ได้ (แบน login ครอบทุกขอบเขต)
+func CheckRestriction(db *gorm.DB, userID uint, scope string) (*entity.UserRestriction, error) {
+	return ActiveRestriction(db, userID, scope, entity.RestrictLogin)
+}
+
+// ActiveRestrictions การจำกัดสิทธิ์ทั้งหมดที่ยังมีผลของผู้ใช้
+func ActiveRestrictions(db *gorm.DB, userID uint) ([]entity.UserRestriction, error) {
+	var rows []entity.UserRestriction
+	err := db.Where("user_id = ? AND lifted_at IS NULL", userID).
+		Where("expires_at IS NULL OR expires_at > ?", time.Now()).
+		Order("id DESC").Find(&rows).Error
+	return rows, err
+}
@@ -0,0 +1,468 @@
+package services
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"sort"
+	"strings"
+	"unicode"
+
+	"example.com/sa-gameshop/entity"
+	"gorm.io/gorm"
+)
+
+// ดัชนีค้นหารวม (games/threads/comments/mods/reviews) ด้วย SQLite FTS5
+// ต้อง build ด้วย tag sqlite_fts5 (make run หรือ go run -tags sqlite_fts5 .)
+// ถ้า sqlite ไม่มีโมดูล fts5 จะถอยไปใช้ LIKE แทน (ไม่มี ranking/typo tolerance)
+
+// แหล่งข้อมูลของดัชนี — rowid = id*8 + Code จึงลบ/แก้ทีละแถวได้เร็ว
+// นิพจน์ใช้ R. แทนแถว (ตอน rebuild = ตารางจริง, ใน trigger = NEW)
+type searchSource struct {
+	Kind  string
+	Code  int
+	Table string
+	Title string
+	Body  string
+	Game  string
+	Where string // เงื่อนไขเพิ่มตอน rebuild (ถ้ามี)
+}
+
+// เกมที่ยังไม่ published ไม่แสดงในผลค้นหา (ดัชนียังเก็บไว้ เผื่อ publish ภายหลัง)
+// เนื้อหาที่ผู้ดูแลซ่อนไว้ (รวมคอมเมนต์ในเธรดที่ถูกซ่อน) ไม่แสดงในผลค้นหา
+const searchVisible = "(kind <> 'game' OR ref_id IN (SELECT id FROM games WHERE status = 'published'))" +
+	" AND (kind <> 'thread' OR ref_id NOT IN (SELECT id FROM threads WHERE hidden))" +
+	" AND (kind <> 'comment' OR ref_id NOT IN (SELECT c.id FROM comments c JOIN threads t ON t.id = c.thread_id WHERE c.hidden OR t.hidden))" +
+	" AND (kind <> 'review' OR ref_id NOT IN (SELECT id FROM reviews WHERE hidden))" +
+	" AND (kind <> 'mod' OR ref_id NOT IN (SELECT id FROM mods WHERE hidden))"
+
+var searchSources = []searchSource{
+	{Kind: "game", Code: 1, Table: "games", Title: "R.game_name", Body: "COALESCE(R.description, '')", Game: "R.id"},
+	{Kind: "thread", Code: 2, Table: "threads", Title: "R.title", Body: "COALESCE(R.content, '')", Game: "R.game_id"},
+	{Kind: "comment", Code: 3, Table: "comments", Title: "''", Body: "COALESCE(R.content, '')",
+		Game:  "(SELECT t.game_id FROM threads t WHERE t.id = R.thread_id)",
+		Where: "EXISTS (SELECT 1 FROM threads t WHERE t.id = R.thread_id AND t.deleted_at IS NULL)"},
+	{Kind: "mod", Code: 4, Table: "mods", Title: "R.title", Body: "COALESCE(R.description, '')", Game: "R.game_id"},
+	{Kind: "review", Code: 5, Table: "reviews", Title: "R.review_title", Body: "COALESCE(R.review_text, '')", Game: "R.game_id"},
+}
+
+// เนื้อหาของเกมที่ผู้ชมอายุไม่ถึงเรต ไม่แสดงในผลค้นหา (col = คอลัมน์ game id)
+func searchAgeFilter(col string, v Viewer, region entity.Region) (string, []interface{}) {
+	sub, args := RestrictedGameIDsSQL(v, region)
+	return "(" + col + " IS NULL OR " + col + " NOT IN (" + sub + "))", args
+}
+
+// SearchKinds ประเภทที่ค้นหาได้
+func SearchKinds() []string {
+	out := make([]string, 0, len(searchSources))
+	for _, s := range searchSources {
+		out = append(out, s.Kind)
+	}
+	return out
+}
+
+var ftsEnabled bool
+
+// SearchUsesFTS บอกว่าใช้ FTS5 อยู่หรือไม่ (false = LIKE)
+func SearchUsesFTS() bool { return ftsEnabled }
+
+// SetupSearchIndex สร้างตาราง FTS5 + trigger ให้ดัชนีอัปเดตตามการ create/update/delete
+// เรียกซ้ำได้ — rebuild ทั้งหมดเฉพาะตอนสร้างดัชนีครั้งแรก
+func SetupSearchIndex(db *gorm.DB) {
+	var exists int64
+	db.Raw("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'search_index'").Scan(&exists)
+
+	if err := db.Exec(`CREATE VIRTUAL TABLE IF NOT EXISTS search_index USING fts5(
+		title, body,
+		kind UNINDEXED, ref_id UNINDEXED, game_id UNINDEXED,
+		tokenize = 'unicode61 remove_diacritics 2',
+		prefix = '2 3'
+	)`).Error; err != nil {
+		log.Println("[Search] ==================================================================")
+		log.Println("[Search] WARNING: SQLite FTS5 unavailable, search falls back to LIKE:", err)
+		log.Println("[Search] no ranking, typo tolerance or prefix index — build with -tags sqlite_fts5 (make run)")
+		log.Println("[Search] ==================================================================")
+		ftsEnabled = false
+		return
+	}
+	if err := db.Exec(`CREATE VIRTUAL TABLE IF NOT EXISTS search_vocab USING fts5vocab(search_index, 'row')`).Error; err != nil {
+		log.Println("[Search] create vocab error:", err)
+	}
+
+	for _, s := range searchSources {
+		for _, stmt := range searchTriggers(s) {
+			if err := db.Exec(stmt).Error; err != nil {
+				log.Println("[Search] create trigger error:", s.Table, err)
+			}
+		}
+	}
+	// ลบเธรด → เอาคอมเมนต์ของเธรดนั้นออกจากดัชนีด้วย
+	if err := db.Exec(`CREATE TRIGGER IF NOT EXISTS search_threads_comments_ad AFTER UPDATE OF deleted_at ON threads
+		WHEN NEW.deleted_at IS NOT NULL BEGIN
+			DELETE FROM search_index WHERE rowid IN (SELECT c.id*8+3 FROM comments c WHERE c.thread_id = OLD.id);
+		END`).Error; err != nil {
+		log.Println("[Search] create trigger error: threads/comments", err)
+	}
+	ftsEnabled = true
+
+	if exists == 0 {
+		if err := RebuildSearchIndex(db); err != nil {
+			log.Println("[Search] rebuild error:", err)
+		}
+	}
+}
+
+// RebuildSearchIndex ล้างและสร้างดัชนีใหม่จากตารางจริงทั้งหมด
+func RebuildSearchIndex(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM search_index").Error; err != nil {
+			return err
+		}
+		for _, s := range searchSources {
+			q := fmt.Sprintf(`INSERT INTO search_index(rowid, title, body, kind, ref_id, game_id)
+				SELECT R.id*8+%d, %s, %s, '%s', R.id, %s FROM %s R WHERE R.deleted_at IS NULL`,
+				s.Code, s.Title, s.Body, s.Kind, s.Game, s.Table)
+			if s.Where != "" {
+				q += " AND " + s.Where
+			}
+			if err := tx.Exec(q).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func searchTriggers(s searchSource) []string {
+	row := func(alias string) string {
+		r := strings.NewReplacer("R.", alias+".")
+		return fmt.Sprintf("%s.id*8+%d, %s, %s, '%s', %s.id, %s",
+			alias, s.Code, r.Replace(s.Title), r.Replace(s.Body), s.Kind, alias, r.Replace(s.Game))
+	}
+	cols := "rowid, title, body, kind, ref_id, game_id"
+	return []string{
+		fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS search_%[1]s_ai AFTER INSERT ON %[1]s WHEN NEW.deleted_at IS NULL BEGIN
+			INSERT INTO search_index(%[2]s) VALUES (%[3]s);
+		END`, s.Table, cols, row("NEW")),
+		fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS search_%[1]s_au AFTER UPDATE ON %[1]s BEGIN
+			DELETE FROM search_index WHERE rowid = OLD.id*8+%[4]d;
+			INSERT INTO search_index(%[2]s) SELECT %[3]s WHERE NEW.deleted_at IS NULL;
+		END`, s.Table, cols, row("NEW"), s.Code),
+		fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS search_%[1]s_ad AFTER DELETE ON %[1]s BEGIN
+			DELETE FROM search_index WHERE rowid = OLD.id*8+%[2]d;
+		END`, s.Table, s.Code),
+	}
+}
+
+// ===== Query =====
+
+type SearchHit struct {
+	Type    string  `json:"type"`
+	ID      uint    `json:"id"`
+	GameID  uint    `json:"game_id"`
+	Title   string  `json:"title"`   // HTML ที่ escape แล้ว คำที่เจออยู่ใน <mark>
+	Snippet string  `json:"snippet"` // เหมือน Title
+	Score   float64 `json:"score"`
+}
+
+type SearchResult struct {
+	Query  string           `json:"query"`
+	Terms  []string         `json:"terms"` // คำที่ใช้ค้นจริง (รวมคำที่แก้คำผิดให้)
+	Total  int64            `json:"total"`
+	Facets map[string]int64 `json:"facets"`
+	Hits   []SearchHit      `json:"hits"`
+}
+
+// Search ค้นหาทุกประเภท kinds ว่าง = ทั้งหมด (ตัดเนื้อหาที่ viewer อายุไม่ถึงออก)
+func Search(db *gorm.DB, text string, kinds []string, limit, offset int, v Viewer, region entity.Region) (SearchResult, error) {
+	res := SearchResult{Query: text, Facets: map[string]int64{}, Hits: []SearchHit{}}
+	tokens := searchTokens(text)
+	if len(tokens) == 0 {
+		return res, nil
+	}
+	if !ftsEnabled {
+		return searchLike(db, res, tokens, kinds, limit, offset, v, region)
+	}
+
+	match, terms := buildMatch(db, tokens)
+	res.Terms = terms
+	ageWhere, ageArgs := searchAgeFilter("game_id", v, region)
+
+	type facet struct {
+		Kind  string
+		Total int64
+	}
+	var facets []facet
+	if err := db.Raw(`SELECT kind, COUNT(*) AS total FROM search_index WHERE search_index MATCH ? AND `+searchVisible+` AND `+ageWhere+` GROUP BY kind`,
+		append([]interface{}{match}, ageArgs...)...).
+		Scan(&facets).Error; err != nil {
+		return res, err
+	}
+	for _, f := range facets {
+		res.Facets[f.Kind] = f.Total
+		if len(kinds) == 0 || containsString(kinds, f.Kind) {
+			res.Total += f.Total
+		}
+	}
+
+	q := db.Table("search_index").
+		Select(`kind AS type, ref_id AS id, game_id,
+			highlight(search_index, 0, char(2), char(3)) AS title,
+			snippet(search_index, 1, char(2), char(3), '…', 16) AS snippet,
+			-bm25(search_index, 5.0, 1.0) AS score`).
+		Where("search_index MATCH ?", match).
+		Where(searchVisible).
+		Where(ageWhere, ageArgs...)
+	if len(kinds) > 0 {
+		q = q.Where("kind IN ?", kinds)
+	}
+	if err := q.Order("bm25(search_index, 5.0, 1.0)").Limit(limit).Offset(offset).Scan(&res.Hits).Error; err != nil {
+		return res, err
+	}
+	for i := range res.Hits {
+		res.Hits[i].Title = markedHTML(res.Hits[i].Title)
+		res.Hits[i].Snippet = markedHTML(res.Hits[i].Snippet)
+	}
+	return res, nil
+}
+
+// title/snippet ของผลค้นหาเป็น HTML ที่ escape แล้ว มีแค่ <mark> รอบคำที่เจอ
+// FTS ครอบคำด้วย markOpen/markClose (ตัวควบคุมที่ไม่มีในข้อความปกติ) แล้วค่อยแปลงหลัง escape
+const (
+	markOpen  = "\x02"
+	markClose = "\x03"
+)
+
+func markedHTML(s string) string {
+	return strings.NewReplacer(markOpen, "<mark>", markClose, "</mark>").Replace(html.EscapeString(s))
+}
+
+// GameSearchSQL เงื่อนไขของ games.id สำหรับ ?q= ใน /game ใช้ดัชนีเดียวกับ Search (prefix + แก้คำผิด)
+// ไม่มี FTS5 → ทุกคำต้องเจอในชื่อหรือคำอธิบาย (LIKE) / ไม่มีคำให้ค้น → sql = ""
+func GameSearchSQL(db *gorm.DB, text string) (string, []interface{}) {
+	tokens := searchTokens(text)
+	if len(tokens) == 0 {
+		return "", nil
+	}
+	if !ftsEnabled {
+		var conds []string
+		var args []interface{}
+		for _, t := range tokens {
+			like := "%" + t + "%"
+			conds = append(conds, "(games.game_name LIKE ? OR games.description LIKE ?)")
+			args = append(args, like, like)
+		}
+		return strings.Join(conds, " AND "), args
+	}
+	match, _ := buildMatch(db, tokens)
+	return "games.id IN (SELECT ref_id FROM search_index WHERE search_index MATCH ? AND kind = 'game')", []interface{}{match}
+}
+
+// แยกคำ (ตัวอักษร/ตัวเลข) เป็นตัวพิมพ์เล็ก
+func searchTokens(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r) && !unicode.Is(unicode.Mn, r)
+	})
+}
+
+// สร้าง MATCH expression: ทุกคำต้องเจอ (AND) แต่ละคำค้นแบบ prefix
+// ถ้าไม่มีคำใดขึ้นต้นด้วย token เลย → หา term ใกล้เคียงใน vocab (พิมพ์ผิด)
+func buildMatch(db *gorm.DB, tokens []string) (string, []string) {
+	var parts, terms []string
+	for _, t := range tokens {
+		alts := []string{`"` + t + `"*`}
+		terms = append(terms, t)
+		var hit int64
+		db.Raw("SELECT COUNT(*) FROM (SELECT 1 FROM search_vocab WHERE term >= ? AND term < ? LIMIT 1)", t, t+"\uffff").Scan(&hit)
+		if hit == 0 {
+			for _, f := range fuzzyTerms(db, t) {
+				alts = append(alts, `"`+f+`"`)
+				terms = append(terms, f)
+			}
+		}
+		parts = append(parts, "("+strings.Join(alts, " OR ")+")")
+	}
+	return strings.Join(parts, " AND "), terms
+}
+
+// term ใน vocab ที่ห่างจาก t ไม่เกิน 1 (คำสั้น) หรือ 2 ตัวอักษร — สูงสุด 3 คำ
+func fuzzyTerms(db *gorm.DB, t string) []string {
+	n := len([]rune(t))
+	if n < 3 {
+		return nil
+	}
+	maxDist := 1
+	if n > 5 {
+		maxDist = 2
+	}
+	first := string([]rune(t)[0])
+
+	var cands []struct {
+		Term string
+		Doc  int64
+	}
+	db.Raw(`SELECT term, doc FROM search_vocab
+		WHERE term >= ? AND term < ? AND length(term) BETWEEN ? AND ?
+		LIMIT 5000`, first, first+"\uffff", n-maxDist, n+maxDist).Scan(&cands)
+
+	type scored struct {
+		term string
+		dist int
+		doc  int64
+	}
+	var out []scored
+	for _, c := range cands {
+		if d := editDistance(t, c.Term); d <= maxDist {
+			out = append(out, scored{c.Term, d, c.Doc})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].dist != out[j].dist {
+			return out[i].dist < out[j].dist
+		}
+		return out[i].doc > out[j].doc
+	})
+	var terms []string
+	for i := 0; i < len(out) && i < 3; i++ {
+		terms = append(terms, out[i].term)
+	}
+	return terms
+}
+
+// Damerau–Levenshtein (แบบสลับตัวติดกัน)
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(ra)][len(rb)]
+}
+
+// ===== Fallback (ไม่มี FTS5) =====
+
+// ผลเรียงตามลำดับ searchSources แล้วตาม id ใหม่ก่อน; นับ facet ด้วย COUNT
+// และดึงเฉพาะแถวของหน้านั้น (LIMIT/OFFSET ต่อแหล่ง) ไม่สแกนทุกแถวมาแบ่งหน้าใน Go
+func searchLike(db *gorm.DB, res SearchResult, tokens, kinds []string, limit, offset int, v Viewer, region entity.Region) (SearchResult, error) {
+	res.Terms = tokens
+	skip := offset
+	for _, s := range searchSources {
+		r := strings.NewReplacer("R.", "")
+		title, body := r.Replace(s.Title), r.Replace(s.Body)
+		game := strings.NewReplacer("R.", s.Table+".").Replace(s.Game)
+
+		q := db.Table(s.Table).
+			Where(s.Table + ".deleted_at IS NULL")
+		if s.Where != "" {
+			q = q.Where(strings.NewReplacer("R.", s.Table+".").Replace(s.Where))
+		}
+		if s.Kind == "game" {
+			q = q.Where("games.status = 'published'")
+		}
+		ageWhere, ageArgs := searchAgeFilter(game, v, region)
+		q = q.Where(ageWhere, ageArgs...)
+		for _, t := range tokens {
+			like := "%" + t + "%"
+			q = q.Where(fmt.Sprintf("(%s LIKE ? OR %s LIKE ?)", title, body), like, like)
+		}
+		q = q.Session(&gorm.Session{})
+
+		var n int64
+		if err := q.Count(&n).Error; err != nil {
+			return res, err
+		}
+		res.Facets[s.Kind] = n
+		if len(kinds) > 0 && !containsString(kinds, s.Kind) {
+			continue
+		}
+		res.Total += n
+		if int64(skip) >= n {
+			skip -= int(n)
+			continue
+		}
+		need := limit - len(res.Hits)
+		if need <= 0 {
+			continue
+		}
+		var hits []SearchHit
+		if err := q.Select(fmt.Sprintf("%s.id AS id, %s AS game_id, %s AS title, %s AS snippet", s.Table, game, title, body)).
+			Order(s.Table + ".id DESC").Limit(need).Offset(skip).Scan(&hits).Error; err != nil {
+			return res, err
+		}
+		skip = 0
+		for i := range hits {
+			hits[i].Type = s.Kind
+			hits[i].Title = likeMark(hits[i].Title, tokens[0], 0)
+			hits[i].Snippet = likeMark(hits[i].Snippet, tokens[0], 80)
+		}
+		res.Hits = append(res.Hits, hits...)
+	}
+	return res, nil
+}
+
+// likeMark ทำ title/snippet แบบเดียวกับ FTS: escape HTML แล้วครอบคำแรกที่เจอด้วย <mark>
+// width > 0 = ตัดข้อความรอบคำนั้นประมาณ width ตัวอักษร
+// เทียบทีละ rune (ToLower ทีละตัว) ตำแหน่งจึงตรงกับข้อความต้นฉบับเสมอ
+func likeMark(text, token string, width int) string {
+	rt := []rune(text)
+	lower := make([]rune, len(rt))
+	for i, r := range rt {
+		lower[i] = unicode.ToLower(r)
+	}
+	tok := []rune(token)
+	idx := -1
+	for i := 0; len(tok) > 0 && i+len(tok) <= len(lower); i++ {
+		if string(lower[i:i+len(tok)]) == token {
+			idx = i
+			break
+		}
+	}
+
+	start, end := 0, len(rt)
+	if width > 0 {
+		if idx >= 0 {
+			start = max(idx-30, 0)
+		}
+		end = min(start+width, len(rt))
+	}
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	if idx >= 0 && idx+len(tok) <= end {
+		b.WriteString(html.EscapeString(string(rt[start:idx])))
+		b.WriteString("<mark>" + html.EscapeString(string(rt[idx:idx+len(tok)])) + "</mark>")
+		b.WriteString(html.EscapeString(string(rt[idx+len(tok) : end])))
+	} else {
+		b.WriteString(html.EscapeString(string(rt[start:end])))
+	}
+	if end < len(rt) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,41 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMarkedHTMLEscapesContent(t *testing.T) {
+	got := markedHTML("<img src=x onerror=alert(1)> " + markOpen + "mod" + markClose + " & more")
+	want := "&lt;img src=x onerror=alert(1)&gt; <mark>mod</mark> &amp; more"
+	if got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestLikeMark(t *testing.T) {
+	cases := []struct {
+		text, token string
+		width       int
+		want        string
+	}{
+		{"<b>Mod</b> pack", "mod", 0, "&lt;b&gt;<mark>Mod</mark>&lt;/b&gt; pack"},
+		{"no match <i>", "zzz", 0, "no match &lt;i&gt;"},
+		// İ ตัวเล็กยาวกว่าเดิม (เป็น byte) — ตำแหน่งต้องยังตรงกับต้นฉบับ
+		{"İİİİ เกมสนุก", "เกม", 0, "İİİİ <mark>เกม</mark>สนุก"},
+	}
+	for _, c := range cases {
+		if got := likeMark(c.text, c.token, c.width); got != c.want {
+			t.Errorf("likeMark(%q, %q) = %q, want %q", c.text, c.token, got, c.want)
+		}
+	}
+
+	long := strings.Repeat("ก", 50) + "needle" + strings.Repeat("ข", 100)
+	got := likeMark(long, "needle", 80)
+	if !strings.HasPrefix(got, "…"+strings.Repeat("ก", 30)+"<mark>needle</mark>") || !strings.HasSuffix(got, "…") {
+		t.Fatalf("windowed = %q", got)
+	}
+	if n := len([]rune(strings.NewReplacer("<mark>", "", "</mark>", "", "…", "").Replace(got))); n != 80 {
+		t.Fatalf("window length = %d, want 80", n)
+	}
+}
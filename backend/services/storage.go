@@ -0,0 +1,166 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// ที่เก็บไฟล์อัปโหลด (สลิป/ม็อด/รูป/ไฟล์แนบ) — เลือกด้วย STORAGE_DRIVER=local|s3
+// key เป็น path ใต้ uploads เช่น "games/<hash>.jpg", "slips/order_1_xxx.png"
+// DB ยังเก็บ path แบบเดิม ("uploads/..." หรือ "/uploads/...") ใช้ StorageKey แปลงเป็น key
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, ObjectInfo, error)
+	Stat(ctx context.Context, key string) (ObjectInfo, error)
+	Delete(ctx context.Context, key string) error
+	// List เรียก fn กับทุกไฟล์ที่ key ขึ้นต้นด้วย prefix ("" = ทั้งหมด) ใช้ตอนเก็บกวาดไฟล์กำพร้า
+	List(ctx context.Context, prefix string, fn func(ObjectInfo) error) error
+	// SignedURL ลิงก์ชั่วคราวสำหรับดาวน์โหลดโดยไม่ต้องผ่านแอป (local = /uploads/... พร้อมลายเซ็น)
+	SignedURL(key string, ttl time.Duration) (string, error)
+}
+
+type ObjectInfo struct {
+	Key         string
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+}
+
+var (
+	ErrObjectNotFound = errors.New("file not found")
+	ErrInvalidKey     = errors.New("invalid file key")
+)
+
+var files Storage = NewLocalStorage("uploads", storageSecret())
+
+// Files storage ที่ใช้อยู่ (ค่าเริ่มต้น = ดิสก์ ./uploads)
+func Files() Storage { return files }
+
+// SetupStorage อ่าน env แล้วตั้ง storage กลาง
+//
+//	STORAGE_DRIVER=local (ค่าเริ่มต้น) ใช้ ./uploads
+//	STORAGE_DRIVER=s3 ใช้ S3_ENDPOINT, S3_REGION, S3_BUCKET, S3_ACCESS_KEY, S3_SECRET_KEY, S3_PATH_STYLE (ค่าเริ่มต้น true)
+func SetupStorage() error {
+	switch strings.ToLower(os.Getenv("STORAGE_DRIVER")) {
+	case "", "local":
+		files = NewLocalStorage("uploads", storageSecret())
+	case "s3":
+		s, err := NewS3Storage(S3Config{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Region:    os.Getenv("S3_REGION"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+			PathStyle: os.Getenv("S3_PATH_STYLE") != "false",
+		})
+		if err != nil {
+			return err
+		}
+		files = s
+	default:
+		return errors.New("unknown STORAGE_DRIVER: " + os.Getenv("STORAGE_DRIVER"))
+	}
+	log.Printf("file storage: %T", files)
+	return nil
+}
+
+// ใช้เซ็น URL ของ local storage (ตั้ง STORAGE_URL_SECRET ได้ ไม่งั้นใช้ JWT_SECRET)
+func storageSecret() []byte {
+	if v := os.Getenv("STORAGE_URL_SECRET"); v != "" {
+		return []byte(v)
+	}
+	if v := os.Getenv("JWT_SECRET"); v != "" {
+		return []byte(v)
+	}
+	return []byte("secret")
+}
+
+// StorageKey แปลง path ใน DB ("/uploads/a/b.png", "uploads/a/b.png", "a/b.png",
+// "http://host/uploads/a/b.png") เป็น key และกัน path traversal (คืน "" ถ้าไม่ปลอดภัย)
+func StorageKey(p string) string {
+	p = strings.ReplaceAll(strings.TrimSpace(p), "\\", "/")
+	if strings.Contains(p, "://") {
+		_, rest, ok := strings.Cut(p, "/uploads/")
+		if !ok {
+			return "" // ลิงก์ภายนอก
+		}
+		p, _, _ = strings.Cut(rest, "?")
+	}
+	p = strings.TrimPrefix(p, "/")
+	p = strings.TrimPrefix(p, "uploads/")
+	if p == "" {
+		return ""
+	}
+	for _, seg := range strings.Split(p, "/") {
+		if seg == "" || seg == "." || seg == ".." {
+			return ""
+		}
+	}
+	return path.Clean(p)
+}
+
+// ===== ไฟล์ส่วนตัว =====
+// สลิป ไฟล์แนบคำร้อง/คำตอบ และไฟล์ม็อด ห้ามเปิดตรง ๆ ด้วย path ที่เดาได้
+// ต้องใช้ signed URL (FileURL) หรือผ่าน handler ที่ตรวจสิทธิ์เจ้าของ/permission
+
+var privateUploadDirs = []string{"slips", "reports", "replies", "mods"}
+
+// PrivateURLTTL อายุของลิงก์ไฟล์ส่วนตัวที่ส่งให้ FE
+const PrivateURLTTL = 30 * time.Minute
+
+// IsPrivateUpload บอกว่า key อยู่ในโฟลเดอร์ไฟล์ส่วนตัวหรือไม่
+func IsPrivateUpload(key string) bool {
+	dir, _, _ := strings.Cut(key, "/")
+	for _, d := range privateUploadDirs {
+		if dir == d {
+			return true
+		}
+	}
+	return false
+}
+
+// FileURL URL สำหรับส่งให้ FE — ไฟล์สาธารณะ = /uploads/<key>, ไฟล์ส่วนตัว = signed URL (หมดอายุใน PrivateURLTTL)
+// local คืน path สัมพัทธ์ (ขึ้นต้นด้วย /) ส่วน S3 คืน URL เต็มของ bucket
+func FileURL(p string) string {
+	key := StorageKey(p)
+	if key == "" {
+		return ""
+	}
+	if !IsPrivateUpload(key) {
+		return "/uploads/" + key
+	}
+	u, err := files.SignedURL(key, PrivateURLTTL)
+	if err != nil {
+		return ""
+	}
+	return u
+}
+
+// PutBytes เก็บข้อมูลในหน่วยความจำลง storage กลาง
+func PutBytes(ctx context.Context, key string, data []byte, contentType string) error {
+	return PutBytesTo(ctx, files, key, data, contentType)
+}
+
+func PutBytesTo(ctx context.Context, st Storage, key string, data []byte, contentType string) error {
+	return st.Put(ctx, key, bytes.NewReader(data), int64(len(data)), contentType)
+}
+
+// ===== ลายเซ็น URL (local) =====
+
+func signKey(secret []byte, key string, expires int64) string {
+	m := hmac.New(sha256.New, secret)
+	m.Write([]byte(key))
+	m.Write([]byte{0})
+	m.Write([]byte(time.Unix(expires, 0).UTC().Format(time.RFC3339)))
+	return hex.EncodeToString(m.Sum(nil))
+}
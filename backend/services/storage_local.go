@@ -0,0 +1,158 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"errors"
+	"io"
+	"io/fs"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LocalStorage เก็บไฟล์บนดิสก์ใต้ Root (ค่าเริ่มต้น ./uploads) เสิร์ฟผ่าน GET /uploads/*
+type LocalStorage struct {
+	Root   string
+	secret []byte
+}
+
+func NewLocalStorage(root string, secret []byte) *LocalStorage {
+	return &LocalStorage{Root: root, secret: secret}
+}
+
+func (s *LocalStorage) path(key string) (string, error) {
+	if k := StorageKey(key); k == "" || k != key {
+		return "", ErrInvalidKey
+	}
+	return filepath.Join(s.Root, filepath.FromSlash(key)), nil
+}
+
+// Put เขียนไฟล์ชั่วคราวแล้ว rename (ไม่มีไฟล์ครึ่ง ๆ กลาง ๆ)
+func (s *LocalStorage) Put(_ context.Context, key string, r io.Reader, _ int64, _ string) error {
+	dst, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dst), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_ = tmp.Chmod(0644)
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dst)
+}
+
+func (s *LocalStorage) Get(ctx context.Context, key string) (io.ReadCloser, ObjectInfo, error) {
+	info, err := s.Stat(ctx, key)
+	if err != nil {
+		return nil, info, err
+	}
+	p, _ := s.path(key)
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, info, err
+	}
+	return f, info, nil
+}
+
+func (s *LocalStorage) Stat(_ context.Context, key string) (ObjectInfo, error) {
+	info := ObjectInfo{Key: key}
+	p, err := s.path(key)
+	if err != nil {
+		return info, err
+	}
+	st, err := os.Stat(p)
+	if errors.Is(err, fs.ErrNotExist) || (err == nil && st.IsDir()) {
+		return info, ErrObjectNotFound
+	}
+	if err != nil {
+		return info, err
+	}
+	info.Size = st.Size()
+	info.ModTime = st.ModTime()
+	info.ContentType = mime.TypeByExtension(filepath.Ext(p))
+	return info, nil
+}
+
+func (s *LocalStorage) Delete(_ context.Context, key string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *LocalStorage) List(ctx context.Context, prefix string, fn func(ObjectInfo) error) error {
+	root := s.Root
+	if dir := path.Dir(prefix); prefix != "" && dir != "." {
+		root = filepath.Join(s.Root, filepath.FromSlash(dir))
+	}
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(s.Root, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		st, err := d.Info()
+		if err != nil {
+			return nil // ถูกลบระหว่างไล่
+		}
+		return fn(ObjectInfo{Key: key, Size: st.Size(), ModTime: st.ModTime(), ContentType: mime.TypeByExtension(filepath.Ext(p))})
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// SignedURL /uploads/<key>?expires=<unix>&signature=<hmac>
+func (s *LocalStorage) SignedURL(key string, ttl time.Duration) (string, error) {
+	if _, err := s.path(key); err != nil {
+		return "", err
+	}
+	exp := time.Now().Add(ttl).Unix()
+	q := url.Values{}
+	q.Set("expires", strconv.FormatInt(exp, 10))
+	q.Set("signature", signKey(s.secret, key, exp))
+	return "/uploads/" + (&url.URL{Path: key}).EscapedPath() + "?" + q.Encode(), nil
+}
+
+// VerifySignature ตรวจลายเซ็นจาก SignedURL (หมดอายุ = false)
+func (s *LocalStorage) VerifySignature(key, expires, signature string) bool {
+	exp, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > exp {
+		return false
+	}
+	return hmac.Equal([]byte(signKey(s.secret, key, exp)), []byte(signature))
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"example.com/sa-gameshop/entity"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCategoryCycle = errors.New("parent cannot be the category itself or one of its descendants")
+	ErrCategoryInUse = errors.New("category still has games; pass move_to or merge it instead")
+)
+
+// Slugify "Open World / RPG" → "open-world-rpg" (เก็บตัวอักษรทุกภาษา เช่นไทย)
+func Slugify(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(strings.TrimSpace(s)) {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) || unicode.Is(unicode.Mn, r) {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
+
+// UniqueSlug เติม -2, -3, ... จนไม่ซ้ำในตาราง (ไม่นับแถว exceptID)
+func UniqueSlug(db *gorm.DB, table, base string, exceptID uint) string {
+	if base == "" {
+		base = "item"
+	}
+	slug := base
+	for i := 2; ; i++ {
+		var n int64
+		db.Table(table).Where("slug = ? AND id <> ?", slug, exceptID).Count(&n)
+		if n == 0 {
+			return slug
+		}
+		slug = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+// CategorySubtreeSQL subquery id ของหมวดที่ระบุรวมหมวดย่อยทุกชั้น (ใช้กับ IN)
+const CategorySubtreeSQL = `WITH RECURSIVE sub(id) AS (
+	SELECT id FROM categories WHERE id IN ? AND deleted_at IS NULL
+	UNION SELECT c.id FROM categories c JOIN sub ON c.parent_id = sub.id WHERE c.deleted_at IS NULL
+) SELECT id FROM sub`
+
+// CategoryDescendants id ของหมวดย่อยทุกชั้น (ไม่รวมตัวเอง)
+func CategoryDescendants(db *gorm.DB, id uint) ([]uint, error) {
+	var ids []uint
+	err := db.Raw(CategorySubtreeSQL, []uint{id}).Scan(&ids).Error
+	out := ids[:0]
+	for _, v := range ids {
+		if v != id {
+			out = append(out, v)
+		}
+	}
+	return out, err
+}
+
+// CheckCategoryParent parent ต้องไม่ใช่ตัวเองหรือหมวดย่อยของตัวเอง
+func CheckCategoryParent(db *gorm.DB, id uint, parentID *uint) error {
+	if parentID == nil || id == 0 {
+		return nil
+	}
+	if *parentID == id {
+		return ErrCategoryCycle
+	}
+	desc, err := CategoryDescendants(db, id)
+	if err != nil {
+		return err
+	}
+	for _, d := range desc {
+		if d == *parentID {
+			return ErrCategoryCycle
+		}
+	}
+	return nil
+}
+
+// relinkCategory ย้ายเกมทั้งหมดจากหมวด from ไป to (ทั้งหมวดหลักและ game_categories)
+func relinkCategory(tx *gorm.DB, from, to uint) error {
+	if err := tx.Exec(`INSERT OR IGNORE INTO game_categories (game_id, categories_id)
+		SELECT game_id, ? FROM game_categories WHERE categories_id = ?`, to, from).Error; err != nil {
+		return err
+	}
+	if err := tx.Exec("DELETE FROM game_categories WHERE categories_id = ?", from).Error; err != nil {
+		return err
+	}
+	return tx.Model(&entity.Game{}).Where("categories_id = ?", from).Update("categories_id", to).Error
+}
+
+// MergeCategory รวมหมวด from เข้า into: ย้ายเกม, ย้ายหมวดย่อย แล้วลบ from
+func MergeCategory(db *gorm.DB, from, into entity.Categories) error {
+	if from.ID == into.ID {
+		return errors.New("cannot merge a category into itself")
+	}
+	if err := CheckCategoryParent(db, from.ID, &into.ID); err != nil {
+		return errors.New("cannot merge a category into one of its descendants")
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := relinkCategory(tx, from.ID, into.ID); err != nil {
+			return err
+		}
+		if err := tx.Model(&entity.Categories{}).Where("parent_id = ?", from.ID).Update("parent_id", into.ID).Error; err != nil {
+			return err
+		}
+		// ลบจริง ให้ชื่อ/slug นำกลับมาใช้ได้
+		return tx.Unscoped().Delete(&entity.Categories{}, from.ID).Error
+	})
+}
+
+// DeleteCategory ลบหมวด หมวดย่อยขึ้นไปอยู่กับ parent เดิม
+// ถ้ายังมีเกมอยู่ต้องระบุ moveTo (0 = ไม่ย้าย → ErrCategoryInUse)
+func DeleteCategory(db *gorm.DB, cat entity.Categories, moveTo uint) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var n int64
+		tx.Raw(`SELECT COUNT(*) FROM games WHERE deleted_at IS NULL AND (categories_id = ?
+			OR id IN (SELECT game_id FROM game_categories WHERE categories_id = ?))`, cat.ID, cat.ID).Scan(&n)
+		if n > 0 {
+			if moveTo == 0 {
+				return ErrCategoryInUse
+			}
+			if err := relinkCategory(tx, cat.ID, moveTo); err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&entity.Categories{}).Where("parent_id = ?", cat.ID).Update("parent_id", cat.ParentID).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&entity.Categories{}, cat.ID).Error
+	})
+}
+
+// MergeTag รวมแท็ก from เข้า into (ทั้งเกมและม็อด) แล้วลบ from
+func MergeTag(db *gorm.DB, from, into uint) error {
+	if from == into {
+		return errors.New("cannot merge a tag into itself")
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`INSERT OR IGNORE INTO game_tags (game_id, tags_id)
+			SELECT game_id, ? FROM game_tags WHERE tags_id = ?`, into, from).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM game_tags WHERE tags_id = ?", from).Error; err != nil {
+			return err
+		}
+		// ม็อดที่มีทั้งสองแท็กอยู่แล้ว → ลบแถวเก่าทิ้ง ที่เหลือย้ายไปแท็กใหม่
+		if err := tx.Exec(`DELETE FROM mod_tags WHERE tag_id = ? AND mod_id IN
+			(SELECT mod_id FROM mod_tags WHERE tag_id = ? AND deleted_at IS NULL)`, from, into).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&entity.ModTags{}).Where("tag_id = ?", from).Update("tag_id", into).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&entity.Tags{}, from).Error
+	})
+}
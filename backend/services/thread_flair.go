@@ -0,0 +1,65 @@
+package services
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"example.com/sa-gameshop/entity"
+	"gorm.io/gorm"
+)
+
+var ErrFlairNotFound = errors.New("flair not found for this game")
+
+// ป้ายเริ่มต้นที่ทุกเกมมี (สร้างให้ตอนเรียกใช้ครั้งแรก)
+var DefaultThreadFlairs = []entity.ThreadFlair{
+	{Slug: "guide", Name: "Guide", Color: "#2e7d32"},
+	{Slug: "bug", Name: "Bug", Color: "#c62828"},
+	{Slug: "discussion", Name: "Discussion", Color: "#1565c0"},
+}
+
+// EnsureThreadFlairs คืนป้ายของเกม ถ้ายังไม่มีเลยให้สร้างป้ายเริ่มต้น
+func EnsureThreadFlairs(db *gorm.DB, gameID uint) ([]entity.ThreadFlair, error) {
+	var rows []entity.ThreadFlair
+	if err := db.Where("game_id = ?", gameID).Order("id ASC").Find(&rows).Error; err != nil || len(rows) > 0 {
+		return rows, err
+	}
+	var n int64
+	db.Unscoped().Model(&entity.ThreadFlair{}).Where("game_id = ?", gameID).Count(&n)
+	if n > 0 {
+		return rows, nil // ผู้ดูแลลบป้ายเริ่มต้นออกเองแล้ว
+	}
+	for _, f := range DefaultThreadFlairs {
+		f.GameID = gameID
+		if err := db.Where("game_id = ? AND slug = ?", gameID, f.Slug).FirstOrCreate(&f).Error; err != nil {
+			return rows, err
+		}
+		rows = append(rows, f)
+	}
+	return rows, nil
+}
+
+// ResolveThreadFlair หาป้ายของเกมจาก id หรือ slug ("" = ไม่ใส่ป้าย)
+func ResolveThreadFlair(db *gorm.DB, gameID uint, ref string) (*uint, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return nil, nil
+	}
+	if _, err := EnsureThreadFlairs(db, gameID); err != nil {
+		return nil, err
+	}
+	q := db.Model(&entity.ThreadFlair{}).Where("game_id = ?", gameID)
+	if id, err := strconv.ParseUint(ref, 10, 64); err == nil {
+		q = q.Where("id = ?", id)
+	} else {
+		q = q.Where("slug = ?", Slugify(ref))
+	}
+	var f entity.ThreadFlair
+	if tx := q.Limit(1).Find(&f); tx.Error != nil {
+		return nil, tx.Error
+	}
+	if f.ID == 0 {
+		return nil, ErrFlairNotFound
+	}
+	return &f.ID, nil
+}
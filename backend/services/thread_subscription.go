@@ -0,0 +1,81 @@
+package services
+
+import (
+	"fmt"
+
+	"example.com/sa-gameshop/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AutoFollowThread ติดตามกระทู้อัตโนมัติ (ถ้าเคยเลิกติดตามเองจะไม่เปลี่ยน)
+func AutoFollowThread(db *gorm.DB, userID, threadID uint) error {
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&entity.ThreadSubscription{
+		UserID: userID, ThreadID: threadID, Following: true,
+	}).Error
+}
+
+// SetThreadFollowing ติดตาม/เลิกติดตามเอง
+func SetThreadFollowing(db *gorm.DB, userID, threadID uint, following bool) error {
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "thread_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"following", "updated_at"}),
+	}).Create(&entity.ThreadSubscription{UserID: userID, ThreadID: threadID, Following: following}).Error
+}
+
+// IsFollowingThread ผู้ใช้ติดตามกระทู้นี้อยู่หรือไม่
+func IsFollowingThread(db *gorm.DB, userID, threadID uint) bool {
+	if userID == 0 {
+		return false
+	}
+	var n int64
+	db.Model(&entity.ThreadSubscription{}).
+		Where("user_id = ? AND thread_id = ? AND following = ?", userID, threadID, true).Count(&n)
+	return n > 0
+}
+
+// NotifyThreadComment แจ้งความเห็นใหม่: เจ้าของความเห็นที่ถูกตอบ (comment_reply) และผู้ติดตามกระทู้ (thread_reply)
+// skip = คนที่ได้แจ้งเตือนจากความเห็นนี้ไปแล้ว (เช่นถูก @ ถึง) จะไม่ได้ซ้ำ
+func NotifyThreadComment(db *gorm.DB, th entity.Thread, cm entity.Comment, author string, skip []uint) error {
+	done := map[uint]bool{cm.UserID: true}
+	for _, id := range skip {
+		done[id] = true
+	}
+
+	if cm.ParentID != nil {
+		var parent entity.Comment
+		db.Select("id, user_id").Limit(1).Find(&parent, *cm.ParentID)
+		if parent.UserID != 0 && !done[parent.UserID] {
+			done[parent.UserID] = true
+			if err := batchThreadNotification(db, parent.UserID, NotificationCommentReply, "มีคนตอบความเห็นของคุณ", th.ID, cm.ID,
+				fmt.Sprintf("%s ตอบความเห็นของคุณในกระทู้ %s", author, th.Title),
+				func(n int) string {
+					return fmt.Sprintf("%d คำตอบใหม่ต่อความเห็นของคุณในกระทู้ %s", n, th.Title)
+				},
+			); err != nil {
+				return err
+			}
+		}
+	}
+
+	var followers []uint
+	if err := db.Model(&entity.ThreadSubscription{}).
+		Where("thread_id = ? AND following = ?", th.ID, true).
+		Pluck("user_id", &followers).Error; err != nil {
+		return err
+	}
+	for _, uid := range followers {
+		if done[uid] {
+			continue
+		}
+		if err := batchThreadNotification(db, uid, NotificationThreadReply, "ความเห็นใหม่ในกระทู้ที่ติดตาม", th.ID, cm.ID,
+			fmt.Sprintf("%s แสดงความเห็นในกระทู้ %s", author, th.Title),
+			func(n int) string {
+				return fmt.Sprintf("%d ความเห็นใหม่ในกระทู้ %s", n, th.Title)
+			},
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,314 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"example.com/sa-gameshop/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ===== อ้างอิงไฟล์จาก DB =====
+// ทุกคอลัมน์ที่เก็บ path ใน uploads ต้องอยู่ในรายการนี้ ไม่งั้น GC จะเห็นไฟล์เป็นไฟล์กำพร้า
+// แถวที่ถูกลบ (หรือแม่ถูกลบ) ไม่นับเป็นการอ้างอิง
+
+type uploadRef struct {
+	tables []string // ข้ามถ้ายังไม่มีตาราง
+	col    string
+	owner  string
+	from   string
+}
+
+var uploadRefs = []uploadRef{
+	{[]string{"games"}, "img_src", "0", "games WHERE deleted_at IS NULL"},
+	{[]string{"game_media"}, "m.url", "0", "game_media m JOIN games g ON g.id = m.game_id WHERE m.deleted_at IS NULL AND g.deleted_at IS NULL"},
+	{[]string{"mods"}, "file_path", "user_id", "mods WHERE deleted_at IS NULL"},
+	{[]string{"mods"}, "image_path", "user_id", "mods WHERE deleted_at IS NULL"},
+	{[]string{"payments", "orders"}, "p.slip_path", "o.user_id", "payments p JOIN orders o ON o.id = p.order_id WHERE p.deleted_at IS NULL"},
+	{[]string{"problem_attachments", "problem_reports"}, "a.file_path", "r.user_id",
+		"problem_attachments a JOIN problem_reports r ON r.id = a.report_id WHERE a.deleted_at IS NULL AND r.deleted_at IS NULL"},
+	{[]string{"problem_reply_attachments", "problem_replies"}, "a.file_path", "r.admin_id",
+		"problem_reply_attachments a JOIN problem_replies r ON r.id = a.reply_id WHERE a.deleted_at IS NULL AND r.deleted_at IS NULL"},
+	{[]string{"promotions"}, "promo_image", "0", "promotions WHERE deleted_at IS NULL"},
+	{[]string{"thread_images", "threads"}, "i.file_url", "t.user_id",
+		"thread_images i JOIN threads t ON t.id = i.thread_id WHERE i.deleted_at IS NULL AND t.deleted_at IS NULL"},
+	{[]string{"refund_attachments", "refund_requests"}, "a.file_path", "r.user_id",
+		"refund_attachments a JOIN refund_requests r ON r.id = a.refund_id WHERE a.deleted_at IS NULL AND r.deleted_at IS NULL"},
+	{[]string{"attachments"}, "file_url", "user_id", "attachments WHERE deleted_at IS NULL"},
+}
+
+// uploadBase ตัดนามสกุลและ _thumb ออก — รูปหลัก/thumbnail/webp ของรูปเดียวกันได้ค่าเดียวกัน
+func uploadBase(key string) string {
+	base := strings.TrimSuffix(key, path.Ext(key))
+	return strings.TrimSuffix(base, "_thumb")
+}
+
+// scanUploadRefs ไล่ทุกการอ้างอิง (like != "" = กรองคอลัมน์ด้วย LIKE ก่อน)
+func scanUploadRefs(db *gorm.DB, like string, fn func(key string, owner uint)) error {
+	for _, ref := range uploadRefs {
+		ok := true
+		for _, t := range ref.tables {
+			ok = ok && db.Migrator().HasTable(t)
+		}
+		if !ok {
+			continue
+		}
+		q := "SELECT " + ref.col + " AS path, " + ref.owner + " AS owner FROM " + ref.from
+		args := []interface{}{}
+		if like != "" {
+			q += " AND " + ref.col + " LIKE ?"
+			args = append(args, like)
+		}
+		var rows []struct {
+			Path  string
+			Owner uint
+		}
+		if err := db.Raw(q, args...).Scan(&rows).Error; err != nil {
+			return err
+		}
+		for _, r := range rows {
+			if key := StorageKey(r.Path); key != "" {
+				fn(key, r.Owner)
+			}
+		}
+	}
+	return nil
+}
+
+// UploadReferenced ยังมีแถวใน DB อ้างถึงไฟล์นี้ (หรือ variant ของรูปเดียวกัน) อยู่หรือไม่
+func UploadReferenced(db *gorm.DB, key string) (bool, error) {
+	base := uploadBase(key)
+	found := false
+	err := scanUploadRefs(db, "%"+path.Base(base)+"%", func(k string, _ uint) {
+		found = found || uploadBase(k) == base
+	})
+	return found, err
+}
+
+// ReleaseUpload ลบไฟล์ (รวม thumbnail/webp) เมื่อไม่มีแถวไหนอ้างถึงแล้ว — เรียกหลังแก้/ลบแถวใน DB สำเร็จ
+// รูปที่ dedupe ด้วย hash อาจถูกใช้ซ้ำที่อื่น จึงต้องเช็คก่อนลบเสมอ
+func ReleaseUpload(ctx context.Context, db *gorm.DB, paths ...string) {
+	for _, p := range paths {
+		key := StorageKey(p)
+		if key == "" {
+			continue
+		}
+		if used, err := UploadReferenced(db, key); err != nil || used {
+			continue
+		}
+		base := uploadBase(key)
+		var keys []string
+		_ = files.List(ctx, base, func(o ObjectInfo) error {
+			if uploadBase(o.Key) == base {
+				keys = append(keys, o.Key)
+			}
+			return nil
+		})
+		deleteUploads(ctx, db, keys)
+	}
+}
+
+func deleteUploads(ctx context.Context, db *gorm.DB, keys []string) int {
+	n := 0
+	var gone []string
+	for _, k := range keys {
+		if err := files.Delete(ctx, k); err == nil {
+			gone = append(gone, k)
+			n++
+		}
+	}
+	if len(gone) > 0 {
+		db.Unscoped().Where("key IN ?", gone).Delete(&entity.UploadObject{})
+	}
+	return n
+}
+
+// ===== พื้นที่ต่อผู้ใช้ / quota =====
+
+var ErrQuotaExceeded = errors.New("storage quota exceeded")
+
+// DefaultUploadQuota โควต้าเริ่มต้นต่อผู้ใช้ (UPLOAD_QUOTA_BYTES, ค่าเริ่มต้น 500MB, 0 = ไม่จำกัด)
+func DefaultUploadQuota() int64 {
+	if v, err := strconv.ParseInt(os.Getenv("UPLOAD_QUOTA_BYTES"), 10, 64); err == nil && v >= 0 {
+		return v
+	}
+	return 500 << 20
+}
+
+type StorageUsage struct {
+	UserID     uint  `json:"user_id"`
+	UsedBytes  int64 `json:"used_bytes"`
+	Files      int64 `json:"files"`
+	QuotaBytes int64 `json:"quota_bytes"` // 0 = ไม่จำกัด
+}
+
+func UserStorageUsage(db *gorm.DB, userID uint) (StorageUsage, error) {
+	u := StorageUsage{UserID: userID, QuotaBytes: DefaultUploadQuota()}
+	var q entity.StorageQuota
+	if err := db.Where("user_id = ?", userID).Limit(1).Find(&q).Error; err != nil {
+		return u, err
+	}
+	if q.ID != 0 {
+		u.QuotaBytes = q.QuotaBytes
+	}
+	var sum struct {
+		UsedBytes int64
+		Files     int64
+	}
+	err := db.Model(&entity.UploadObject{}).
+		Select("COALESCE(SUM(size), 0) AS used_bytes, COUNT(*) AS files").
+		Where("user_id = ?", userID).
+		Scan(&sum).Error
+	u.UsedBytes, u.Files = sum.UsedBytes, sum.Files
+	return u, err
+}
+
+// CheckUploadQuota ตรวจก่อนเก็บไฟล์ขนาด incoming ไบต์ (ผู้ใช้ 0 = ระบบ ไม่จำกัด)
+func CheckUploadQuota(db *gorm.DB, userID uint, incoming int64) (StorageUsage, error) {
+	if userID == 0 {
+		return StorageUsage{}, nil
+	}
+	u, err := UserStorageUsage(db, userID)
+	if err != nil {
+		return u, err
+	}
+	if u.QuotaBytes > 0 && u.UsedBytes+incoming > u.QuotaBytes {
+		return u, fmt.Errorf("%w (used %d of %d bytes)", ErrQuotaExceeded, u.UsedBytes, u.QuotaBytes)
+	}
+	return u, nil
+}
+
+// TrackUpload บันทึกไฟล์ที่เพิ่งเก็บ (ขนาดจริงจาก storage) ให้ userID — key ที่มีเจ้าของแล้วไม่เปลี่ยนเจ้าของ
+func TrackUpload(ctx context.Context, db *gorm.DB, userID uint, paths ...string) {
+	for _, p := range paths {
+		key := StorageKey(p)
+		if key == "" {
+			continue
+		}
+		info, err := files.Stat(ctx, key)
+		if err != nil {
+			continue
+		}
+		db.Clauses(clause.OnConflict{DoNothing: true}).Create(&entity.UploadObject{
+			Key: key, UserID: userID, Size: info.Size, ContentType: info.ContentType,
+		})
+	}
+}
+
+// ===== เก็บกวาดไฟล์กำพร้า =====
+
+type UploadGCOptions struct {
+	DryRun bool
+	MinAge time.Duration // ไม่แตะไฟล์ที่ใหม่กว่านี้ (อาจกำลังอัปโหลด/รอ commit)
+}
+
+type UploadGCItem struct {
+	Key     string    `json:"key"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+type UploadGCReport struct {
+	DryRun       bool           `json:"dry_run"`
+	Scanned      int            `json:"scanned"`
+	ScannedBytes int64          `json:"scanned_bytes"`
+	Orphans      []UploadGCItem `json:"orphans"`
+	OrphanBytes  int64          `json:"orphan_bytes"`
+	Deleted      int            `json:"deleted"`
+	Missing      []string       `json:"missing"`       // อ้างใน DB แต่ไม่มีไฟล์
+	Untracked    int            `json:"untracked"`     // ไฟล์ที่ใช้อยู่แต่ยังไม่ถูกนับพื้นที่ (รันจริงจะบันทึกให้)
+	StaleRecords int            `json:"stale_records"` // แถว upload_objects ที่ไม่มีไฟล์แล้ว (รันจริงจะลบ)
+}
+
+// CollectUploadGarbage เทียบไฟล์ทั้งหมดใน storage กับการอ้างอิงใน DB
+// DryRun = รายงานอย่างเดียว, ไม่งั้นลบไฟล์กำพร้าและปรับ upload_objects ให้ตรงกับของจริง
+func CollectUploadGarbage(ctx context.Context, db *gorm.DB, opt UploadGCOptions) (UploadGCReport, error) {
+	rep := UploadGCReport{DryRun: opt.DryRun, Orphans: []UploadGCItem{}, Missing: []string{}}
+
+	refs := map[string]string{} // base → key ที่อ้าง
+	owners := map[string]uint{}
+	if err := scanUploadRefs(db, "", func(key string, owner uint) {
+		base := uploadBase(key)
+		refs[base] = key
+		if owners[base] == 0 {
+			owners[base] = owner
+		}
+	}); err != nil {
+		return rep, err
+	}
+
+	var trackedKeys []string
+	if err := db.Model(&entity.UploadObject{}).Pluck("key", &trackedKeys).Error; err != nil {
+		return rep, err
+	}
+	tracked := map[string]bool{}
+	for _, k := range trackedKeys {
+		tracked[k] = true
+	}
+
+	cutoff := time.Now().Add(-opt.MinAge)
+	present := map[string]bool{}
+	seen := map[string]bool{}
+	var untracked []entity.UploadObject
+	err := files.List(ctx, "", func(o ObjectInfo) error {
+		rep.Scanned++
+		rep.ScannedBytes += o.Size
+		base := uploadBase(o.Key)
+		present[base] = true
+		seen[o.Key] = true
+		if _, ok := refs[base]; ok {
+			if !tracked[o.Key] {
+				untracked = append(untracked, entity.UploadObject{Key: o.Key, UserID: owners[base], Size: o.Size, ContentType: o.ContentType})
+			}
+			return nil
+		}
+		if o.ModTime.Before(cutoff) {
+			rep.Orphans = append(rep.Orphans, UploadGCItem{Key: o.Key, Size: o.Size, ModTime: o.ModTime})
+			rep.OrphanBytes += o.Size
+		}
+		return nil
+	})
+	if err != nil {
+		return rep, err
+	}
+	rep.Untracked = len(untracked)
+
+	for base, key := range refs {
+		if !present[base] {
+			rep.Missing = append(rep.Missing, key)
+		}
+	}
+	var stale []string
+	for _, k := range trackedKeys {
+		if !seen[k] {
+			stale = append(stale, k)
+		}
+	}
+	rep.StaleRecords = len(stale)
+	sort.Strings(rep.Missing)
+	sort.Slice(rep.Orphans, func(i, j int) bool { return rep.Orphans[i].Key < rep.Orphans[j].Key })
+
+	if opt.DryRun {
+		return rep, nil
+	}
+	keys := make([]string, 0, len(rep.Orphans))
+	for _, o := range rep.Orphans {
+		keys = append(keys, o.Key)
+	}
+	rep.Deleted = deleteUploads(ctx, db, keys)
+	if len(stale) > 0 {
+		db.Unscoped().Where("key IN ?", stale).Delete(&entity.UploadObject{})
+	}
+	if len(untracked) > 0 {
+		db.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(untracked, 200)
+	}
+	return rep, nil
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"example.com/sa-gameshop/entity"
+	"gorm.io/gorm"
+)
+
+// ชนิดแจ้งเตือนของ wishlist (Notification.Type)
+const (
+	NotificationWishlistPrice   = "wishlist_price_drop"
+	NotificationWishlistRelease = "wishlist_release"
+)
+
+// ราคาลดซ้ำภายในช่วงนี้จะแจ้งอีกก็ต่อเมื่อต่ำกว่าราคาที่เคยแจ้ง (กันโปรซ้อน/โปรจบแล้วเริ่มใหม่)
+const WishlistNotifyCooldown = 24 * time.Hour
+
+// wishlistItemsInRegion แถว wishlist ของเกมที่เจ้าของอยู่ใน region นี้ (ไม่รวมคนที่ซื้อเกมแล้ว)
+// ผู้ใช้ที่ไม่ได้ตั้ง region หรือ region ไม่มีในระบบนับเป็น region ค่าเริ่มต้น (ตาม ResolveRegion)
+func wishlistItemsInRegion(db *gorm.DB, gameID uint, region entity.Region) ([]entity.WishlistItem, error) {
+	q := wishlistWatchers(db, gameID)
+	if region.IsDefault {
+		q = q.Where(`UPPER(COALESCE(u.region_code, '')) = ? OR COALESCE(u.region_code, '') = ''
+			OR UPPER(u.region_code) NOT IN (SELECT code FROM regions WHERE deleted_at IS NULL)`, region.Code)
+	} else {
+		q = q.Where("UPPER(u.region_code) = ?", region.Code)
+	}
+	var items []entity.WishlistItem
+	err := q.Find(&items).Error
+	return items, err
+}
+
+func wishlistWatchers(db *gorm.DB, gameID uint) *gorm.DB {
+	return db.Model(&entity.WishlistItem{}).
+		Joins("JOIN users u ON u.id = wishlist_items.user_id AND u.deleted_at IS NULL").
+		Where("wishlist_items.game_id = ?", gameID).
+		Where(`NOT EXISTS (SELECT 1 FROM user_games ug
+			WHERE ug.user_id = wishlist_items.user_id AND ug.game_id = wishlist_items.game_id AND ug.deleted_at IS NULL)`)
+}
+
+// WishlistPrice ราคาปัจจุบันของเกมใน region ของผู้ใช้ (ใช้ตั้งราคาเริ่มต้นตอนเพิ่มเข้า wishlist)
+func WishlistPrice(db *gorm.DB, userID, gameID uint, at time.Time) (float64, entity.Region, error) {
+	region, err := RegionForUser(db, userID)
+	if err != nil {
+		return 0, region, err
+	}
+	price, err := GetDiscountedPriceForGame(db, gameID, region, at)
+	return price, region, err
+}
+
+// NotifyWishlistPrice เรียกเมื่อราคาเกมใน region เปลี่ยน (จาก SnapshotGamePrices)
+// แจ้งเฉพาะเกมที่วางขายแล้วและราคาต่ำกว่า baseline ของแต่ละคน
+func NotifyWishlistPrice(db *gorm.DB, game entity.Game, region entity.Region, price float64, at time.Time) error {
+	items, err := wishlistItemsInRegion(db, game.ID, region)
+	if err != nil {
+		return err
+	}
+	for _, it := range items {
+		if price == it.BaselinePrice {
+			continue
+		}
+		updates := map[string]interface{}{"baseline_price": price}
+		if price < it.BaselinePrice && game.Status == entity.GamePublished {
+			newLow := it.LastNotifiedPrice == nil || price < *it.LastNotifiedPrice
+			cooled := it.LastNotifiedAt == nil || at.Sub(*it.LastNotifiedAt) >= WishlistNotifyCooldown
+			if newLow || cooled {
+				title := fmt.Sprintf("%s ลดราคา", game.GameName)
+				msg := fmt.Sprintf("%s ในรายการที่อยากได้ลดเหลือ %s (จาก %s)",
+					game.GameName, formatMoney(price, region), formatMoney(it.BaselinePrice, region))
+				if err := upsertGameNotification(db, it.UserID, game.ID, NotificationWishlistPrice, title, msg); err != nil {
+					return err
+				}
+				updates["last_notified_price"] = price
+				updates["last_notified_at"] = at
+			}
+		}
+		if err := db.Model(&entity.WishlistItem{}).Where("id = ?", it.ID).Updates(updates).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NotifyWishlistRelease แจ้งทุกคนที่มีเกมใน wishlist เมื่อเกม publish (ครั้งเดียวต่อแถว)
+func NotifyWishlistRelease(db *gorm.DB, game entity.Game) error {
+	var items []entity.WishlistItem
+	if err := wishlistWatchers(db, game.ID).
+		Where("wishlist_items.release_notified_at IS NULL").
+		Find(&items).Error; err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		return nil
+	}
+	ids := make([]uint, 0, len(items))
+	for _, it := range items {
+		title := fmt.Sprintf("%s วางขายแล้ว", game.GameName)
+		msg := fmt.Sprintf("%s ในรายการที่อยากได้พร้อมให้ซื้อแล้ว", game.GameName)
+		if err := upsertGameNotification(db, it.UserID, game.ID, NotificationWishlistRelease, title, msg); err != nil {
+			return err
+		}
+		ids = append(ids, it.ID)
+	}
+	return db.Model(&entity.WishlistItem{}).Where("id IN ?", ids).Update("release_notified_at", time.Now()).Error
+}
+
+// upsertGameNotification ถ้ายังมีแจ้งเตือนชนิดเดียวกันของเกมนี้ที่ยังไม่อ่าน ให้แก้ข้อความเดิมแทนสร้างใหม่
+func upsertGameNotification(db *gorm.DB, userID, gameID uint, typ, title, msg string) error {
+	if !NotificationEnabled(db, userID, typ) {
+		return nil
+	}
+	var n entity.Notification
+	err := db.Where("user_id = ? AND game_id = ? AND type = ? AND is_read = ?", userID, gameID, typ, false).
+		Order("id DESC").Limit(1).Find(&n).Error
+	if err != nil {
+		return err
+	}
+	if n.ID != 0 {
+		merged := n
+		merged.Title, merged.Message = title, msg
+		if err := replaceNotification(db, n, &merged); err != nil {
+			return err
+		}
+		PublishNotification(db, merged)
+		return nil
+	}
+	gid := gameID
+	n = entity.Notification{
+		Title:   title,
+		Type:    typ,
+		Message: msg,
+		UserID:  userID,
+		GameID:  &gid,
+	}
+	if err := db.Create(&n).Error; err != nil {
+		return err
+	}
+	PublishNotification(db, n)
+	return nil
+}
+
+func formatMoney(v float64, region entity.Region) string {
+	if region.Currency == nil {
+		return fmt.Sprintf("%.2f", v)
+	}
+	return fmt.Sprintf("%.*f %s", region.Currency.Decimals, v, region.Currency.Code)
+}
@@ -85,7 +85,7 @@ export default function NotificationBell({ userId, pollMs = 5000 }: Props) {
 
   const openView = async (n: Notification) => {
     try {
-      if (!n.is_read) await markNotificationRead(n.ID);
+      if (!n.is_read) await markNotificationRead(n.ID, userId);
       setViewNoti(n);
       setViewReport(null);
 
@@ -171,7 +171,7 @@ export default function NotificationBell({ userId, pollMs = 5000 }: Props) {
               !n.is_read ? (
                 <Button
                   size="small"
-                  onClick={async () => { await markNotificationRead(n.ID); await load(); }}
+                  onClick={async () => { await markNotificationRead(n.ID, userId); await load(); }}
                   style={{
                     background: "linear-gradient(90deg,#ff5ca8,#9254de)",
                     border: "none",
@@ -186,7 +186,7 @@ export default function NotificationBell({ userId, pollMs = 5000 }: Props) {
               ) : null,
               <Button
                 size="small"
-                onClick={async () => { await deleteNotification(n.ID); await load(); }}
+                onClick={async () => { await deleteNotification(n.ID, userId); await load(); }}
                 style={{
                   background: "linear-gradient(90deg,#ff4d4f,#a8071a)",
                   border: "none",
@@ -235,7 +235,7 @@ export default function NotificationBell({ userId, pollMs = 5000 }: Props) {
         <div style={{ display: "flex", flexWrap: "wrap", gap: 12, marginTop: 10 }}>
           {adminOnly.map((att) => {
             const rawPath = att.file_path ?? att.FilePath ?? "";
-            const url = normalizeUrl(rawPath);
+            const url = att.url || normalizeUrl(rawPath);
             const isImg = /\.(jpg|jpeg|png|gif|webp|bmp|svg)$/i.test(rawPath);
             return (
               <div
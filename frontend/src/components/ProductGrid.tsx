@@ -4,6 +4,7 @@ import { useEffect, useState } from "react";
 import { useNavigate } from "react-router-dom";
 import axios from "axios";
 import { useCart } from "../context/CartContext";
+import { fetchAllGames } from "../services/game";
 
 const base_url = "http://localhost:8088";
 
@@ -45,8 +46,7 @@ const ProductGrid: React.FC<ProductGridProps> = ({ userId = null }) => {
 
   const fetchGames = async () => {
     try {
-      const res = await axios.get(`${base_url}/game`);
-      setGames(Array.isArray(res.data) ? (res.data as Game[]) : []);
+      setGames((await fetchAllGames({ base: base_url })) as Game[]);
     } catch (err) {
       console.error("fetch games error:", err);
       message.error("โหลดรายการเกมไม่สำเร็จ");
@@ -101,7 +101,7 @@ const ProductGrid: React.FC<ProductGridProps> = ({ userId = null }) => {
       message.error("ไม่สามารถเพิ่มลงตะกร้าได้");
     }
   };
-  const approveGames = games.filter((g) => g.status === "approve");
+  const approveGames = games.filter((g) => g.status === "published");
 
   return (
     <Row gutter={[16, 16]}>
@@ -1,5 +1,6 @@
 export interface ProblemAttachment {
   file_path: string;
+  url?: string; // signed URL (หมดอายุ) จาก backend
   ID: number;
   FilePath: string;
   UploadedAt?: string; // optional เพราะบางกรณีอาจยังไม่เซ็ต
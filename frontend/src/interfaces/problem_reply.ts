@@ -4,6 +4,7 @@ export interface ProblemReplyAttachment {
   ID: number;
   file_path: string;
   FilePath?: string;
+  url?: string; // signed URL (หมดอายุ) จาก backend
   original_name?: string;
   OriginalName?: string;
   reply_id: number;
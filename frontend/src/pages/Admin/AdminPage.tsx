@@ -276,9 +276,9 @@ export default function AdminPage({ refunds, setRefunds }: AdminPageProps) {
                       const isImage = path
                         .toLowerCase()
                         .match(/\.(jpg|jpeg|png|gif|webp|bmp|svg)$/i);
-                      const url = `${API_URL}${
-                        path.startsWith("/") ? "" : "/"
-                      }${path}`;
+                      const url =
+                        (att as any).url ||
+                        `${API_URL}${path.startsWith("/") ? "" : "/"}${path}`;
                       return isImage ? (
                         <img
                           key={(att as any).ID}
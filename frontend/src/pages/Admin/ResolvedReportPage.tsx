@@ -16,6 +16,7 @@ type Attachment = {
   ID?: number;
   file_path?: string;
   original_name?: string;
+  url?: string;
 };
 
 type User = {
@@ -254,9 +255,9 @@ export default function ResolvedReportsPage() {
                     const isImage = path
                       .toLowerCase()
                       .match(/\.(jpg|jpeg|png|gif|webp|bmp|svg)$/i);
-                    const url = path.startsWith("http")
-                      ? path
-                      : `${API_URL}${path}`;
+                    const url =
+                      att.url ||
+                      (path.startsWith("http") ? path : `${API_URL}${path}`);
                     return isImage ? (
                       <img
                         key={att.ID || path}
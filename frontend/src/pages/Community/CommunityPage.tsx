@@ -5,6 +5,7 @@ import axios from "axios";
 import ThreadList from "./ThreadList";
 import ThreadDetail from "./ThreadDetail";
 import { useAuth } from "../../context/AuthContext";
+import { fetchAllGames } from "../../services/game";
 
 type ThreadImg = { id: number; url: string };
 export type Thread = {
@@ -61,8 +62,8 @@ export default function CommunityPage() {
   useEffect(() => {
     (async () => {
       try {
-        const res = await axios.get(`${BASE_URL}/game`);
-        const list = (res.data || []).map((g: any) => ({
+        const rows = await fetchAllGames({ base: BASE_URL });
+        const list = rows.map((g: any) => ({
           id: g.id ?? g.ID,
           name: g.game_name ?? g.GameName ?? `Game #${g.id ?? g.ID}`,
         }));
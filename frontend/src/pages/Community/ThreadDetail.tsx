@@ -10,6 +10,7 @@ type Thread = {
   id: number;
   title: string;
   content: string;
+  contentHtml?: string;
   author: string;
   createdAt: string;
   likeCount: number;
@@ -19,8 +20,13 @@ type Thread = {
 export type ThreadComment = {
   id: number;
   content: string;
+  contentHtml?: string;
   userName: string;
   createdAt: string;
+  depth: number;
+  edited: boolean;
+  removed: boolean;
+  quoteText?: string;
 };
 
 const { Title, Text } = Typography;
@@ -56,6 +62,7 @@ export default function ThreadDetail({ threadId, onBack }: Props) {
         id: t.id ?? t.ID,
         title: t.title ?? t.Title ?? "",
         content: t.content ?? t.Content ?? "",
+        contentHtml: t.content_html || undefined,
         author: t.user?.username ?? t.User?.Username ?? "",
         createdAt: t.posted_at ?? t.PostedAt ?? t.created_at ?? t.CreatedAt ?? "",
         likeCount: t.like_count ?? t.LikeCount ?? 0,
@@ -74,10 +81,15 @@ export default function ThreadDetail({ threadId, onBack }: Props) {
       const rows: ThreadComment[] = (cm.data || []).map((c: any) => ({
         id: c.id ?? c.ID,
         content: c.content ?? c.Content ?? "",
+        contentHtml: c.content_html || undefined,
         userName: c.user?.username ?? c.User?.Username ?? "",
         createdAt: c.created_at ?? c.CreatedAt ?? "",
+        depth: c.depth ?? 0,
+        edited: !!c.edited_at,
+        removed: !!c.removed,
+        quoteText: c.quote_text || undefined,
       }));
-      rows.sort((a, b) => (a.id ?? 0) - (b.id ?? 0));
+      // backend เรียงแบบ depth-first มาแล้ว
       setComments(rows);
     } catch (e) {
       console.error(e);
@@ -150,7 +162,12 @@ export default function ThreadDetail({ threadId, onBack }: Props) {
         >
           <Space direction="vertical" size="middle" style={{ width: "100%" }}>
             <Title level={3} style={{ color: "#e6e6e6", margin: 0 }}>{thread.title}</Title>
-            <Text style={{ color: "#a8b3cf" }}>{thread.content}</Text>
+            {/* content_html ผ่านการ sanitize ที่ฝั่ง backend แล้ว */}
+            {thread.contentHtml ? (
+              <div className="post-body" style={{ color: "#a8b3cf" }} dangerouslySetInnerHTML={{ __html: thread.contentHtml }} />
+            ) : (
+              <Text style={{ color: "#a8b3cf" }}>{thread.content}</Text>
+            )}
 
             {!!thread.images?.length && (
               <div style={{
@@ -196,23 +213,33 @@ export default function ThreadDetail({ threadId, onBack }: Props) {
               </Space>
             </div>
 
-            {/* คอมเมนต์แบบแถวเดียว */}
+            {/* คอมเมนต์ (ย่อหน้าตามชั้นคำตอบ) */}
             <div style={{ marginTop: 8, padding: 12, border: "1px solid #1f2942", background: "#0f1420", borderRadius: 12 }}>
               {comments.length === 0 ? (
                 <Text style={{ color: "#93a0c2" }}>ยังไม่มีความเห็น</Text>
               ) : (
                 comments.map((c) => (
-                  <div key={c.id} style={{ display: "flex", gap: 12, marginBottom: 12 }}>
+                  <div key={c.id} style={{ display: "flex", gap: 12, marginBottom: 12, marginLeft: c.depth * 28 }}>
                     <Avatar icon={<UserOutlined />} />
                     <div style={{ flex: 1 }}>
                       <div style={{ background: "#0c111b", border: "1px solid #1f2942", borderRadius: 10, padding: 10 }}>
                         <div style={{ display: "flex", gap: 8, alignItems: "baseline" }}>
-                          <span style={{ color: "#e6e6e6", fontWeight: 500 }}>{c.userName || "ไม่ระบุ"}</span>
+                          <span style={{ color: "#e6e6e6", fontWeight: 500 }}>{c.removed ? "-" : c.userName || "ไม่ระบุ"}</span>
                           <span style={{ color: "#93a0c2", fontSize: 12 }}>
                             {c.createdAt ? dayjs(thread.createdAt).format("D/M/YYYY HH:mm") : ""}
+                            {c.edited ? " · แก้ไขแล้ว" : ""}
                           </span>
                         </div>
-                        <div style={{ color: "#cfd7ef", marginTop: 6 }}>{c.content}</div>
+                        {c.quoteText && (
+                          <div style={{ color: "#93a0c2", marginTop: 6, paddingLeft: 8, borderLeft: "3px solid #2a3655" }}>
+                            {c.quoteText}
+                          </div>
+                        )}
+                        <div style={{ color: c.removed ? "#6b7694" : "#cfd7ef", marginTop: 6 }}>
+                          {c.removed ? "ความเห็นนี้ถูกลบ" : c.contentHtml ? (
+                            <div className="post-body" dangerouslySetInnerHTML={{ __html: c.contentHtml }} />
+                          ) : c.content}
+                        </div>
                       </div>
                     </div>
                   </div>
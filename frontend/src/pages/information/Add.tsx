@@ -35,14 +35,17 @@ const Add = () => {
     const [memory, setMemory] = useState("");
     const [graphics, setGraphics] = useState("");
     const [storage, setStorage] = useState("");
-    //const { id } = useAuth(); //คนใช้งานระบบ
+    const { id, token } = useAuth(); //คนใช้งานระบบ (สร้างเกม/อัปโหลดรูปต้องมีสิทธิ์ games.manage)
+    const authHeaders: Record<string, string> = {};
+    if (token) authHeaders["Authorization"] = `Bearer ${token}`;
+    if (id) authHeaders["X-User-ID"] = String(id);
     //const [useron, Setuseron] = useState<useronline | null>(null)
 
     async function uploadGameImage(file: File): Promise<string> {
         const fd = new FormData();
         fd.append("file", file);
         const res = await axios.post(`${base_url}/upload/game`, fd, {
-            headers: { "Content-Type": "multipart/form-data" },
+            headers: { ...authHeaders, "Content-Type": "multipart/form-data" },
         });
         return res.data.url as string; // "/uploads/games/xxx.png"
   }
@@ -122,7 +125,7 @@ const Add = () => {
                 graphics:graphics,
                 storage:storage,
             }
-        });
+        }, { headers: authHeaders });
             console.log("เพิ่มเกมสำเร็จ:", response.data)
             //setM_id(m_id+1)
         } catch(err) {
@@ -1,12 +1,13 @@
 import { Layout, Space, Button, Select } from 'antd';
 import Navbar from "../components/Navbar";
-import { Typography, Input, DatePicker, Result} from "antd";
+import { Typography, Input, DatePicker, Result, message} from "antd";
 import { Col, Row } from 'antd';
 import { Link } from 'react-router-dom';
 import  axios  from 'axios';
 import type { Game } from '../interfaces';
 import { useState, useEffect} from 'react';
 import { useAuth } from '../context/AuthContext';
+import { fetchAllGames } from '../services/game';
 import {
   PlusOutlined  ,
 } from '@ant-design/icons';
@@ -18,8 +19,12 @@ const Request = () => {
     const[gameid,SetgameID] = useState<number | null>(null);
     const[reason, SetReason]=useState("")
     const [date, setDate] = useState<string | null>(null);
-    const { id } = useAuth(); //คนใช้งานระบบ
+    const { id, token } = useAuth(); //คนใช้งานระบบ
+    const authHeaders: Record<string, string> = {};
+    if (token) authHeaders["Authorization"] = `Bearer ${token}`;
+    if (id) authHeaders["X-User-ID"] = String(id);
     
+    // บันทึกรีเควส แล้วส่งเกมเข้าคิวตรวจ (draft/rejected → submitted) ผ่าน POST /games/:id/status
     async function CreateRequest() {
         try {
             const response = await axios.post(`${base_url}/new-request`, {
@@ -29,15 +34,18 @@ const Request = () => {
                 game: gameid, 
         });
             console.log("เพิ่มรีเควสสำเร็จ:", response.data)
+            await axios.post(`${base_url}/games/${gameid}/status`, { status: "submitted", note: reason }, { headers: authHeaders });
+            message.success("ส่งเกมเข้าคิวตรวจแล้ว");
+            GetGame()
         } catch(err) {
             console.log("add request error",err)
+            message.error("ส่งรีเควสไม่สำเร็จ (ต้องเป็นผู้สร้างเกมหรือผู้ดูแล)");
         }  
     }
      async function GetGame() {
         try {
-        const response = await axios.get(`${base_url}/game`)
-        Setgame(response.data)
-        console.log(response.data)
+        const rows = await fetchAllGames({ base: base_url, params: { status: "draft,rejected" }, headers: authHeaders })
+        Setgame(rows as Game[])
         } catch(err) {
         console.log('get game error',err)
         }  
@@ -46,7 +54,7 @@ const Request = () => {
         GetGame()
     }, [])
 
-    const pendingGames = game ? game.filter(g => g.status === "pending") : [];
+    const pendingGames = game ? game.filter(g => g.status === "draft" || g.status === "rejected") : [];
     return(<div style={{background: '#141414', flex: 1 , minHeight: '100vh'}}>{(pendingGames.length !== 0) ? (
         <Layout style={{flex: 1}}>
             <Layout style={{ background: '#141414', flex: 1 , minHeight: '100vh'}}>
@@ -7,6 +7,7 @@ import { useState, useEffect } from "react";
 import type { Request } from "../interfaces/Request";
 import type { Game } from "../interfaces";
 import { useAuth } from '../context/AuthContext';
+import { fetchAllGames } from '../services/game';
 
 const base_url = "http://localhost:8088";
 const { Title } = Typography;
@@ -43,15 +44,21 @@ export default function Requestinfo() {
   const [Requestinfo, SetRequestinfo] = useState<Request[]>([]);
   const [game, Setgame] = useState<Game[]>([]);
   const [gameid, SetgameID] = useState<number | null>(null);
-  const { id } = useAuth(); //คนใช้งานระบบ
+  const { id, token } = useAuth(); //คนใช้งานระบบ
   const [useron, Setuseron] = useState<useronline | null>(null)
+  const authHeaders: Record<string, string> = {};
+  if (token) authHeaders["Authorization"] = `Bearer ${token}`;
+  if (id) authHeaders["X-User-ID"] = String(id);
 
-
-  async function UpdateGame(id: number, data: { status?: string }) {
+  // อนุมัติเกมที่ส่งตรวจแล้วขึ้นหน้าร้านทันที: submitted → approved → published
+  async function PublishGame(gameID: number) {
     try {
-      const response = await axios.put(`${base_url}/update-game/${id}`, data);
+      await axios.post(`${base_url}/games/${gameID}/status`, { status: "approved" }, { headers: authHeaders });
+      const response = await axios.post(`${base_url}/games/${gameID}/status`, { status: "published" }, { headers: authHeaders });
       console.log("อัปเดตสำเร็จ:", response.data);
       message.success("อัปเดตสถานะเกมสำเร็จ!");
+      SetgameID(null);
+      GetGame();
       return response.data;
     } catch (err) {
       console.error("update error:", err);
@@ -89,9 +96,8 @@ export default function Requestinfo() {
 
     async function GetGame() {
         try {
-        const response = await axios.get(`${base_url}/game`)
-        Setgame(response.data)
-        console.log(response.data)
+        const rows = await fetchAllGames({ base: base_url, params: { status: "submitted" }, headers: authHeaders })
+        Setgame(rows as Game[])
         } catch(err) {
         console.log('get game error',err)
         }  
@@ -100,7 +106,7 @@ export default function Requestinfo() {
         GetGame()
     }, [])
 
-    const pendingGames = game ? game.filter(g => g.status === "pending") : [];
+    const pendingGames = game ? game.filter(g => g.status === "submitted") : [];
     const useronline = useron?.role?.title === "Admin"
 
   return (<div><div>{useronline ? (
@@ -150,7 +156,7 @@ export default function Requestinfo() {
             type="primary"
             onClick={() => {
               if (gameid !== null) {
-                UpdateGame(gameid, { status: "approve" });
+                PublishGame(gameid);
               } else {
                 message.warning("กรุณาเลือกเกมก่อน");
               }
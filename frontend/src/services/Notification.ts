@@ -16,8 +16,17 @@ interface RawNotification {
   user?: User; User?: User;
 }
 
+// backend ผูกแจ้งเตือนกับผู้ใช้จาก token / X-User-ID (ไม่รับ user_id ใน query แล้ว)
+function authHeaders(userId?: number): Record<string, string> {
+  const h: Record<string, string> = {};
+  const token = localStorage.getItem("token");
+  if (token) h.Authorization = token.startsWith("Bearer ") ? token : `Bearer ${token}`;
+  if (userId) h["X-User-ID"] = String(userId);
+  return h;
+}
+
 export async function fetchNotifications(userId: number): Promise<AppNotification[]> {
-  const { data } = await api.get("/notifications", { params: { user_id: userId } });
+  const { data } = await api.get("/notifications", { params: { limit: 100 }, headers: authHeaders(userId) });
   const list = (Array.isArray(data) ? data : data?.items || []) as RawNotification[];
   return list.map((n) => ({
     ID: n.ID ?? n.id ?? 0,
@@ -31,30 +40,28 @@ export async function fetchNotifications(userId: number): Promise<AppNotificatio
   })) as AppNotification[];
 }
 
-export async function createNotification(payload: {
-  title: string;
-  message: string;
-  type: string;
-  user_id: number;
-  report_id?: number;
-}): Promise<AppNotification | null> {
+// ประกาศแจ้งเตือนระบบ (ผู้ดูแลที่มีสิทธิ์ users.manage) ไม่ส่ง role_ids = ทุกคน
+export async function createNotification(
+  payload: { title: string; message: string; role_ids?: number[] },
+  userId?: number
+): Promise<number | null> {
   try {
-    const res = await api.post("/notifications", payload);
-    return res.data as AppNotification;
+    const res = await api.post("/notifications", payload, { headers: authHeaders(userId) });
+    return res.data?.recipients ?? 0;
   } catch (err) {
     console.error("❌ createNotification error:", err);
     return null;
   }
 }
 
-export async function markNotificationRead(id: number): Promise<void> {
-  await api.put(`/notifications/${id}/read`);
+export async function markNotificationRead(id: number, userId?: number): Promise<void> {
+  await api.put(`/notifications/${id}/read`, null, { headers: authHeaders(userId) });
 }
 
 export async function markAllNotificationsRead(userId: number): Promise<void> {
-  await api.put(`/notifications/read-all`, { user_id: userId });
+  await api.put(`/notifications/read-all`, null, { headers: authHeaders(userId) });
 }
 
-export async function deleteNotification(id: number): Promise<void> {
-  await api.delete(`/notifications/${id}`);
+export async function deleteNotification(id: number, userId?: number): Promise<void> {
+  await api.delete(`/notifications/${id}`, { headers: authHeaders(userId) });
 }
\ No newline at end of file
@@ -23,6 +23,16 @@ async function parseResponse(res: Response) {
   return data;
 }
 
+/** /reports ทุกเส้นต้องล็อกอิน: แนบ token + X-User-ID ของผู้ใช้ปัจจุบัน */
+function authHeaders(userId?: number): Record<string, string> {
+  const h: Record<string, string> = {};
+  const token = localStorage.getItem("token");
+  if (token) h.Authorization = token.startsWith("Bearer ") ? token : `Bearer ${token}`;
+  const uid = userId || Number(localStorage.getItem("userid"));
+  if (uid) h["X-User-ID"] = String(uid);
+  return h;
+}
+
 /* ----------------------- ผู้ใช้ส่งคำร้อง (Report) ----------------------- */
 export async function createReport(payload: {
   title: string;
@@ -47,10 +57,8 @@ export async function createReport(payload: {
   const res = await fetch(`${API_URL}/reports`, {
     method: "POST",
     body: fd,
-    headers: {
-      // backend รองรับ X-User-ID → จะเช็คผู้ใช้จาก header ก่อน
-      "X-User-ID": String(userIdToSend),
-    },
+    // backend ใช้ผู้ใช้จาก token / X-User-ID เท่านั้น
+    headers: authHeaders(userIdToSend),
   });
 
   // backend คืน { data: report }
@@ -60,7 +68,7 @@ export async function createReport(payload: {
 /* ---------------------------- ดึงรายการ ---------------------------- */
 /** ดึงลิสต์สถานะ open (ใช้ในหน้า Admin หลัก) */
 export async function fetchReports(): Promise<any[]> {
-  const res = await fetch(`${API_URL}/reports?status=open&limit=200`);
+  const res = await fetch(`${API_URL}/reports?status=open&limit=200`, { headers: authHeaders() });
   const data = await parseResponse(res);
   return Array.isArray(data) ? data : data?.data || [];
 }
@@ -71,7 +79,8 @@ export async function fetchReportsByStatus(
   limit = 200
 ): Promise<any[]> {
   const res = await fetch(
-    `${API_URL}/reports?status=${encodeURIComponent(status)}&limit=${limit}`
+    `${API_URL}/reports?status=${encodeURIComponent(status)}&limit=${limit}`,
+    { headers: authHeaders() }
   );
   const data = await parseResponse(res);
   return Array.isArray(data) ? data : data?.data || [];
@@ -84,7 +93,7 @@ export async function fetchResolvedReports(): Promise<any[]> {
 
 /** ดึงรายงานตาม ID (ใช้ตอนเปิดดูจาก Notification) */
 export async function getReportByID(id: number): Promise<any> {
-  const res = await fetch(`${API_URL}/reports/${id}`);
+  const res = await fetch(`${API_URL}/reports/${id}`, { headers: authHeaders() });
   const data = await parseResponse(res);
   return data?.data ?? data; // เผื่อ backend คืน {data: ...}
 }
@@ -106,6 +115,7 @@ export async function replyReport(
   const res = await fetch(`${API_URL}/reports/${reportId}/reply`, {
     method: "POST",
     body: fd,
+    headers: authHeaders(),
   });
   await parseResponse(res);
 }
@@ -115,7 +125,7 @@ export async function resolveReport(reportId: number): Promise<any> {
   // ใช้ PUT /reports/:id {"resolve": true} แทน endpoint แอดมิน
   const res = await fetch(`${API_URL}/reports/${reportId}`, {
     method: "PUT",
-    headers: { "Content-Type": "application/json" },
+    headers: { ...authHeaders(), "Content-Type": "application/json" },
     body: JSON.stringify({ resolve: true }),
   });
   return parseResponse(res);
@@ -53,14 +53,40 @@ export interface MinimumSpec {
 
 /* ---------- Games ---------- */
 
+/**
+ * ดึง /game ครบทุกหน้า — backend คืนทีละหน้า (สูงสุด 100) และส่ง cursor หน้าถัดไปใน header X-Next-Cursor
+ * params = query อื่น ๆ ของ /game เช่น { status: "submitted" }
+ */
+export async function fetchAllGames(
+  opts: { base?: string; params?: Record<string, string>; headers?: Record<string, string>; credentials?: RequestCredentials } = {}
+): Promise<any[]> {
+  const all: any[] = [];
+  let cursor = "";
+  do {
+    const url = new URL(`${opts.base ?? API}/game`);
+    url.searchParams.set("limit", "100");
+    for (const [k, v] of Object.entries(opts.params ?? {})) url.searchParams.set(k, v);
+    if (cursor) url.searchParams.set("cursor", cursor);
+    const res = await fetch(url.toString(), { headers: opts.headers, credentials: opts.credentials });
+    if (!res.ok) {
+      throw new Error((await okText(res)) || "Failed to list games");
+    }
+    all.push(...asArray(await okJson<any>(res)));
+    cursor = res.headers.get("X-Next-Cursor") ?? "";
+  } while (cursor);
+  return all;
+}
+
 /** ลิสต์เกมทั้งหมด (พยายามใช้ /game เป็นหลัก และ fallback ไป /games) */
 export async function listGames(): Promise<Game[]> {
-  // try /game ก่อน (ตรงกับโปรเจกต์คุณ)
-  let res = await fetch(`${API}/game`, { credentials: "include" });
-  if (!res.ok) {
+  // try /game ก่อน (ตรงกับโปรเจกต์คุณ) — ไล่ครบทุกหน้า
+  try {
+    const rows = await fetchAllGames({ credentials: "include" });
+    return rows.map((r: any) => addAliases<Game>(r));
+  } catch {
     // fallback: /games (บางสาขาเคยใช้ path นี้)
-    res = await fetch(`${API}/games`, { credentials: "include" });
   }
+  const res = await fetch(`${API}/games`, { credentials: "include" });
   if (!res.ok) {
     throw new Error((await okText(res)) || "Failed to list games");
   }
@@ -1,5 +1,6 @@
 import type { Promotion } from "../interfaces/Promotion";
 import type { Game } from "../interfaces/Game";
+import { fetchAllGames } from "./game";
 
 const API_URL = import.meta.env.VITE_API_URL || "http://localhost:8088";
 
@@ -62,7 +63,6 @@ export async function deletePromotion(id: number, token?: string): Promise<void>
 }
 
 export async function listGames(): Promise<Game[]> {
-  const res = await fetch(`${API_URL}/game`);
-  return handleResponse<Game[]>(res);
+  return (await fetchAllGames({ base: API_URL })) as Game[];
 }
 
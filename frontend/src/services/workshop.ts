@@ -8,6 +8,7 @@ import type {
   ModRating,
   CreateModRatingRequest,
 } from "../interfaces";
+import { fetchAllGames } from "./game";
 
 const API_URL =
   (import.meta as any)?.env?.VITE_API_BASE ??
@@ -53,8 +54,7 @@ function addAliases<T extends Record<string, any>>(row: T) {
 
 // ------------------------------- Games -------------------------------
 export async function listGames(): Promise<Game[]> {
-  const res = await fetch(`${API_URL}/game`);
-  return handleResponse<Game[]>(res);
+  return (await fetchAllGames({ base: API_URL })) as Game[];
 }
 export async function getGame(id: number): Promise<Game> {
   const games = await listGames();